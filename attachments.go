@@ -0,0 +1,43 @@
+package kdbx
+
+import (
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+// Attachment è un allegato di una entry. In lettura viene riportato solo
+// il nome; Data serve a creare l'allegato quando la entry viene aggiunta
+// (importazioni)
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// entryAttachments ritorna i nomi degli allegati della entry
+func entryAttachments(entry *gokeepasslib.Entry) []Attachment {
+	var attachments []Attachment
+	for _, ref := range entry.Binaries {
+		attachments = append(attachments, Attachment{Name: ref.Name})
+	}
+	return attachments
+}
+
+// binaries ritorna il contenuto degli allegati del database, che nei
+// KDBX 4 sta nell'header interno e nei KDBX 3.1 nei metadati
+func (db *Database) binaries() gokeepasslib.Binaries {
+	if db.Header.IsKdbx4() {
+		if db.Content.InnerHeader != nil {
+			return db.Content.InnerHeader.Binaries
+		}
+		return nil
+	}
+	if db.Content.Meta != nil {
+		return db.Content.Meta.Binaries
+	}
+	return nil
+}
+
+// addAttachment salva il contenuto nel database e lo collega alla entry
+func (db *Database) addAttachment(entry *gokeepasslib.Entry, attachment Attachment) {
+	binary := db.AddBinary(attachment.Data)
+	entry.Binaries = append(entry.Binaries, binary.CreateReference(attachment.Name))
+}
@@ -0,0 +1,369 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Tipi di elemento di Bitwarden
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+	bitwardenSSHKey     = 5
+)
+
+// Tipi dei campi personalizzati di Bitwarden
+const (
+	bitwardenFieldText    = 0
+	bitwardenFieldHidden  = 1
+	bitwardenFieldBoolean = 2
+	bitwardenFieldLinked  = 3
+)
+
+// Algoritmi di derivazione della chiave degli export protetti da password
+const (
+	bitwardenPBKDF2   = 0
+	bitwardenArgon2id = 1
+)
+
+// Limiti dei parametri di derivazione, gli stessi accettati dai client
+// Bitwarden. I valori arrivano dal file: fuori da questi limiti la
+// derivazione andrebbe in panic (parallelismo 0), userebbe memoria senza
+// limite o non proteggerebbe nulla (0 iterazioni)
+const (
+	bitwardenPBKDF2MinIterations  = 5000
+	bitwardenPBKDF2MaxIterations  = 2000000
+	bitwardenArgon2MinIterations  = 2
+	bitwardenArgon2MaxIterations  = 10
+	bitwardenArgon2MinMemory      = 16 // MiB
+	bitwardenArgon2MaxMemory      = 1024
+	bitwardenArgon2MinParallelism = 1
+	bitwardenArgon2MaxParallelism = 16
+)
+
+// bitwardenExport è il file JSON esportato da Bitwarden
+type bitwardenExport struct {
+	Encrypted         bool              `json:"encrypted"`
+	PasswordProtected bool              `json:"passwordProtected"`
+	Salt              string            `json:"salt"`
+	KdfType           int               `json:"kdfType"`
+	KdfIterations     int               `json:"kdfIterations"`
+	KdfMemory         int               `json:"kdfMemory"` // MiB
+	KdfParallelism    int               `json:"kdfParallelism"`
+	KeyValidation     string            `json:"encKeyValidation_DO_NOT_EDIT"`
+	Data              string            `json:"data"`
+	Folders           []bitwardenFolder `json:"folders"`
+	Collections       []bitwardenFolder `json:"collections"`
+	Items             []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	Type          int                 `json:"type"`
+	Name          string              `json:"name"`
+	Notes         string              `json:"notes"`
+	FolderID      string              `json:"folderId"`
+	CollectionIDs []string            `json:"collectionIds"`
+	DeletedDate   string              `json:"deletedDate"`
+	Fields        []bitwardenField    `json:"fields"`
+	Login         *bitwardenLoginData `json:"login"`
+	Card          map[string]any      `json:"card"`
+	Identity      map[string]any      `json:"identity"`
+	SSHKey        map[string]any      `json:"sshKey"`
+	Attachments   []struct {
+		FileName string `json:"fileName"`
+		SizeName string `json:"sizeName"`
+	} `json:"attachments"`
+}
+
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+	Type  int    `json:"type"`
+}
+
+type bitwardenLoginData struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	TOTP     string `json:"totp"`
+	URIs     []struct {
+		URI string `json:"uri"`
+	} `json:"uris"`
+}
+
+// bitwardenCardFields e bitwardenIdentityFields associano le chiavi di
+// Bitwarden ai nomi dei campi della entry, nell'ordine in cui compaiono
+var bitwardenCardFields = []importField{
+	{"cardholderName", "Titolare", false},
+	{"brand", "Circuito", false},
+	{"number", "Numero carta", true},
+	{"code", "Codice di sicurezza", true},
+}
+
+var bitwardenIdentityFields = []importField{
+	{"title", "Titolo personale", false},
+	{"firstName", "Nome", false},
+	{"middleName", "Secondo nome", false},
+	{"lastName", "Cognome", false},
+	{"company", "Azienda", false},
+	{"email", "Email", false},
+	{"phone", "Telefono", false},
+	{"address1", "Indirizzo", false},
+	{"address2", "Indirizzo 2", false},
+	{"address3", "Indirizzo 3", false},
+	{"city", "Città", false},
+	{"state", "Provincia", false},
+	{"postalCode", "CAP", false},
+	{"country", "Paese", false},
+	{"ssn", "Codice fiscale", true},
+	{"passportNumber", "Passaporto", true},
+	{"licenseNumber", "Patente", true},
+}
+
+var bitwardenSSHKeyFields = []importField{
+	{"privateKey", "Chiave privata", true},
+	{"publicKey", "Chiave pubblica", false},
+	{"keyFingerprint", "Impronta", false},
+}
+
+// BitwardenNeedsPassword indica se l'export è cifrato con una password
+func BitwardenNeedsPassword(data []byte) (bool, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return false, fmt.Errorf("il file non è un export JSON di Bitwarden: %w", err)
+	}
+	return export.Encrypted, nil
+}
+
+// ParseBitwardenJSON converte un export JSON di Bitwarden, in chiaro o
+// protetto da password, in entries. Le cartelle (o le raccolte di
+// un'organizzazione) diventano gruppi; carte, identità e chiavi SSH
+// diventano campi aggiuntivi. Gli allegati non fanno parte dell'export e
+// vengono solo elencati nelle note
+func ParseBitwardenJSON(data []byte, password string) ([]Entry, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("il file non è un export JSON di Bitwarden: %w", err)
+	}
+
+	if export.Encrypted {
+		plain, err := export.decrypt(password)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(plain, &export); err != nil {
+			return nil, fmt.Errorf("contenuto dell'export non valido: %w", err)
+		}
+	}
+
+	groups := map[string]string{}
+	for _, folder := range export.Folders {
+		groups[folder.ID] = importGroupPath(folder.Name)
+	}
+	for _, collection := range export.Collections {
+		groups[collection.ID] = importGroupPath(collection.Name)
+	}
+
+	entries := make([]Entry, 0, len(export.Items))
+	for _, item := range export.Items {
+		if item.DeletedDate != "" {
+			continue
+		}
+
+		e := Entry{Title: item.Name, Notes: item.Notes}
+		switch {
+		case item.FolderID != "":
+			e.GroupPath = groups[item.FolderID]
+		case len(item.CollectionIDs) > 0:
+			e.GroupPath = groups[item.CollectionIDs[0]]
+		}
+
+		switch item.Type {
+		case bitwardenLogin:
+			if login := item.Login; login != nil {
+				e.Username = login.Username
+				e.Password = login.Password
+				for i, uri := range login.URIs {
+					if i == 0 {
+						e.URL = uri.URI
+						continue
+					}
+					// Stessa convenzione di KeePassXC per gli URL aggiuntivi
+					addImportField(&e, fmt.Sprintf("KP2A_URL_%d", i), uri.URI, false)
+				}
+				e.OTP = importOTP(login.TOTP, e)
+			}
+		case bitwardenCard:
+			addImportFields(&e, item.Card, bitwardenCardFields)
+			if month, year := stringValue(item.Card["expMonth"]), stringValue(item.Card["expYear"]); month != "" || year != "" {
+				addImportField(&e, "Scadenza carta", strings.Trim(month+"/"+year, "/"), false)
+			}
+		case bitwardenIdentity:
+			e.Username = stringValue(item.Identity["username"])
+			addImportFields(&e, item.Identity, bitwardenIdentityFields)
+		case bitwardenSSHKey:
+			addImportFields(&e, item.SSHKey, bitwardenSSHKeyFields)
+		}
+
+		for _, field := range item.Fields {
+			if field.Type == bitwardenFieldLinked {
+				// Punta a un altro campo dell'elemento: nessun valore proprio
+				continue
+			}
+			addImportField(&e, field.Name, stringValue(field.Value), field.Type == bitwardenFieldHidden)
+		}
+
+		if len(item.Attachments) > 0 {
+			names := make([]string, len(item.Attachments))
+			for i, attachment := range item.Attachments {
+				names[i] = strings.TrimSpace(attachment.FileName + " " + attachment.SizeName)
+			}
+			e.Notes = strings.TrimSpace(e.Notes + "\n\nAllegati rimasti in Bitwarden: " + strings.Join(names, ", "))
+		}
+
+		e.Title = importTitle(e)
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// stringValue converte un valore JSON (stringa, numero o booleano) in testo
+func stringValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// decrypt decifra un export protetto da password. Gli export cifrati con
+// la chiave dell'account non sono leggibili fuori da Bitwarden
+func (export *bitwardenExport) decrypt(password string) ([]byte, error) {
+	if !export.PasswordProtected || export.Salt == "" {
+		return nil, fmt.Errorf("export cifrato con la chiave dell'account Bitwarden: " +
+			"esporta di nuovo in JSON in chiaro o protetto da password")
+	}
+	if password == "" {
+		return nil, ErrImportPasswordRequired
+	}
+	if err := export.checkKdf(); err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	switch export.KdfType {
+	case bitwardenPBKDF2:
+		key = pbkdf2.Key([]byte(password), []byte(export.Salt), export.KdfIterations, 32, sha256.New)
+	case bitwardenArgon2id:
+		salt := sha256.Sum256([]byte(export.Salt))
+		key = argon2.IDKey([]byte(password), salt[:], uint32(export.KdfIterations),
+			uint32(export.KdfMemory)*1024, uint8(export.KdfParallelism), 32)
+	}
+
+	// La chiave viene estesa con HKDF-Expand in una chiave AES e una HMAC
+	encKey, macKey := make([]byte, 32), make([]byte, 32)
+	io.ReadFull(hkdf.Expand(sha256.New, key, []byte("enc")), encKey)
+	io.ReadFull(hkdf.Expand(sha256.New, key, []byte("mac")), macKey)
+
+	if _, err := decryptBitwardenString(export.KeyValidation, encKey, macKey); err != nil {
+		return nil, ErrImportWrongPassword
+	}
+	return decryptBitwardenString(export.Data, encKey, macKey)
+}
+
+// checkKdf verifica che i parametri di derivazione della chiave letti dal
+// file siano nei limiti di Bitwarden, prima di usarli
+func (export *bitwardenExport) checkKdf() error {
+	inRange := func(name string, value, min, max int) error {
+		if value < min || value > max {
+			return fmt.Errorf("parametro %s dell'export Bitwarden non valido: %d (atteso tra %d e %d)",
+				name, value, min, max)
+		}
+		return nil
+	}
+
+	switch export.KdfType {
+	case bitwardenPBKDF2:
+		return inRange("kdfIterations", export.KdfIterations, bitwardenPBKDF2MinIterations, bitwardenPBKDF2MaxIterations)
+	case bitwardenArgon2id:
+		if err := inRange("kdfIterations", export.KdfIterations, bitwardenArgon2MinIterations, bitwardenArgon2MaxIterations); err != nil {
+			return err
+		}
+		if err := inRange("kdfMemory", export.KdfMemory, bitwardenArgon2MinMemory, bitwardenArgon2MaxMemory); err != nil {
+			return err
+		}
+		return inRange("kdfParallelism", export.KdfParallelism, bitwardenArgon2MinParallelism, bitwardenArgon2MaxParallelism)
+	default:
+		return fmt.Errorf("derivazione della chiave Bitwarden non supportata: %d", export.KdfType)
+	}
+}
+
+// decryptBitwardenString decifra una stringa "2.iv|dati|mac"
+// (AES-256-CBC con HMAC-SHA256, encrypt-then-MAC)
+func decryptBitwardenString(value string, encKey, macKey []byte) ([]byte, error) {
+	encType, payload, found := strings.Cut(value, ".")
+	if !found || encType != "2" {
+		return nil, fmt.Errorf("cifratura Bitwarden non supportata")
+	}
+
+	parts := strings.Split(payload, "|")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("dato cifrato Bitwarden non valido")
+	}
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		var err error
+		if decoded[i], err = base64.StdEncoding.DecodeString(part); err != nil {
+			return nil, fmt.Errorf("dato cifrato Bitwarden non valido: %w", err)
+		}
+	}
+	iv, data, tag := decoded[0], decoded[1], decoded[2]
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(iv)
+	mac.Write(data)
+	if !hmac.Equal(mac.Sum(nil), tag) {
+		return nil, fmt.Errorf("autenticazione del dato cifrato fallita")
+	}
+	if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("dato cifrato Bitwarden non valido")
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// Padding PKCS#7 (già autenticato dall'HMAC)
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.HasSuffix(plain, bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, fmt.Errorf("padding del dato cifrato non valido")
+	}
+	return plain[:len(plain)-padding], nil
+}
@@ -0,0 +1,199 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// encryptBitwardenString cifra come Bitwarden ("2.iv|dati|mac")
+func encryptBitwardenString(t *testing.T, plain, encKey, macKey []byte) string {
+	t.Helper()
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatal(err)
+	}
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	data := append(append([]byte(nil), plain...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(iv)
+	mac.Write(data)
+	return "2." + base64.StdEncoding.EncodeToString(iv) + "|" +
+		base64.StdEncoding.EncodeToString(data) + "|" +
+		base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encryptBitwardenExport crea un export protetto da password con il
+// contenuto plain e i parametri di derivazione di header
+func encryptBitwardenExport(t *testing.T, header bitwardenExport, password string, plain []byte) []byte {
+	t.Helper()
+	var key []byte
+	switch header.KdfType {
+	case bitwardenPBKDF2:
+		key = pbkdf2.Key([]byte(password), []byte(header.Salt), header.KdfIterations, 32, sha256.New)
+	case bitwardenArgon2id:
+		salt := sha256.Sum256([]byte(header.Salt))
+		key = argon2.IDKey([]byte(password), salt[:], uint32(header.KdfIterations),
+			uint32(header.KdfMemory)*1024, uint8(header.KdfParallelism), 32)
+	}
+	encKey, macKey := make([]byte, 32), make([]byte, 32)
+	io.ReadFull(hkdf.Expand(sha256.New, key, []byte("enc")), encKey)
+	io.ReadFull(hkdf.Expand(sha256.New, key, []byte("mac")), macKey)
+
+	header.Encrypted = true
+	header.PasswordProtected = true
+	header.KeyValidation = encryptBitwardenString(t, []byte("validation"), encKey, macKey)
+	header.Data = encryptBitwardenString(t, plain, encKey, macKey)
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+const bitwardenTestItems = `{"folders":[{"id":"f1","name":"Lavoro"}],"items":[
+	{"type":1,"name":"Posta","folderId":"f1","login":{"username":"mario","password":"segreta","uris":[{"uri":"https://mail.example.com"}]}}
+]}`
+
+func TestParseBitwardenJSONEncrypted(t *testing.T) {
+	headers := []bitwardenExport{
+		{Salt: "sale", KdfType: bitwardenPBKDF2, KdfIterations: bitwardenPBKDF2MinIterations},
+		{Salt: "sale", KdfType: bitwardenArgon2id, KdfIterations: 2, KdfMemory: 16, KdfParallelism: 1},
+	}
+
+	for _, header := range headers {
+		data := encryptBitwardenExport(t, header, "export", []byte(bitwardenTestItems))
+
+		entries, err := ParseBitwardenJSON(data, "export")
+		if err != nil {
+			t.Fatalf("kdf %d: %v", header.KdfType, err)
+		}
+		if len(entries) != 1 || entries[0].Password != "segreta" || entries[0].GroupPath != "Lavoro" {
+			t.Errorf("kdf %d: entries = %+v", header.KdfType, entries)
+		}
+
+		if _, err := ParseBitwardenJSON(data, "sbagliata"); !errors.Is(err, ErrImportWrongPassword) {
+			t.Errorf("kdf %d, password errata: %v", header.KdfType, err)
+		}
+		if _, err := ParseBitwardenJSON(data, ""); !errors.Is(err, ErrImportPasswordRequired) {
+			t.Errorf("kdf %d, senza password: %v", header.KdfType, err)
+		}
+	}
+}
+
+func TestParseBitwardenJSONMalformedKdf(t *testing.T) {
+	// Intestazioni che prima mandavano in panic argon2, allocavano memoria
+	// senza limite o derivavano la chiave con 0 iterazioni
+	headers := map[string]string{
+		"argon2 senza parallelismo":  `"kdfType":1,"kdfIterations":3,"kdfMemory":64`,
+		"argon2 parallelismo 256":    `"kdfType":1,"kdfIterations":3,"kdfMemory":64,"kdfParallelism":256`,
+		"argon2 memoria enorme":      `"kdfType":1,"kdfIterations":3,"kdfMemory":4194304,"kdfParallelism":4`,
+		"argon2 memoria negativa":    `"kdfType":1,"kdfIterations":3,"kdfMemory":-1,"kdfParallelism":4`,
+		"argon2 0 iterazioni":        `"kdfType":1,"kdfIterations":0,"kdfMemory":64,"kdfParallelism":4`,
+		"pbkdf2 0 iterazioni":        `"kdfType":0,"kdfIterations":0`,
+		"pbkdf2 iterazioni negative": `"kdfType":0,"kdfIterations":-600000`,
+		"pbkdf2 iterazioni enormi":   `"kdfType":0,"kdfIterations":2147483647`,
+		"kdf sconosciuta":            `"kdfType":7,"kdfIterations":600000`,
+	}
+
+	for name, kdf := range headers {
+		data := []byte(`{"encrypted":true,"passwordProtected":true,"salt":"sale",` + kdf +
+			`,"encKeyValidation_DO_NOT_EDIT":"2.AAAA|AAAA|AAAA","data":"2.AAAA|AAAA|AAAA"}`)
+
+		_, err := ParseBitwardenJSON(data, "password")
+		if err == nil {
+			t.Errorf("%s: nessun errore", name)
+			continue
+		}
+		if errors.Is(err, ErrImportWrongPassword) {
+			t.Errorf("%s: la chiave è stata derivata prima di controllare i parametri", name)
+		}
+		if !strings.Contains(err.Error(), "kdf") && !strings.Contains(err.Error(), "non supportata") {
+			t.Errorf("%s: errore inatteso %q", name, err)
+		}
+	}
+}
+
+// checkBitwardenFixture verifica le entries di testdata/bitwarden.json
+func checkBitwardenFixture(t *testing.T, entries []Entry) {
+	t.Helper()
+	// L'item nel cestino viene ignorato
+	if len(entries) != 3 {
+		t.Fatalf("entries = %d, attese 3", len(entries))
+	}
+
+	posta := entryByTitle(t, entries, "Posta")
+	if posta.Username != "mario" || posta.Password != "bw-segreta" ||
+		posta.URL != "https://mail.example.com" || posta.GroupPath != "Lavoro" {
+		t.Errorf("Posta: %+v", posta)
+	}
+	if !strings.Contains(posta.OTP, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("Posta: OTP %q", posta.OTP)
+	}
+	checkField(t, posta, "KP2A_URL_1", "https://webmail.example.com", false)
+	checkField(t, posta, "Dominio", "EXAMPLE", false)
+	checkField(t, posta, "PIN", "4321", true)
+	checkField(t, posta, "Ricorda", "true", false)
+	if _, ok := fieldByKey(posta, "Collegato"); ok {
+		t.Error("Posta: importato il campo collegato")
+	}
+
+	// Le cartelle annidate usano la barra
+	visa := entryByTitle(t, entries, "Visa")
+	if visa.GroupPath != "Personale"+PathSeparator+"Banche" {
+		t.Errorf("Visa: gruppo %q", visa.GroupPath)
+	}
+	checkField(t, visa, "Numero carta", "4111111111111111", true)
+	checkField(t, visa, "Codice di sicurezza", "123", true)
+	checkField(t, visa, "Scadenza carta", "12/2027", false)
+
+	// Gli allegati non sono nell'export: restano citati nelle note
+	note := entryByTitle(t, entries, "Nota")
+	if !strings.HasPrefix(note.Notes, "Testo della nota") || !strings.Contains(note.Notes, "scansione.pdf") {
+		t.Errorf("Nota: note %q", note.Notes)
+	}
+}
+
+func TestParseBitwardenJSONFixture(t *testing.T) {
+	entries, err := ParseBitwardenJSON(readTestdata(t, "bitwarden.json"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBitwardenFixture(t, entries)
+}
+
+func TestParseBitwardenJSONEncryptedFixture(t *testing.T) {
+	// Stesso contenuto di bitwarden.json, protetto con la password "export"
+	data := readTestdata(t, "bitwarden_encrypted.json")
+
+	entries, err := ParseBitwardenJSON(data, "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBitwardenFixture(t, entries)
+
+	if _, err := ParseBitwardenJSON(data, "sbagliata"); !errors.Is(err, ErrImportWrongPassword) {
+		t.Errorf("password errata: %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	// md4 è deprecato perché MD4 non è sicuro come hash crittografico. Qui
+	// non protegge nulla: serve solo a calcolare l'hash NTLM (MD4 per
+	// definizione) con cui è indicizzato il dataset HIBP, che non cambia.
+	// I vettori dell'RFC 1320 in breach_test.go verificano l'implementazione
+	"golang.org/x/crypto/md4"
+)
+
+// BreachHashType è l'hash usato dal dataset Have I Been Pwned
+type BreachHashType int
+
+const (
+	BreachSHA1 BreachHashType = iota
+	BreachNTLM
+)
+
+// String ritorna il nome dell'hash
+func (t BreachHashType) String() string {
+	if t == BreachNTLM {
+		return "NTLM"
+	}
+	return "SHA-1"
+}
+
+// hexLength ritorna la lunghezza in caratteri esadecimali dell'hash
+func (t BreachHashType) hexLength() int {
+	if t == BreachNTLM {
+		return 32
+	}
+	return 40
+}
+
+// Il dataset HIBP si trova in due formati: un unico file ordinato per hash
+// ("HASH:CONTEGGIO" per riga, anche decine di GB) oppure una cartella di
+// range come quella del PwnedPasswordsDownloader, con un file per ogni
+// prefisso di 5 caratteri ("ABCDE.txt") che contiene "SUFFISSO:CONTEGGIO"
+const (
+	breachRangePrefixLength = 5
+	breachMaxLineLength     = 512
+)
+
+// BreachDatabase è un dataset HIBP locale, consultato con ricerca binaria
+// direttamente sul disco: nessun caricamento in memoria, nessuna rete
+type BreachDatabase struct {
+	path     string
+	hashType BreachHashType
+	isDir    bool
+	file     *os.File // Solo per il file unico
+	size     int64
+}
+
+// OpenBreachDatabase apre un file ordinato o una cartella di range HIBP,
+// riconoscendo dal contenuto se gli hash sono SHA-1 o NTLM
+func OpenBreachDatabase(path string) (*BreachDatabase, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("errore apertura dataset violazioni: %w", err)
+	}
+
+	b := &BreachDatabase{path: path, isDir: info.IsDir()}
+	if b.isDir {
+		if err := b.detectRangeDir(); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("errore apertura dataset violazioni: %w", err)
+	}
+	b.file, b.size = file, info.Size()
+
+	line, _, err := readLineAt(file, info.Size(), 0)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if b.hashType, err = detectHashType(line, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// detectRangeDir riconosce il tipo di hash dal primo file di range
+func (b *BreachDatabase) detectRangeDir() error {
+	names, err := filepath.Glob(filepath.Join(b.path, strings.Repeat("[0-9A-Fa-f]", breachRangePrefixLength)+".txt"))
+	if err != nil || len(names) == 0 {
+		return fmt.Errorf("la cartella non contiene file di range HIBP (es. 00000.txt)")
+	}
+
+	file, err := os.Open(names[0])
+	if err != nil {
+		return fmt.Errorf("errore apertura dataset violazioni: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("errore apertura dataset violazioni: %w", err)
+	}
+	line, _, err := readLineAt(file, info.Size(), 0)
+	if err != nil {
+		return err
+	}
+
+	b.hashType, err = detectHashType(line, breachRangePrefixLength)
+	return err
+}
+
+// detectHashType deduce il tipo di hash dalla lunghezza della prima riga,
+// tolti i caratteri del prefisso già contenuti nel nome del file
+func detectHashType(line []byte, prefixLength int) (BreachHashType, error) {
+	hash, _ := splitBreachLine(line)
+	switch len(hash) + prefixLength {
+	case BreachSHA1.hexLength():
+		return BreachSHA1, nil
+	case BreachNTLM.hexLength():
+		return BreachNTLM, nil
+	}
+	return 0, fmt.Errorf("formato non riconosciuto: attesi hash SHA-1 o NTLM esadecimali")
+}
+
+// HashType ritorna il tipo di hash del dataset
+func (b *BreachDatabase) HashType() BreachHashType {
+	return b.hashType
+}
+
+// Close chiude il dataset
+func (b *BreachDatabase) Close() error {
+	if b.file != nil {
+		return b.file.Close()
+	}
+	return nil
+}
+
+// HashPassword calcola l'hash esadecimale maiuscolo usato dal dataset
+func (b *BreachDatabase) HashPassword(password string) string {
+	if b.hashType == BreachNTLM {
+		// NTLM è MD4 della password in UTF-16LE
+		units := utf16.Encode([]rune(password))
+		encoded := make([]byte, 0, 2*len(units))
+		for _, unit := range units {
+			encoded = binary.LittleEndian.AppendUint16(encoded, unit)
+		}
+
+		h := md4.New()
+		h.Write(encoded)
+		return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+	}
+
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// Lookup cerca la password nel dataset e ritorna quante volte compare
+// nelle violazioni note (0 se il dataset non riporta il conteggio)
+func (b *BreachDatabase) Lookup(password string) (count int64, found bool, err error) {
+	hash := b.HashPassword(password)
+	if !b.isDir {
+		return searchSortedHashes(b.file, b.size, hash)
+	}
+
+	prefix, suffix := hash[:breachRangePrefixLength], hash[breachRangePrefixLength:]
+	file, err := os.Open(filepath.Join(b.path, prefix+".txt"))
+	if os.IsNotExist(err) {
+		// Range assente: prova con il prefisso minuscolo prima di arrendersi
+		file, err = os.Open(filepath.Join(b.path, strings.ToLower(prefix)+".txt"))
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("range %s mancante nel dataset: %w", prefix, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, false, fmt.Errorf("errore lettura dataset violazioni: %w", err)
+	}
+	return searchSortedHashes(file, info.Size(), suffix)
+}
+
+// searchSortedHashes esegue una ricerca binaria sugli offset del file:
+// da ogni punto di mezzo legge la prima riga completa successiva. Servono
+// circa log2(righe) letture, poche decine anche per un file da 30 GB
+func searchSortedHashes(r io.ReaderAt, size int64, target string) (int64, bool, error) {
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, start, err := readLineAt(r, size, mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if start >= hi {
+			// Nessuna riga inizia in [mid, hi)
+			hi = mid
+			continue
+		}
+
+		hash, count := splitBreachLine(line)
+		switch cmp := strings.Compare(strings.ToUpper(string(hash)), target); {
+		case cmp == 0:
+			return count, true, nil
+		case cmp < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, false, nil
+}
+
+// readLineAt ritorna la prima riga che inizia in offset o dopo, con il suo
+// offset di inizio. Oltre la fine del file ritorna start = size
+func readLineAt(r io.ReaderAt, size, offset int64) ([]byte, int64, error) {
+	start := offset
+	buf := make([]byte, breachMaxLineLength+1)
+
+	if offset > 0 {
+		// Una riga inizia in offset solo se il byte precedente è un a capo
+		n, err := r.ReadAt(buf[:breachMaxLineLength+1], offset-1)
+		if err != nil && err != io.EOF {
+			return nil, 0, fmt.Errorf("errore lettura dataset violazioni: %w", err)
+		}
+		newline := bytes.IndexByte(buf[:n], '\n')
+		if newline < 0 {
+			if offset-1+int64(n) >= size {
+				return nil, size, nil
+			}
+			return nil, 0, fmt.Errorf("riga più lunga di %d byte: il file non è un elenco di hash", breachMaxLineLength)
+		}
+		start = offset + int64(newline)
+	}
+	if start >= size {
+		return nil, size, nil
+	}
+
+	n, err := r.ReadAt(buf[:breachMaxLineLength], start)
+	if err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("errore lettura dataset violazioni: %w", err)
+	}
+	line := buf[:n]
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	} else if start+int64(n) < size {
+		return nil, 0, fmt.Errorf("riga più lunga di %d byte: il file non è un elenco di hash", breachMaxLineLength)
+	}
+
+	return line, start, nil
+}
+
+// splitBreachLine divide una riga "HASH:CONTEGGIO" (il conteggio è opzionale)
+func splitBreachLine(line []byte) ([]byte, int64) {
+	line = bytes.TrimRight(line, "\r")
+	hash, countText, found := bytes.Cut(line, []byte(":"))
+	if !found {
+		return bytes.TrimSpace(hash), 0
+	}
+
+	count, _ := strconv.ParseInt(string(bytes.TrimSpace(countText)), 10, 64)
+	return bytes.TrimSpace(hash), count
+}
+
+// BreachResult è una entry la cui password compare nel dataset
+type BreachResult struct {
+	Entry Entry
+	Count int64 // Occorrenze nelle violazioni (0 = non indicato)
+}
+
+// CheckEntries cerca le password di tutte le entries, una volta sola per
+// password distinta, e ritorna quelle trovate
+func (b *BreachDatabase) CheckEntries(entries []Entry) ([]BreachResult, error) {
+	type lookup struct {
+		count int64
+		found bool
+	}
+	cache := map[string]lookup{}
+
+	var results []BreachResult
+	for _, e := range entries {
+		if e.Password == "" {
+			continue
+		}
+
+		result, ok := cache[e.Password]
+		if !ok {
+			count, found, err := b.Lookup(e.Password)
+			if err != nil {
+				return nil, err
+			}
+			result = lookup{count, found}
+			cache[e.Password] = result
+		}
+
+		if result.found {
+			results = append(results, BreachResult{Entry: e, Count: result.count})
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// breachDatasetPref è la preferenza con il path dell'ultimo dataset HIBP
+const breachDatasetPref = "breachDatasetPath"
+
+// showBreachCheck confronta le password con un dataset Have I Been Pwned
+// scaricato in locale (file ordinato o cartella di range, SHA-1 o NTLM).
+// Il controllo è interamente offline
+func (mw *MainWindow) showBreachCheck() {
+	if mw.Database == nil {
+		dialog.ShowInformation("Violazioni", "Apri prima un database", mw.Window)
+		return
+	}
+
+	path := mw.App.Preferences().String(breachDatasetPref)
+	pathLabel := widget.NewLabel(path)
+	pathLabel.Wrapping = fyne.TextWrapBreak
+	if path == "" {
+		pathLabel.SetText("Nessun dataset selezionato")
+	}
+
+	setPath := func(uri fyne.URI) {
+		path = uri.Path()
+		pathLabel.SetText(path)
+	}
+
+	fileBtn := widget.NewButton("File ordinato...", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			setPath(reader.URI())
+		}, mw.Window)
+	})
+	folderBtn := widget.NewButton("Cartella di range...", func() {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil || dir == nil {
+				return
+			}
+			setPath(dir)
+		}, mw.Window)
+	})
+
+	info := widget.NewLabel("Usa il file pwned-passwords ordinato per hash (SHA-1 o NTLM) " +
+		"oppure la cartella creata dal PwnedPasswordsDownloader. Nessun dato lascia il computer.")
+	info.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	checkBtn := widget.NewButton("Controlla", func() {
+		if path == "" {
+			return
+		}
+		d.Hide()
+		mw.App.Preferences().SetString(breachDatasetPref, path)
+		mw.runBreachCheck(path)
+	})
+
+	content := container.NewVBox(
+		info,
+		container.NewHBox(fileBtn, folderBtn),
+		pathLabel,
+		checkBtn,
+	)
+
+	d = dialog.NewCustom("Controllo violazioni (offline)", "Annulla", content, mw.Window)
+	d.Resize(fyne.NewSize(520, 280))
+	d.Show()
+}
+
+// runBreachCheck esegue il controllo in background e segna le entries
+// trovate nella lista
+func (mw *MainWindow) runBreachCheck(path string) {
+	entries := mw.Database.GetAllEntries()
+
+	progress := dialog.NewCustomWithoutButtons("Controllo violazioni",
+		container.NewVBox(widget.NewLabel(fmt.Sprintf("Controllo di %d password...", len(entries))), widget.NewProgressBarInfinite()),
+		mw.Window)
+	progress.Show()
+
+	go func() {
+		results, hashType, err := checkBreaches(path, entries)
+
+		fyne.Do(func() {
+			progress.Hide()
+			if err != nil {
+				dialog.ShowError(err, mw.Window)
+				return
+			}
+
+			mw.breachHits = make(map[gokeepasslib.UUID]int64, len(results))
+			for _, result := range results {
+				mw.breachHits[result.Entry.UUID] = result.Count
+			}
+			mw.entryList.Refresh()
+			if mw.selectedEntry >= 0 {
+				mw.showEntryDetails(mw.selectedEntry)
+			}
+
+			if len(results) == 0 {
+				dialog.ShowInformation("Controllo violazioni",
+					fmt.Sprintf("Nessuna delle %d password compare nel dataset (%s)", len(entries), hashType), mw.Window)
+				return
+			}
+
+			lines := make([]string, 0, len(results))
+			for _, result := range results {
+				lines = append(lines, fmt.Sprintf("⚠ %s — %s", result.Entry.Title, breachCountText(result.Count)))
+			}
+			report := widget.NewLabel(strings.Join(lines, "\n"))
+			d := dialog.NewCustom(fmt.Sprintf("%d password compromesse", len(results)), "Chiudi",
+				container.NewVScroll(report), mw.Window)
+			d.Resize(fyne.NewSize(520, 400))
+			d.Show()
+		})
+	}()
+}
+
+// checkBreaches apre il dataset e controlla le entries
+func checkBreaches(path string, entries []kdbx.Entry) ([]kdbx.BreachResult, kdbx.BreachHashType, error) {
+	dataset, err := kdbx.OpenBreachDatabase(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer dataset.Close()
+
+	results, err := dataset.CheckEntries(entries)
+	return results, dataset.HashType(), err
+}
+
+// breachCountText descrive quante volte la password compare nelle violazioni
+func breachCountText(count int64) string {
+	if count <= 0 {
+		return "presente in violazioni note"
+	}
+	return fmt.Sprintf("vista %d volte in violazioni note", count)
+}
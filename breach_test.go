@@ -0,0 +1,201 @@
+package kdbx
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/md4"
+)
+
+func TestMD4Vectors(t *testing.T) {
+	// RFC 1320, appendice A.5
+	vectors := []struct{ input, sum string }{
+		{"", "31d6cfe0d16ae931b73c59d7e0c089c0"},
+		{"a", "bde52cb31de33e46245e05fbdbd6fb24"},
+		{"abc", "a448017aaf21d8525fc10ae87aa6729d"},
+		{"message digest", "d9130a8164549fe818874806e1c7014b"},
+		{"abcdefghijklmnopqrstuvwxyz", "d79e1c308aa5bbcdeea8ed63df412da9"},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", "043f8582f241db351ce627e153e7f0e4"},
+		{"12345678901234567890123456789012345678901234567890123456789012345678901234567890", "e33b4ddc9c38f2199c3e7b164fcc0536"},
+	}
+	for _, v := range vectors {
+		h := md4.New()
+		h.Write([]byte(v.input))
+		if got := hex.EncodeToString(h.Sum(nil)); got != v.sum {
+			t.Errorf("MD4(%q) = %s, atteso %s", v.input, got, v.sum)
+		}
+	}
+}
+
+func TestBreachHashPassword(t *testing.T) {
+	ntlm := &BreachDatabase{hashType: BreachNTLM}
+	if got, want := ntlm.HashPassword("password"), "8846F7EAEE8FB117AD06BDD830B7586C"; got != want {
+		t.Errorf("NTLM(password) = %s, atteso %s", got, want)
+	}
+
+	sha := &BreachDatabase{hashType: BreachSHA1}
+	if got, want := sha.HashPassword("password"), "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8"; got != want {
+		t.Errorf("SHA-1(password) = %s, atteso %s", got, want)
+	}
+}
+
+// sha1Hex ritorna l'hash SHA-1 esadecimale maiuscolo di una password
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachFile scrive un dataset ordinato "HASH:CONTEGGIO" con le
+// password date (conteggio = posizione + 1) e ritorna gli hash ordinati
+func writeBreachFile(t *testing.T, passwords []string, lineEnd string, trailingNewline bool) (string, []string) {
+	t.Helper()
+	var hashes []string
+	for _, p := range passwords {
+		hashes = append(hashes, sha1Hex(p))
+	}
+	sort.Strings(hashes)
+
+	var lines []string
+	for i, h := range hashes {
+		lines = append(lines, fmt.Sprintf("%s:%d", h, i+1))
+	}
+	content := strings.Join(lines, lineEnd)
+	if trailingNewline {
+		content += lineEnd
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, hashes
+}
+
+func TestSearchSortedHashes(t *testing.T) {
+	var passwords []string
+	for i := 0; i < 50; i++ {
+		passwords = append(passwords, fmt.Sprintf("password%d", i))
+	}
+
+	for _, format := range []struct {
+		name     string
+		lineEnd  string
+		trailing bool
+	}{
+		{"LF", "\n", true},
+		{"LF senza a capo finale", "\n", false},
+		{"CRLF", "\r\n", true},
+		{"CRLF senza a capo finale", "\r\n", false},
+	} {
+		t.Run(format.name, func(t *testing.T) {
+			path, hashes := writeBreachFile(t, passwords, format.lineEnd, format.trailing)
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			info, _ := file.Stat()
+
+			// Tutte le righe, comprese la prima e l'ultima
+			for i, hash := range hashes {
+				count, found, err := searchSortedHashes(file, info.Size(), hash)
+				if err != nil || !found || count != int64(i+1) {
+					t.Errorf("riga %d: count=%d found=%v err=%v", i+1, count, found, err)
+				}
+			}
+
+			// Prima della prima riga, dopo l'ultima e in mezzo
+			for _, missing := range []string{
+				strings.Repeat("0", 40),
+				strings.Repeat("F", 40),
+				hashes[10][:39] + "G",
+			} {
+				if _, found, err := searchSortedHashes(file, info.Size(), missing); err != nil || found {
+					t.Errorf("%s: found=%v err=%v, atteso non trovato", missing, found, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchSortedHashesSmallFiles(t *testing.T) {
+	for _, content := range []string{"", "\n"} {
+		_, found, err := searchSortedHashes(strings.NewReader(content), int64(len(content)), sha1Hex("x"))
+		if err != nil || found {
+			t.Errorf("%q: found=%v err=%v", content, found, err)
+		}
+	}
+
+	// Una sola riga, senza a capo finale
+	line := sha1Hex("solo") + ":7"
+	count, found, err := searchSortedHashes(strings.NewReader(line), int64(len(line)), sha1Hex("solo"))
+	if err != nil || !found || count != 7 {
+		t.Errorf("riga singola: count=%d found=%v err=%v", count, found, err)
+	}
+}
+
+func TestReadLineAt(t *testing.T) {
+	content := "AAA:1\nBBB:2\nCCC:3"
+	r := strings.NewReader(content)
+	size := int64(len(content))
+
+	tests := []struct {
+		offset int64
+		line   string
+		start  int64
+	}{
+		{0, "AAA:1", 0},
+		{1, "BBB:2", 6},   // A metà della prima riga: la successiva
+		{6, "BBB:2", 6},   // Proprio all'inizio di una riga
+		{5, "BBB:2", 6},   // Sull'a capo
+		{12, "CCC:3", 12}, // Ultima riga, senza a capo finale
+		{13, "", size},    // Dentro l'ultima riga: nessuna riga dopo
+		{size, "", size},
+	}
+	for _, tt := range tests {
+		line, start, err := readLineAt(r, size, tt.offset)
+		if err != nil || string(line) != tt.line || start != tt.start {
+			t.Errorf("offset %d: %q inizio %d err %v, atteso %q inizio %d",
+				tt.offset, line, start, err, tt.line, tt.start)
+		}
+	}
+
+	long := strings.Repeat("A", breachMaxLineLength+10) + "\nBBB:2\n"
+	if _, _, err := readLineAt(strings.NewReader(long), int64(len(long)), 0); err == nil {
+		t.Error("riga troppo lunga: nessun errore")
+	}
+}
+
+func TestBreachRangeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	hash := sha1Hex("password")
+	prefix, suffix := hash[:breachRangePrefixLength], hash[breachRangePrefixLength:]
+
+	content := strings.Repeat("0", 35) + ":1\r\n" + suffix + ":42\r\n" + strings.Repeat("F", 35) + ":3"
+	if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := OpenBreachDatabase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if b.HashType() != BreachSHA1 {
+		t.Fatalf("tipo hash %s, atteso SHA-1", b.HashType())
+	}
+
+	count, found, err := b.Lookup("password")
+	if err != nil || !found || count != 42 {
+		t.Errorf("password: count=%d found=%v err=%v", count, found, err)
+	}
+	if _, _, err := b.Lookup("un'altra password"); err == nil {
+		t.Error("range mancante: nessun errore")
+	}
+}
@@ -0,0 +1,106 @@
+package kdbx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BrowserCSVFormat è il formato dell'export CSV delle password di un browser
+type BrowserCSVFormat string
+
+const (
+	BrowserChromium BrowserCSVFormat = "Chrome, Edge, Brave, Opera"
+	BrowserFirefox  BrowserCSVFormat = "Firefox"
+	BrowserSafari   BrowserCSVFormat = "Safari"
+)
+
+// browserCSVColumns sono le colonne che identificano ogni formato, in
+// minuscolo. Vengono provati nell'ordine: Chromium ha solo colonne comuni
+var browserCSVColumns = []struct {
+	format  BrowserCSVFormat
+	columns []string
+}{
+	{BrowserFirefox, []string{"url", "username", "password", "httprealm", "formactionorigin"}},
+	{BrowserSafari, []string{"title", "url", "username", "password", "otpauth"}},
+	{BrowserChromium, []string{"name", "url", "username", "password"}},
+}
+
+// ParseBrowserCSV converte l'export CSV delle password di Chromium (Chrome,
+// Edge, Brave, Opera), Firefox o Safari, riconoscendo il formato. Le
+// entries non hanno gruppo: vanno nel gruppo di destinazione scelto
+func ParseBrowserCSV(data []byte, _ string) ([]Entry, error) {
+	// I browser usano sempre la virgola; la codifica viene riconosciuta
+	opts := DetectCSVOptions(data)
+	opts.Delimiter, opts.HasHeader = ',', true
+	parsed, err := ParseCSV(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	format, columns := detectBrowserColumns(parsed.Header)
+	if format == "" {
+		return nil, fmt.Errorf("il file non è un export CSV di Chrome, Firefox o Safari")
+	}
+
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	entries := make([]Entry, 0, len(parsed.Rows))
+	for _, row := range parsed.Rows {
+		e := Entry{
+			URL:      strings.TrimSpace(value(row, "url")),
+			Username: value(row, "username"),
+			Password: value(row, "password"),
+		}
+
+		switch format {
+		case BrowserChromium:
+			e.Title = strings.TrimSpace(value(row, "name"))
+			e.Notes = value(row, "note")
+		case BrowserFirefox:
+			// L'account Firefox stesso, non una password di un sito
+			if strings.HasPrefix(e.URL, "chrome://") {
+				continue
+			}
+			addImportField(&e, "Realm HTTP", value(row, "httprealm"), false)
+		case BrowserSafari:
+			e.Title = strings.TrimSpace(value(row, "title"))
+			e.Notes = value(row, "notes")
+			e.OTP = importOTP(value(row, "otpauth"), e)
+		}
+
+		if e.URL == "" && e.Username == "" && e.Password == "" {
+			continue
+		}
+		e.Title = importTitle(e)
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// detectBrowserColumns riconosce il formato dall'intestazione e ritorna
+// l'indice di ogni colonna (per nome in minuscolo)
+func detectBrowserColumns(header []string) (BrowserCSVFormat, map[string]int) {
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, candidate := range browserCSVColumns {
+		found := true
+		for _, name := range candidate.columns {
+			if _, ok := columns[name]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			return candidate.format, columns
+		}
+	}
+	return "", nil
+}
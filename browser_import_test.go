@@ -0,0 +1,72 @@
+package kdbx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBrowserCSV(t *testing.T) {
+	tests := []struct {
+		file  string
+		title string
+		entry Entry
+	}{
+		{"chrome.csv", "Banca", Entry{
+			Username: "mario.rossi", Password: "banca-segreta",
+			URL: "https://banca.example.it/", Notes: "PIN nel cassetto",
+		}},
+		{"chrome.csv", "example.com", Entry{
+			Username: "mario", Password: "chrome-segreta", URL: "https://example.com/login",
+		}},
+		{"firefox.csv", "example.com", Entry{
+			Username: "mario", Password: "firefox-segreta", URL: "https://example.com",
+		}},
+		{"firefox.csv", "intranet.example.com", Entry{
+			Username: "mario", Password: "intranet-segreta", URL: "https://intranet.example.com",
+		}},
+		{"safari.csv", "NAS", Entry{
+			Username: "admin", Password: "nas-segreta", URL: "https://nas.local/", Notes: "Nota NAS",
+		}},
+		{"safari.csv", "example.com (mario)", Entry{
+			Username: "mario", Password: "safari-segreta", URL: "https://example.com/",
+		}},
+	}
+
+	parsed := map[string][]Entry{}
+	for _, file := range []string{"chrome.csv", "firefox.csv", "safari.csv"} {
+		entries, err := ParseBrowserCSV(readTestdata(t, file), "")
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		parsed[file] = entries
+	}
+
+	for _, tt := range tests {
+		e := entryByTitle(t, parsed[tt.file], tt.title)
+		if e.Username != tt.entry.Username || e.Password != tt.entry.Password ||
+			e.URL != tt.entry.URL || e.Notes != tt.entry.Notes {
+			t.Errorf("%s, %s: %+v", tt.file, tt.title, e)
+		}
+	}
+
+	// Le app Android senza credenziali prendono il titolo dal pacchetto
+	if len(parsed["chrome.csv"]) != 3 {
+		t.Errorf("chrome: entries = %d, attese 3", len(parsed["chrome.csv"]))
+	}
+	entryByTitle(t, parsed["chrome.csv"], "com.example.app")
+
+	// Le righe interne di Firefox (chrome://) vengono saltate, il realm
+	// HTTP resta in un campo
+	if len(parsed["firefox.csv"]) != 2 {
+		t.Errorf("firefox: entries = %d, attese 2", len(parsed["firefox.csv"]))
+	}
+	checkField(t, entryByTitle(t, parsed["firefox.csv"], "intranet.example.com"), "Realm HTTP", "Area riservata", false)
+
+	// Safari esporta con CRLF e la colonna OTPAuth
+	if otp := entryByTitle(t, parsed["safari.csv"], "example.com (mario)").OTP; !strings.Contains(otp, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("safari: OTP %q", otp)
+	}
+	if notes := entryByTitle(t, parsed["safari.csv"], "NAS").Notes; strings.ContainsRune(notes, '\r') {
+		t.Errorf("safari: note %q con \\r", notes)
+	}
+}
@@ -0,0 +1,354 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// CSVField è il campo della entry a cui corrisponde una colonna del CSV
+type CSVField int
+
+const (
+	CSVIgnore CSVField = iota
+	CSVTitle
+	CSVUsername
+	CSVPassword
+	CSVURL
+	CSVNotes
+	CSVGroup
+	CSVTOTP
+	CSVTags
+)
+
+// CSVFields elenca i campi nell'ordine proposto nella mappatura
+var CSVFields = []CSVField{CSVIgnore, CSVTitle, CSVUsername, CSVPassword, CSVURL, CSVNotes, CSVGroup, CSVTOTP, CSVTags}
+
+// Label ritorna il nome del campo per l'interfaccia
+func (f CSVField) Label() string {
+	switch f {
+	case CSVTitle:
+		return "Titolo"
+	case CSVUsername:
+		return "Username"
+	case CSVPassword:
+		return "Password"
+	case CSVURL:
+		return "URL"
+	case CSVNotes:
+		return "Note"
+	case CSVGroup:
+		return "Gruppo"
+	case CSVTOTP:
+		return "TOTP"
+	case CSVTags:
+		return "Tag"
+	default:
+		return "(ignora)"
+	}
+}
+
+// csvHeaderAliases riconosce le intestazioni degli export più comuni
+// (KeePassXC, Bitwarden, browser, LastPass) e quelle in italiano
+var csvHeaderAliases = map[string]CSVField{
+	"title":          CSVTitle,
+	"titolo":         CSVTitle,
+	"name":           CSVTitle,
+	"nome":           CSVTitle,
+	"account":        CSVTitle,
+	"username":       CSVUsername,
+	"user":           CSVUsername,
+	"user name":      CSVUsername,
+	"login":          CSVUsername,
+	"login_username": CSVUsername,
+	"email":          CSVUsername,
+	"utente":         CSVUsername,
+	"password":       CSVPassword,
+	"pass":           CSVPassword,
+	"login_password": CSVPassword,
+	"url":            CSVURL,
+	"uri":            CSVURL,
+	"login_uri":      CSVURL,
+	"website":        CSVURL,
+	"web site":       CSVURL,
+	"sito":           CSVURL,
+	"notes":          CSVNotes,
+	"note":           CSVNotes,
+	"extra":          CSVNotes,
+	"comments":       CSVNotes,
+	"group":          CSVGroup,
+	"gruppo":         CSVGroup,
+	"folder":         CSVGroup,
+	"cartella":       CSVGroup,
+	"grouping":       CSVGroup,
+	"path":           CSVGroup,
+	"totp":           CSVTOTP,
+	"otp":            CSVTOTP,
+	"otpauth":        CSVTOTP,
+	"login_totp":     CSVTOTP,
+	"tags":           CSVTags,
+	"tag":            CSVTags,
+}
+
+// CSVEncoding è la codifica dei caratteri del file
+type CSVEncoding string
+
+const (
+	CSVUTF8        CSVEncoding = "UTF-8"
+	CSVUTF16LE     CSVEncoding = "UTF-16 LE"
+	CSVUTF16BE     CSVEncoding = "UTF-16 BE"
+	CSVWindows1252 CSVEncoding = "Windows-1252"
+)
+
+// CSVEncodings elenca le codifiche selezionabili
+var CSVEncodings = []CSVEncoding{CSVUTF8, CSVUTF16LE, CSVUTF16BE, CSVWindows1252}
+
+// decoder ritorna il decoder della codifica (nil per UTF-8)
+func (enc CSVEncoding) decoder() *encoding.Decoder {
+	switch enc {
+	case CSVUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
+	case CSVUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
+	case CSVWindows1252:
+		return charmap.Windows1252.NewDecoder()
+	}
+	return nil
+}
+
+// csvDelimiters sono i separatori provati dal rilevamento automatico
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// csvSampleRows è il numero di righe usate per rilevare il separatore
+const csvSampleRows = 20
+
+// CSVOptions descrive come leggere il file
+type CSVOptions struct {
+	Delimiter rune
+	Encoding  CSVEncoding
+	HasHeader bool
+}
+
+// CSVData è il contenuto di un CSV pronto per la mappatura delle colonne
+type CSVData struct {
+	Options CSVOptions
+	Header  []string   // Intestazioni (nomi generati se il file non le ha)
+	Rows    [][]string // Righe di dati, tutte lunghe quanto Header
+}
+
+// DetectCSVOptions rileva codifica, separatore e intestazione del file
+func DetectCSVOptions(data []byte) CSVOptions {
+	opts := CSVOptions{Delimiter: ',', Encoding: detectCSVEncoding(data)}
+
+	text, err := decodeCSV(data, opts.Encoding)
+	if err != nil {
+		return opts
+	}
+
+	// Vince il separatore che divide più righe nello stesso numero (>1)
+	// di colonne
+	best := 0
+	for _, delimiter := range csvDelimiters {
+		if score := delimiterScore(text, delimiter); score > best {
+			best = score
+			opts.Delimiter = delimiter
+		}
+	}
+
+	reader := newCSVReader(text, opts.Delimiter)
+	if first, err := reader.Read(); err == nil {
+		// Il file ha un'intestazione se almeno due colonne hanno un nome noto
+		known := 0
+		for _, field := range GuessCSVMapping(first) {
+			if field != CSVIgnore {
+				known++
+			}
+		}
+		opts.HasHeader = known >= 2
+	}
+
+	return opts
+}
+
+// detectCSVEncoding riconosce la codifica dal BOM; senza BOM un file che
+// non è UTF-8 valido viene letto come Windows-1252 (Excel su Windows)
+func detectCSVEncoding(data []byte) CSVEncoding {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return CSVUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return CSVUTF16BE
+	case utf8.Valid(data):
+		return CSVUTF8
+	}
+	return CSVWindows1252
+}
+
+// delimiterScore conta le righe del campione con lo stesso numero di
+// colonne della prima, se sono almeno due
+func delimiterScore(text []byte, delimiter rune) int {
+	reader := newCSVReader(text, delimiter)
+	reader.FieldsPerRecord = -1
+
+	score, columns := 0, 0
+	for i := 0; i < csvSampleRows; i++ {
+		record, err := reader.Read()
+		if err != nil {
+			break
+		}
+		if i == 0 {
+			columns = len(record)
+		}
+		if columns < 2 || len(record) != columns {
+			continue
+		}
+		score++
+	}
+	return score
+}
+
+// decodeCSV converte il file in UTF-8 togliendo il BOM
+func decodeCSV(data []byte, enc CSVEncoding) ([]byte, error) {
+	if decoder := enc.decoder(); decoder != nil {
+		var err error
+		if data, err = decoder.Bytes(data); err != nil {
+			return nil, fmt.Errorf("errore decodifica %s: %w", enc, err)
+		}
+	}
+	return bytes.TrimPrefix(data, []byte("\ufeff")), nil
+}
+
+// newCSVReader crea un lettore tollerante verso le virgolette irregolari
+func newCSVReader(text []byte, delimiter rune) *csv.Reader {
+	reader := csv.NewReader(bytes.NewReader(text))
+	reader.Comma = delimiter
+	reader.LazyQuotes = true
+	return reader
+}
+
+// ParseCSV legge il file con le opzioni indicate
+func ParseCSV(data []byte, opts CSVOptions) (*CSVData, error) {
+	text, err := decodeCSV(data, opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newCSVReader(text, opts.Delimiter)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("errore lettura CSV: %w", err)
+	}
+
+	result := &CSVData{Options: opts}
+	if opts.HasHeader && len(records) > 0 {
+		result.Header = records[0]
+		records = records[1:]
+	}
+
+	// Tutte le righe vengono portate al numero massimo di colonne
+	columns := len(result.Header)
+	for _, record := range records {
+		columns = max(columns, len(record))
+	}
+	for i := len(result.Header); i < columns; i++ {
+		result.Header = append(result.Header, fmt.Sprintf("Colonna %d", i+1))
+	}
+	for _, record := range records {
+		if isBlankRecord(record) {
+			continue
+		}
+		row := make([]string, columns)
+		copy(row, record)
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, nil
+}
+
+// isBlankRecord indica se la riga non contiene dati
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// GuessCSVMapping propone la mappatura delle colonne dalle intestazioni.
+// Ogni campo viene assegnato una volta sola, alla prima colonna che lo usa
+func GuessCSVMapping(header []string) []CSVField {
+	mapping := make([]CSVField, len(header))
+	used := map[CSVField]bool{}
+
+	for i, name := range header {
+		field, ok := csvHeaderAliases[strings.ToLower(strings.TrimSpace(name))]
+		if ok && !used[field] {
+			mapping[i] = field
+			used[field] = true
+		}
+	}
+	return mapping
+}
+
+// Entries converte le righe in entries secondo la mappatura. Più colonne
+// mappate sulle note vengono unite; le entries senza titolo prendono l'host
+// dell'URL o lo username
+func (d *CSVData) Entries(mapping []CSVField) ([]Entry, error) {
+	mapped := false
+	for _, field := range mapping {
+		if field != CSVIgnore {
+			mapped = true
+		}
+	}
+	if !mapped {
+		return nil, fmt.Errorf("nessuna colonna associata a un campo")
+	}
+
+	entries := make([]Entry, 0, len(d.Rows))
+	for _, row := range d.Rows {
+		var e Entry
+		var notes []string
+		var otp string
+
+		for i, value := range row {
+			if i >= len(mapping) {
+				break
+			}
+			switch mapping[i] {
+			case CSVTitle:
+				e.Title = strings.TrimSpace(value)
+			case CSVUsername:
+				e.Username = value
+			case CSVPassword:
+				e.Password = value
+			case CSVURL:
+				e.URL = strings.TrimSpace(value)
+			case CSVNotes:
+				if value != "" {
+					notes = append(notes, value)
+				}
+			case CSVGroup:
+				e.GroupPath = importGroupPath(value)
+			case CSVTOTP:
+				otp = value
+			case CSVTags:
+				e.Tags = ParseTags(value)
+			}
+		}
+
+		e.Notes = strings.Join(notes, "\n")
+		e.Title = importTitle(e)
+		e.OTP = importOTP(otp, e)
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
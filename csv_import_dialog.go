@@ -0,0 +1,230 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// csvPreviewRows è il numero di entries mostrate nell'anteprima
+const csvPreviewRows = 50
+
+// csvDelimiterLabels sono i separatori selezionabili, con il loro nome
+var csvDelimiterLabels = []struct {
+	label     string
+	delimiter rune
+}{
+	{"Virgola (,)", ','},
+	{"Punto e virgola (;)", ';'},
+	{"Tabulazione", '\t'},
+	{"Barra verticale (|)", '|'},
+}
+
+// csvPreviewColumns sono le colonne dell'anteprima delle entries
+var csvPreviewColumns = []string{"Titolo", "Username", "Password", "URL", "Gruppo", "Tag", "TOTP"}
+
+// showCSVImport importa un file CSV: dopo la scelta del file mostra la
+// mappatura delle colonne con l'anteprima delle entries risultanti
+func (mw *MainWindow) showCSVImport() {
+	if mw.Database == nil {
+		dialog.ShowInformation("Importa CSV", "Apri o crea prima un database", mw.Window)
+		return
+	}
+
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("errore lettura file: %w", err), mw.Window)
+			return
+		}
+		mw.showCSVMapping(reader.URI().Name(), data)
+	}, mw.Window)
+}
+
+// showCSVMapping mostra il passo di mappatura: opzioni di lettura, campo
+// di ogni colonna e anteprima. Le opzioni rilevate si possono correggere
+func (mw *MainWindow) showCSVMapping(name string, data []byte) {
+	opts := kdbx.DetectCSVOptions(data)
+	var parsed *kdbx.CSVData
+	var mapping []kdbx.CSVField
+	var preview []kdbx.Entry
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(preview) + 1, len(csvPreviewColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Template colonna")
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(csvPreviewColumns[id.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{}
+			label.SetText(csvPreviewCell(preview[id.Row-1], id.Col))
+		},
+	)
+	for i := range csvPreviewColumns {
+		table.SetColumnWidth(i, 130)
+	}
+
+	updatePreview := func() {
+		preview = preview[:0]
+		entries, err := parsed.Entries(mapping)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+		} else {
+			statusLabel.SetText(fmt.Sprintf("%d entries da importare", len(entries)))
+			preview = entries[:min(len(entries), csvPreviewRows)]
+		}
+		table.Refresh()
+	}
+
+	// Ogni colonna ha la sua select; vengono ricreate se cambia la lettura
+	mappingForm := widget.NewForm()
+	fieldLabels := make([]string, len(kdbx.CSVFields))
+	for i, field := range kdbx.CSVFields {
+		fieldLabels[i] = field.Label()
+	}
+
+	reparse := func() {
+		var err error
+		if parsed, err = kdbx.ParseCSV(data, opts); err != nil {
+			parsed = &kdbx.CSVData{Options: opts}
+			statusLabel.SetText(err.Error())
+		}
+
+		mapping = kdbx.GuessCSVMapping(parsed.Header)
+		if !opts.HasHeader {
+			mapping = make([]kdbx.CSVField, len(parsed.Header))
+		}
+
+		mappingForm.Items = nil
+		for i, header := range parsed.Header {
+			column := i
+			fieldSelect := widget.NewSelect(fieldLabels, func(string) {})
+			fieldSelect.SetSelectedIndex(int(mapping[column]))
+			fieldSelect.OnChanged = func(string) {
+				mapping[column] = kdbx.CSVFields[fieldSelect.SelectedIndex()]
+				updatePreview()
+			}
+			// Nessun valore di esempio accanto al nome: una colonna non
+			// ancora mappata può contenere password o seed in chiaro.
+			// I valori si vedono nell'anteprima, con la password mascherata
+			mappingForm.Append(header, fieldSelect)
+		}
+		mappingForm.Refresh()
+
+		updatePreview()
+	}
+
+	delimiterOptions := make([]string, len(csvDelimiterLabels))
+	for i, d := range csvDelimiterLabels {
+		delimiterOptions[i] = d.label
+	}
+	delimiterSelect := widget.NewSelect(delimiterOptions, nil)
+	for i, d := range csvDelimiterLabels {
+		if d.delimiter == opts.Delimiter {
+			delimiterSelect.SetSelectedIndex(i)
+		}
+	}
+
+	encodingOptions := make([]string, len(kdbx.CSVEncodings))
+	for i, enc := range kdbx.CSVEncodings {
+		encodingOptions[i] = string(enc)
+	}
+	encodingSelect := widget.NewSelect(encodingOptions, nil)
+	encodingSelect.SetSelected(string(opts.Encoding))
+
+	headerCheck := widget.NewCheck("La prima riga contiene le intestazioni", nil)
+	headerCheck.SetChecked(opts.HasHeader)
+
+	reparse()
+
+	delimiterSelect.OnChanged = func(string) {
+		opts.Delimiter = csvDelimiterLabels[delimiterSelect.SelectedIndex()].delimiter
+		reparse()
+	}
+	encodingSelect.OnChanged = func(value string) {
+		opts.Encoding = kdbx.CSVEncoding(value)
+		reparse()
+	}
+	headerCheck.OnChanged = func(checked bool) {
+		opts.HasHeader = checked
+		reparse()
+	}
+
+	var d dialog.Dialog
+	importBtn := widget.NewButton("Avanti", func() {
+		entries, err := parsed.Entries(mapping)
+		if err != nil {
+			dialog.ShowError(err, mw.Window)
+			return
+		}
+		d.Hide()
+		mw.confirmImport("Importa CSV", entries)
+	})
+
+	options := widget.NewForm(
+		widget.NewFormItem("Separatore", delimiterSelect),
+		widget.NewFormItem("Codifica", encodingSelect),
+		widget.NewFormItem("", headerCheck),
+	)
+
+	content := container.NewBorder(
+		container.NewVBox(options, widget.NewLabel("Colonne")),
+		container.NewVBox(statusLabel, importBtn),
+		nil,
+		nil,
+		container.NewVSplit(container.NewVScroll(mappingForm), table),
+	)
+
+	d = dialog.NewCustom(fmt.Sprintf("Importa CSV — %s", name), "Annulla", content, mw.Window)
+	d.Resize(fyne.NewSize(900, 700))
+	d.Show()
+}
+
+// csvPreviewCell ritorna il valore di una cella dell'anteprima; la password
+// non viene mai mostrata
+func csvPreviewCell(e kdbx.Entry, column int) string {
+	switch column {
+	case 0:
+		return e.Title
+	case 1:
+		return e.Username
+	case 2:
+		if e.Password == "" {
+			return ""
+		}
+		return "••••••"
+	case 3:
+		return e.URL
+	case 4:
+		return e.GroupPath
+	case 5:
+		return strings.Join(e.Tags, ", ")
+	default:
+		if e.OTP != "" {
+			return "sì"
+		}
+		return ""
+	}
+}
@@ -0,0 +1,72 @@
+package kdbx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCSVWindows1252(t *testing.T) {
+	// Export di Excel: punto e virgola, Windows-1252 e intestazioni in italiano
+	data := readTestdata(t, "generico.csv")
+
+	opts := DetectCSVOptions(data)
+	want := CSVOptions{Delimiter: ';', Encoding: CSVWindows1252, HasHeader: true}
+	if opts != want {
+		t.Fatalf("opzioni %+v, attese %+v", opts, want)
+	}
+
+	csvData, err := ParseCSV(data, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping := GuessCSVMapping(csvData.Header)
+	wantMapping := []CSVField{CSVTitle, CSVUsername, CSVPassword, CSVURL, CSVNotes, CSVGroup}
+	if !reflect.DeepEqual(mapping, wantMapping) {
+		t.Fatalf("mappatura %v, attesa %v", mapping, wantMapping)
+	}
+
+	entries, err := csvData.Entries(mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, attese 2", len(entries))
+	}
+
+	caffe := entryByTitle(t, entries, "Caffè")
+	if caffe.Password != "caffè-segreta" || caffe.Notes != "Più note; con punto e virgola" ||
+		caffe.GroupPath != "Personale"+PathSeparator+"Bar" {
+		t.Errorf("Caffè: %+v", caffe)
+	}
+	if banca := entryByTitle(t, entries, "Banca"); banca.Username != "m.rossi" || banca.URL != "https://banca.example.it" {
+		t.Errorf("Banca: %+v", banca)
+	}
+}
+
+func TestParseCSVWithoutHeader(t *testing.T) {
+	data := []byte("Posta,mario,segreta\nForum,vecchio,altra\n")
+
+	opts := DetectCSVOptions(data)
+	if opts.HasHeader {
+		t.Fatal("rilevata un'intestazione inesistente")
+	}
+	csvData, err := ParseCSV(data, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(csvData.Header) != 3 || len(csvData.Rows) != 2 {
+		t.Fatalf("intestazione %v, righe %d", csvData.Header, len(csvData.Rows))
+	}
+
+	// Senza intestazione nessuna colonna è mappata finché non lo fa l'utente
+	if _, err := csvData.Entries(GuessCSVMapping(csvData.Header)); err == nil {
+		t.Error("nessuna colonna mappata: nessun errore")
+	}
+	entries, err := csvData.Entries([]CSVField{CSVTitle, CSVUsername, CSVPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forum := entryByTitle(t, entries, "Forum"); forum.Username != "vecchio" || forum.Password != "altra" {
+		t.Errorf("Forum: %+v", forum)
+	}
+}
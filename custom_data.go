@@ -0,0 +1,80 @@
+package kdbx
+
+import (
+	"encoding/base64"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+// customDataPrefix identifica le chiavi CustomData scritte da KeePassGo.
+// KeePassXC e KeePass ignorano le chiavi che non conoscono ma le preservano
+const customDataPrefix = "KeePassGo/"
+
+// customData ritorna il valore della chiave nel CustomData del database
+func (db *Database) customData(key string) (string, bool) {
+	if db.Content == nil || db.Content.Meta == nil {
+		return "", false
+	}
+
+	for _, item := range db.Content.Meta.CustomData {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return "", false
+}
+
+// setCustomData imposta una chiave nel CustomData del database; un valore
+// vuoto rimuove la chiave
+func (db *Database) setCustomData(key, value string) {
+	if db.Content.Meta == nil {
+		db.Content.Meta = gokeepasslib.NewMetaData()
+	}
+	meta := db.Content.Meta
+
+	for i, item := range meta.CustomData {
+		if item.Key != key {
+			continue
+		}
+		if value == "" {
+			meta.CustomData = append(meta.CustomData[:i], meta.CustomData[i+1:]...)
+		} else {
+			meta.CustomData[i].Value = value
+		}
+		return
+	}
+
+	if value != "" {
+		meta.CustomData = append(meta.CustomData, gokeepasslib.CustomData{Key: key, Value: value})
+	}
+}
+
+// groupCustomDataKey ritorna la chiave CustomData di un dato del gruppo.
+// I gruppi di gokeepasslib non hanno un campo CustomData (quello presente
+// nel file viene perso in lettura), quindi i dati per gruppo vanno nel
+// CustomData del database, con l'UUID del gruppo nella chiave
+func groupCustomDataKey(name string, uuid gokeepasslib.UUID) string {
+	return customDataPrefix + name + "/" + base64.StdEncoding.EncodeToString(uuid[:])
+}
+
+// groupChain ritorna i gruppi dal root fino a quello indicato dal path,
+// per risolvere le impostazioni ereditate. Se il gruppo non esiste ancora
+// (verrà creato con la entry) la catena si ferma all'ultimo esistente
+func (db *Database) groupChain(path string) []*gokeepasslib.Group {
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return nil
+	}
+
+	group := &db.Content.Root.Groups[0]
+	chain := []*gokeepasslib.Group{group}
+
+	for _, name := range db.relativeGroupNames(path) {
+		group = findSubGroup(group, name)
+		if group == nil {
+			break
+		}
+		chain = append(chain, group)
+	}
+
+	return chain
+}
@@ -0,0 +1,224 @@
+package kdbx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// DuplicateCluster è un gruppo di entries con la stessa credenziale: stesso
+// sito, username e password. Entries va dalla modificata più di recente,
+// che è quella proposta da tenere
+type DuplicateCluster struct {
+	Host     string // Host normalizzato (vuoto per le entries senza URL)
+	Username string
+	Entries  []Entry
+}
+
+// FindDuplicates cerca i doppioni tra le entries del database
+func (db *Database) FindDuplicates() []DuplicateCluster {
+	return FindDuplicates(db.GetAllEntries())
+}
+
+// FindDuplicates raggruppa le entries con lo stesso host (normalizzato:
+// minuscolo, senza "www." e senza schema), username e password. Entries
+// dello stesso sito e username con password diverse non sono doppioni.
+// Una entry senza URL si unisce al gruppo con le sue stesse credenziali;
+// altrimenti le entries senza URL sono doppioni se hanno titolo, username
+// e password uguali. I gruppi sono in ordine di host
+func FindDuplicates(entries []Entry) []DuplicateCluster {
+	clusters := map[string]*DuplicateCluster{}
+	var keys []string
+	add := func(key, host string, e Entry) {
+		cluster, ok := clusters[key]
+		if !ok {
+			cluster = &DuplicateCluster{Host: host, Username: e.Username}
+			clusters[key] = cluster
+			keys = append(keys, key)
+		}
+		cluster.Entries = append(cluster.Entries, e)
+	}
+
+	// Credenziali (username e password) presenti nei gruppi per host
+	credentials := map[string]string{}
+	var withoutHost []Entry
+	for _, e := range entries {
+		if duplicateKey(e) == "" {
+			withoutHost = append(withoutHost, e)
+			continue
+		}
+		key := urlHost(e.URL) + "\x00" + credentialKey(e)
+		add(key, urlHost(e.URL), e)
+		if e.Password != "" {
+			credential := credentialKey(e)
+			if _, ok := credentials[credential]; !ok {
+				credentials[credential] = key
+			}
+		}
+	}
+
+	for _, e := range withoutHost {
+		if e.Password == "" {
+			continue
+		}
+		if key, ok := credentials[credentialKey(e)]; ok {
+			add(key, clusters[key].Host, e)
+			continue
+		}
+		add("\x00"+strings.ToLower(strings.TrimSpace(e.Title))+"\x00"+credentialKey(e), "", e)
+	}
+
+	var result []DuplicateCluster
+	for _, key := range keys {
+		cluster := clusters[key]
+		if len(cluster.Entries) < 2 {
+			continue
+		}
+		sort.SliceStable(cluster.Entries, func(i, j int) bool {
+			return cluster.Entries[i].Modified.After(cluster.Entries[j].Modified)
+		})
+		result = append(result, *cluster)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Host != result[j].Host {
+			return result[i].Host < result[j].Host
+		}
+		return strings.ToLower(result[i].Username) < strings.ToLower(result[j].Username)
+	})
+	return result
+}
+
+// credentialKey identifica username e password di una entry
+func credentialKey(e Entry) string {
+	return strings.ToLower(strings.TrimSpace(e.Username)) + "\x00" + e.Password
+}
+
+// MergeDuplicates unisce le entries others in quella keep, che resta nel
+// suo gruppo. Se le password sono diverse (le entries non vengono da
+// FindDuplicates) diventa quella cambiata più di recente tra tutte;
+// le entries unite (con le loro history) finiscono nella history di keep,
+// che viene completata con URL, note, TOTP, campi, tag e allegati
+// mancanti. Le entries unite vengono eliminate. Le policy dei gruppi non
+// vengono applicate: le password esistono già. La history di keep può
+// superare maxHistoryItems; le modifiche successive non la allungano e
+// scartano prima le versioni con password doppie (vedi addToHistory)
+func (db *Database) MergeDuplicates(keep gokeepasslib.UUID, others []gokeepasslib.UUID) error {
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return fmt.Errorf("database non inizializzato correttamente")
+	}
+	root := &db.Content.Root.Groups[0]
+
+	byUUID := map[gokeepasslib.UUID]Entry{}
+	for _, e := range db.GetAllEntries() {
+		byUUID[e.UUID] = e
+	}
+	merged, ok := byUUID[keep]
+	if !ok {
+		return fmt.Errorf("entry da tenere non trovata")
+	}
+
+	var mergedEntries []Entry
+	for _, uuid := range others {
+		e, ok := byUUID[uuid]
+		if !ok {
+			return fmt.Errorf("entry da unire non trovata")
+		}
+		if uuid.Compare(keep) {
+			continue
+		}
+		mergedEntries = append(mergedEntries, e)
+	}
+	sort.SliceStable(mergedEntries, func(i, j int) bool {
+		return mergedEntries[i].Modified.After(mergedEntries[j].Modified)
+	})
+
+	// Dati della entry tenuta, completati dalle altre (dalla più recente)
+	newest := merged
+	for _, e := range mergedEntries {
+		merged = mergeImportedEntry(merged, e)
+		if e.PasswordChanged.After(newest.PasswordChanged) && e.Password != "" {
+			newest = e
+		}
+	}
+	if newest.Password != "" {
+		merged.Password = newest.Password
+	}
+	merged.Attachments = nil
+
+	// Versioni e allegati delle entries unite
+	var history []gokeepasslib.Entry
+	var binaries []gokeepasslib.BinaryReference
+	for _, e := range mergedEntries {
+		parent, index := findEntry(root, e.UUID)
+		entry := parent.Entries[index]
+		for _, h := range entry.Histories {
+			history = append(history, h.Entries...)
+		}
+		snapshot := entry
+		snapshot.Histories = nil
+		history = append(history, snapshot)
+		binaries = append(binaries, entry.Binaries...)
+	}
+
+	// Prima si aggiorna la entry tenuta: se fallisce le altre esistono ancora
+	parent, index := findEntry(root, keep)
+	if err := db.updateEntry(parent, index, merged, false); err != nil {
+		return err
+	}
+
+	parent, index = findEntry(root, keep)
+	entry := &parent.Entries[index]
+	for _, ref := range binaries {
+		if !hasBinaryReference(entry, ref.Name) {
+			entry.Binaries = append(entry.Binaries, ref)
+		}
+	}
+
+	// Tutta la history in ordine cronologico. Può superare maxHistoryItems:
+	// addToHistory la mantiene a questa lunghezza, per non perdere le
+	// password delle entries unite alla modifica successiva
+	if len(entry.Histories) == 0 {
+		entry.Histories = []gokeepasslib.History{{}}
+	}
+	// Come in KeePass, le versioni hanno l'UUID della entry
+	for i := range history {
+		history[i].UUID = keep
+	}
+	versions := append(entry.Histories[0].Entries, history...)
+	sort.SliceStable(versions, func(i, j int) bool {
+		return modificationTime(&versions[i]).Before(modificationTime(&versions[j]))
+	})
+	entry.Histories[0].Entries = versions
+
+	for _, e := range mergedEntries {
+		parent, index := findEntry(root, e.UUID)
+		db.removeEntry(parent, index)
+	}
+
+	return nil
+}
+
+// removeEntry elimina la entry in parent.Entries[index], registrandola
+// tra gli oggetti eliminati per la sincronizzazione con altri client
+func (db *Database) removeEntry(parent *gokeepasslib.Group, index int) {
+	uuid := parent.Entries[index].UUID
+	parent.Entries = append(parent.Entries[:index], parent.Entries[index+1:]...)
+
+	now := w.Now()
+	db.Content.Root.DeletedObjects = append(db.Content.Root.DeletedObjects,
+		gokeepasslib.DeletedObjectData{UUID: uuid, DeletionTime: &now})
+}
+
+// hasBinaryReference indica se la entry ha già un allegato con il nome dato
+func hasBinaryReference(entry *gokeepasslib.Entry, name string) bool {
+	for _, ref := range entry.Binaries {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// showDuplicates mostra i gruppi di entries doppie (stesso sito, username
+// e password) e permette di unirle: per ogni gruppo si sceglie la entry da
+// tenere e quelle da unire, che finiscono nella sua history
+func (mw *MainWindow) showDuplicates() {
+	if mw.Database == nil {
+		dialog.ShowInformation("Doppioni", "Apri prima un database", mw.Window)
+		return
+	}
+
+	var clusters []kdbx.DuplicateCluster
+
+	summaryLabel := widget.NewLabel("")
+	details := container.NewVBox()
+
+	list := widget.NewList(
+		func() int {
+			return len(clusters)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Template gruppo di doppioni")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(duplicateClusterLabel(clusters[id]))
+		},
+	)
+
+	var run func()
+	showCluster := func(cluster kdbx.DuplicateCluster) {
+		keep := 0
+		merge := make([]bool, len(cluster.Entries))
+		for i := range merge {
+			merge[i] = true
+		}
+
+		options := make([]string, len(cluster.Entries))
+		for i, e := range cluster.Entries {
+			options[i] = duplicateEntryLabel(e, i)
+		}
+		keepRadio := widget.NewRadioGroup(options, nil)
+		keepRadio.SetSelected(options[0])
+
+		checks := container.NewVBox()
+		for i, option := range options {
+			check := widget.NewCheck(option, func(checked bool) {
+				merge[i] = checked
+			})
+			check.SetChecked(true)
+			checks.Add(check)
+		}
+		keepRadio.OnChanged = func(value string) {
+			for i, option := range options {
+				if option == value {
+					keep = i
+				}
+				if check := checks.Objects[i].(*widget.Check); option == value {
+					check.Disable()
+				} else {
+					check.Enable()
+				}
+			}
+		}
+		keepRadio.OnChanged(options[0])
+
+		mergeBtn := widget.NewButton("Unisci", func() {
+			var others []gokeepasslib.UUID
+			for i, e := range cluster.Entries {
+				if i != keep && merge[i] {
+					others = append(others, e.UUID)
+				}
+			}
+			if len(others) == 0 {
+				dialog.ShowInformation("Doppioni", "Seleziona almeno una entry da unire", mw.Window)
+				return
+			}
+			if err := mw.Database.MergeDuplicates(cluster.Entries[keep].UUID, others); err != nil {
+				dialog.ShowError(err, mw.Window)
+				return
+			}
+			mw.refreshEntries()
+			run()
+		})
+		mergeBtn.Importance = widget.HighImportance
+
+		details.Objects = []fyne.CanvasObject{
+			widget.NewLabelWithStyle("Entry da tenere", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			keepRadio,
+			widget.NewLabelWithStyle("Entries da unire", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			checks,
+			widget.NewLabel("La entry tenuta viene completata con i dati delle altre, che vanno nella sua history."),
+			mergeBtn,
+		}
+		details.Refresh()
+	}
+
+	run = func() {
+		clusters = mw.Database.FindDuplicates()
+		summaryLabel.SetText(fmt.Sprintf("%d gruppi di doppioni", len(clusters)))
+		details.Objects = []fyne.CanvasObject{widget.NewLabel("Seleziona un gruppo per rivedere l'unione.")}
+		details.Refresh()
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		showCluster(clusters[id])
+	}
+
+	mergeAllBtn := widget.NewButton("Unisci tutti...", func() {
+		if len(clusters) == 0 {
+			return
+		}
+		dialog.ShowConfirm("Unisci tutti",
+			fmt.Sprintf("Unire tutti i %d gruppi tenendo per ognuno la entry modificata più di recente?", len(clusters)),
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				for _, cluster := range clusters {
+					others := make([]gokeepasslib.UUID, 0, len(cluster.Entries)-1)
+					for _, e := range cluster.Entries[1:] {
+						others = append(others, e.UUID)
+					}
+					if err := mw.Database.MergeDuplicates(cluster.Entries[0].UUID, others); err != nil {
+						dialog.ShowError(err, mw.Window)
+						break
+					}
+				}
+				mw.refreshEntries()
+				run()
+			}, mw.Window)
+	})
+
+	run()
+
+	split := container.NewHSplit(list, container.NewVScroll(details))
+	split.Offset = 0.4
+
+	content := container.NewBorder(
+		container.NewVBox(
+			summaryLabel,
+			widget.NewLabel("Stesso sito, username e password. Le modifiche diventano permanenti al salvataggio."),
+		),
+		container.NewHBox(widget.NewButton("Ricontrolla", run), mergeAllBtn),
+		nil,
+		nil,
+		split,
+	)
+
+	d := dialog.NewCustom("Doppioni", "Chiudi", content, mw.Window)
+	d.Resize(fyne.NewSize(860, 600))
+	d.Show()
+}
+
+// duplicateClusterLabel descrive un gruppo di doppioni nella lista
+func duplicateClusterLabel(cluster kdbx.DuplicateCluster) string {
+	host := cluster.Host
+	if host == "" {
+		host = cluster.Entries[0].Title
+	}
+	username := cluster.Username
+	if username == "" {
+		username = "(senza username)"
+	}
+	return fmt.Sprintf("%s — %s (%d)", host, username, len(cluster.Entries))
+}
+
+// duplicateEntryLabel descrive una entry di un gruppo di doppioni; il
+// numero la distingue dalle altre con lo stesso titolo
+func duplicateEntryLabel(e kdbx.Entry, index int) string {
+	modified := "data sconosciuta"
+	if !e.Modified.IsZero() {
+		modified = e.Modified.Local().Format("2006-01-02 15:04")
+	}
+	return fmt.Sprintf("%d. %s — %s (modificata %s)", index+1, e.Title, e.GroupPath, modified)
+}
@@ -0,0 +1,151 @@
+package kdbx
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// setModified imposta la data di ultima modifica della entry
+func setModified(entry *gokeepasslib.Entry, at time.Time) {
+	modified := w.Now()
+	modified.Time = at
+	entry.Times.LastModificationTime = &modified
+}
+
+func TestFindDuplicates(t *testing.T) {
+	entries := []Entry{
+		{Title: "Posta", Username: "Mario", Password: "a", URL: "https://www.mail.example.com/login"},
+		{Title: "Mail", Username: "mario", Password: "b", URL: "mail.example.com"},
+		{Title: "Posta app", Username: "mario", Password: "a"},
+		{Title: "Posta", Username: "luigi", Password: "a", URL: "https://mail.example.com"},
+		{Title: "Nota", Username: "x", Password: "y"},
+		{Title: "nota", Username: "X", Password: "y"},
+	}
+
+	clusters := FindDuplicates(entries)
+	if len(clusters) != 2 {
+		t.Fatalf("gruppi = %+v, attesi 2", clusters)
+	}
+	// Senza URL: stesso titolo e credenziali
+	if c := clusters[0]; c.Host != "" || len(c.Entries) != 2 {
+		t.Errorf("gruppo senza host: %+v", c)
+	}
+	// Stesso host, username e password, più la entry senza URL con le
+	// stesse credenziali. "Mail" ha un'altra password e resta fuori
+	c := clusters[1]
+	if c.Host != "mail.example.com" || len(c.Entries) != 2 {
+		t.Fatalf("gruppo mail.example.com: %+v", c)
+	}
+	for _, e := range c.Entries {
+		if e.Password != "a" {
+			t.Errorf("gruppo mail.example.com: %q con password %q", e.Title, e.Password)
+		}
+	}
+}
+
+func TestMergeDuplicatesKeepsHistory(t *testing.T) {
+	db, err := CreateNewDatabase(testSaveOptions(t, "test.kdbx", "master"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// La entry da tenere ha già una history piena
+	keep := db.addEntry(Entry{Title: "Posta", Username: "mario", Password: "v0", URL: "https://mail.example.com"})
+	for i := 1; i <= maxHistoryItems; i++ {
+		keep.Password = fmt.Sprintf("tenuta-%d", i)
+		if err := db.UpdateEntry(keep); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := db.addEntry(Entry{Title: "Mail", Username: "mario", Password: "vecchia", URL: "mail.example.com", GroupPath: "Altro"})
+	other.Password = "unita"
+	if err := db.UpdateEntry(other); err != nil {
+		t.Fatal(err)
+	}
+	// Le versioni della entry unita sono più vecchie di tutta la history
+	// di quella tenuta: sono le prime che un limite scarterebbe
+	parent, index := findEntry(&db.Content.Root.Groups[0], other.UUID)
+	entry := &parent.Entries[index]
+	setModified(entry, time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC))
+	setModified(&entry.Histories[0].Entries[0], time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	if err := db.MergeDuplicates(keep.UUID, []gokeepasslib.UUID{other.UUID}); err != nil {
+		t.Fatal(err)
+	}
+	if parent, _ := findEntry(&db.Content.Root.Groups[0], other.UUID); parent != nil {
+		t.Error("la entry unita esiste ancora")
+	}
+
+	// Le modifiche successive non devono scartare le versioni unite
+	for i := 0; i < 3; i++ {
+		keep.Notes = fmt.Sprintf("modifica %d", i)
+		if err := db.UpdateEntry(keep); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history := historyPasswords(t, db, keep.UUID)
+	for _, password := range []string{"vecchia", "unita"} {
+		if !history[password] {
+			t.Errorf("la password %q della entry unita non è più nella history", password)
+		}
+	}
+}
+
+func TestAddToHistoryCap(t *testing.T) {
+	entry := gokeepasslib.NewEntry()
+	for i := 0; i < maxHistoryItems+5; i++ {
+		addToHistory(&entry)
+	}
+	if n := len(entry.Histories[0].Entries); n != maxHistoryItems {
+		t.Errorf("history di %d versioni, attese %d", n, maxHistoryItems)
+	}
+
+	// Una history oltre il limite, come dopo MergeDuplicates: le versioni
+	// unite e, per ultima, quella con la password attuale. Le modifiche
+	// successive non devono allungarla né perdere le password unite
+	version := func(password string) gokeepasslib.Entry {
+		e := gokeepasslib.NewEntry()
+		setEntryValue(&e, "Password", password, true)
+		return e
+	}
+	var versions []gokeepasslib.Entry
+	var mergedPasswords []string
+	for i := 0; i < maxHistoryItems+2; i++ {
+		password := fmt.Sprintf("unita-%d", i)
+		mergedPasswords = append(mergedPasswords, password)
+		versions = append(versions, version(password))
+	}
+	versions = append(versions, version("attuale"))
+	length := len(versions)
+	merged := version("attuale")
+	merged.Histories = []gokeepasslib.History{{Entries: versions}}
+
+	for i := 0; i < 50; i++ {
+		addToHistory(&merged)
+	}
+	if n := len(merged.Histories[0].Entries); n != length {
+		t.Fatalf("history di %d versioni dopo 50 modifiche, attese %d", n, length)
+	}
+	passwords := map[string]bool{}
+	for _, version := range merged.Histories[0].Entries {
+		passwords[version.GetPassword()] = true
+	}
+	for _, password := range mergedPasswords {
+		if !passwords[password] {
+			t.Errorf("persa la password %q", password)
+		}
+	}
+
+	// Cambiando ogni volta password la lunghezza resta la stessa
+	for i := 0; i < 50; i++ {
+		setEntryValue(&merged, "Password", fmt.Sprintf("nuova-%d", i), true)
+		addToHistory(&merged)
+	}
+	if n := len(merged.Histories[0].Entries); n != length {
+		t.Errorf("history di %d versioni dopo 50 cambi di password, attese %d", n, length)
+	}
+}
@@ -0,0 +1,331 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// expiryDateFormat è il formato della data di scadenza nell'editor
+const expiryDateFormat = "2006-01-02"
+
+// showEntryEditor mostra l'editor condiviso per aggiungere (existing nil)
+// o modificare una entry
+func (mw *MainWindow) showEntryEditor(existing *kdbx.Entry) {
+	entry := kdbx.Entry{GroupPath: mw.selectedGroup}
+	if existing != nil {
+		entry = *existing
+	}
+
+	titleEntry := widget.NewEntry()
+	titleEntry.SetText(entry.Title)
+	titleEntry.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("il titolo è obbligatorio")
+		}
+		return nil
+	}
+
+	usernameEntry := widget.NewEntry()
+	usernameEntry.SetText(entry.Username)
+
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetText(entry.Password)
+
+	strengthBar, strengthLabel := newStrengthMeter()
+	policyLabel := widget.NewLabel("")
+	policyLabel.Wrapping = fyne.TextWrapWord
+	policyLabel.Hide()
+
+	groupSelect := widget.NewSelectEntry(mw.groupPaths())
+	groupSelect.SetText(entry.GroupPath)
+	groupSelect.PlaceHolder = "Root"
+
+	// La policy del gruppo viene mostrata sotto la password; se è bloccante
+	// il validator disabilita il salvataggio finché non è rispettata
+	checkPolicy := func(password string) error {
+		if mw.Database == nil {
+			return nil
+		}
+
+		candidate := entry
+		candidate.Password = password
+		candidate.GroupPath = groupSelect.Text
+		policy, sourcePath, violations, warnings := mw.Database.CheckPasswordPolicy(candidate)
+		problems := append(violations, warnings...)
+		if len(problems) == 0 {
+			policyLabel.Hide()
+			return nil
+		}
+
+		policyLabel.SetText(fmt.Sprintf("Policy di %s:\n• %s", sourcePath, strings.Join(problems, "\n• ")))
+		policyLabel.Show()
+		if len(violations) > 0 && policy.Enforcement == kdbx.PolicyRefuse {
+			return fmt.Errorf("la password non rispetta la policy del gruppo")
+		}
+		return nil
+	}
+	passwordEntry.Validator = checkPolicy
+
+	updateStrength := func(password string) {
+		updateStrengthMeter(strengthBar, strengthLabel, password)
+	}
+	passwordEntry.OnChanged = updateStrength
+	updateStrength(entry.Password)
+
+	revealCheck := widget.NewCheck("Mostra password", func(checked bool) {
+		passwordEntry.Password = !checked
+		passwordEntry.Refresh()
+	})
+
+	generateBtn := widget.NewButton("Genera", func() {
+		mw.showGenerator(func(password string) {
+			passwordEntry.SetText(password)
+		}, groupSelect.Text)
+	})
+
+	// Le nuove entries partono con una password del profilo di default del
+	// gruppo, rigenerata se si cambia gruppo prima di modificarla
+	autoPassword := ""
+	if existing == nil {
+		autoPassword = mw.generateGroupPassword(entry.GroupPath)
+		passwordEntry.SetText(autoPassword)
+	}
+	groupSelect.OnChanged = func(path string) {
+		if existing == nil && passwordEntry.Text == autoPassword {
+			if generated := mw.generateGroupPassword(path); generated != "" {
+				autoPassword = generated
+				passwordEntry.SetText(autoPassword)
+			}
+		}
+		// La policy dipende dal gruppo di destinazione
+		passwordEntry.Validate()
+	}
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(entry.URL)
+	urlEntry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		if _, err := url.Parse(s); err != nil {
+			return fmt.Errorf("URL non valido")
+		}
+		return nil
+	}
+
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetText(entry.Notes)
+
+	var knownTags []kdbx.TagCount
+	if mw.Database != nil {
+		knownTags = mw.Database.AllTags()
+	}
+	tagsEntry, tagsInput := newTagInput(knownTags, entry.Tags)
+
+	otp := entry.OTP
+	totpLabel := widget.NewLabel("")
+	updateTOTPLabel := func() {
+		switch totp, err := kdbx.ParseOTPURI(otp); {
+		case otp == "":
+			totpLabel.SetText("Non configurato")
+		case err != nil:
+			totpLabel.SetText(err.Error())
+		default:
+			text := fmt.Sprintf("%d cifre ogni %d s", totp.Digits, totp.Period)
+			if totp.Steam {
+				text = fmt.Sprintf("Steam Guard ogni %d s", totp.Period)
+			}
+			totpLabel.SetText(text)
+		}
+	}
+	updateTOTPLabel()
+	totpBtn := widget.NewButton("Configura...", func() {
+		current := entry
+		current.Title, current.Username, current.OTP = titleEntry.Text, usernameEntry.Text, otp
+		mw.showTOTPSetup(current, func(uri string) {
+			otp = uri
+			updateTOTPLabel()
+		})
+	})
+
+	expiryEntry := widget.NewEntry()
+	expiryEntry.PlaceHolder = "AAAA-MM-GG"
+	expiryEntry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		if _, err := time.ParseInLocation(expiryDateFormat, s, time.Local); err != nil {
+			return fmt.Errorf("data non valida, usa il formato AAAA-MM-GG")
+		}
+		return nil
+	}
+	if entry.Expires && !entry.ExpiryTime.IsZero() {
+		expiryEntry.SetText(entry.ExpiryTime.Local().Format(expiryDateFormat))
+	}
+
+	calendarBtn := widget.NewButton("📅", func() {
+		mw.showDatePicker(expiryEntry)
+	})
+
+	title := "Nuova Password"
+	if existing != nil {
+		title = "Modifica Password"
+	}
+
+	form := dialog.NewForm(title, "Salva", "Annulla",
+		[]*widget.FormItem{
+			widget.NewFormItem("Titolo", titleEntry),
+			widget.NewFormItem("Username", usernameEntry),
+			// La entry della password è l'elemento del form, così il suo
+			// validator (policy bloccante) può disabilitare il salvataggio
+			widget.NewFormItem("Password", passwordEntry),
+			widget.NewFormItem("", container.NewBorder(nil, nil, nil, generateBtn, revealCheck)),
+			widget.NewFormItem("Robustezza", container.NewVBox(strengthBar, strengthLabel, policyLabel)),
+			widget.NewFormItem("TOTP", container.NewBorder(nil, nil, nil, totpBtn, totpLabel)),
+			widget.NewFormItem("URL", urlEntry),
+			widget.NewFormItem("Gruppo", groupSelect),
+			widget.NewFormItem("Tag", tagsInput),
+			widget.NewFormItem("Scadenza", container.NewBorder(nil, nil, nil, calendarBtn, expiryEntry)),
+			widget.NewFormItem("Note", notesEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+
+			entry.Title = strings.TrimSpace(titleEntry.Text)
+			entry.Username = usernameEntry.Text
+			entry.Password = passwordEntry.Text
+			entry.URL = urlEntry.Text
+			entry.Notes = notesEntry.Text
+			entry.GroupPath = groupSelect.Text
+			entry.Tags = inputTags(tagsEntry)
+			entry.OTP = otp
+
+			entry.Expires = expiryEntry.Text != ""
+			if entry.Expires {
+				// Il validator garantisce che la data sia valida
+				entry.ExpiryTime, _ = time.ParseInLocation(expiryDateFormat, expiryEntry.Text, time.Local)
+			}
+
+			// Una password cambiata va ricontrollata nel dataset delle violazioni
+			if existing != nil && entry.Password != existing.Password {
+				delete(mw.breachHits, entry.UUID)
+			}
+
+			var err error
+			if existing == nil {
+				_, err = mw.Database.CreateEntry(entry)
+			} else {
+				err = mw.Database.UpdateEntry(entry)
+			}
+			if err != nil {
+				dialog.ShowError(err, mw.Window)
+				return
+			}
+
+			// Ricarica entries
+			mw.refreshEntries()
+			if existing == nil {
+				dialog.ShowInformation("Successo", "Password aggiunta", mw.Window)
+			} else {
+				dialog.ShowInformation("Successo", "Password modificata", mw.Window)
+			}
+		},
+		mw.Window,
+	)
+	form.Resize(fyne.NewSize(600, 650))
+	form.Show()
+}
+
+// generateGroupPassword genera una password con il profilo di default del
+// gruppo; ritorna "" se il gruppo non ha un profilo
+func (mw *MainWindow) generateGroupPassword(groupPath string) string {
+	if mw.Database == nil {
+		return ""
+	}
+
+	profile, _, ok := mw.Database.GroupGeneratorProfile(groupPath)
+	if !ok {
+		return ""
+	}
+
+	password, err := kdbx.GeneratePassword(profile.Options)
+	if err != nil {
+		return ""
+	}
+	return password
+}
+
+// showDatePicker mostra un calendario che compila il campo data
+func (mw *MainWindow) showDatePicker(target *widget.Entry) {
+	start := time.Now()
+	if t, err := time.ParseInLocation(expiryDateFormat, target.Text, time.Local); err == nil {
+		start = t
+	}
+
+	var d dialog.Dialog
+	calendar := widget.NewCalendar(start, func(t time.Time) {
+		target.SetText(t.Format(expiryDateFormat))
+		d.Hide()
+	})
+
+	clearBtn := widget.NewButton("Nessuna scadenza", func() {
+		target.SetText("")
+		d.Hide()
+	})
+
+	d = dialog.NewCustom("Data di scadenza", "Annulla", container.NewVBox(calendar, clearBtn), mw.Window)
+	d.Show()
+}
+
+// groupPaths ritorna i path di tutti i gruppi in ordine alfabetico
+func (mw *MainWindow) groupPaths() []string {
+	paths := make([]string, 0, len(mw.groupIndex))
+	for path := range mw.groupIndex {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// newStrengthMeter crea la barra e l'etichetta del misuratore di robustezza
+func newStrengthMeter() (*widget.ProgressBar, *widget.Label) {
+	bar := widget.NewProgressBar()
+	bar.Max = float64(kdbx.ScoreVeryStrong + 1)
+	bar.TextFormatter = func() string {
+		return ""
+	}
+
+	label := widget.NewLabel("")
+	label.Wrapping = fyne.TextWrapWord
+	return bar, label
+}
+
+// updateStrengthMeter aggiorna il misuratore con la stima di robustezza
+func updateStrengthMeter(bar *widget.ProgressBar, label *widget.Label, password string) {
+	if password == "" {
+		bar.SetValue(0)
+		label.SetText("Nessuna password")
+		return
+	}
+
+	strength := kdbx.EstimateStrength(password)
+	bar.SetValue(float64(strength.Score + 1))
+
+	text := fmt.Sprintf("%s (~%.0f bit)", strength.Score, strength.Entropy)
+	if len(strength.Warnings) > 0 {
+		text += "\n" + strength.Warnings[0]
+	}
+	label.SetText(text)
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// Preferenza e default della finestra di preavviso sulle scadenze
+const (
+	expiryWarningDaysPref    = "expiryWarningDays"
+	defaultExpiryWarningDays = 7
+	maxNotifiedTitles        = 3
+)
+
+// expiryWindow ritorna la finestra di preavviso configurata
+func (mw *MainWindow) expiryWindow() time.Duration {
+	days := mw.App.Preferences().IntWithFallback(expiryWarningDaysPref, defaultExpiryWarningDays)
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// isExpiringEntry indica se la entry è scaduta o scade entro la finestra
+func (mw *MainWindow) isExpiringEntry(e kdbx.Entry, now time.Time) bool {
+	return e.IsExpired(now) || e.ExpiresWithin(now, mw.expiryWindow())
+}
+
+// notifyExpiringEntries invia una notifica desktop con le entries scadute
+// o in scadenza; viene chiamata all'apertura del database
+func (mw *MainWindow) notifyExpiringEntries() {
+	if mw.Database == nil {
+		return
+	}
+
+	now := time.Now()
+	expiring := kdbx.ExpiringEntries(mw.Database.GetAllEntries(), now, mw.expiryWindow())
+	if len(expiring) == 0 {
+		return
+	}
+
+	expired := 0
+	titles := make([]string, 0, maxNotifiedTitles)
+	for _, e := range expiring {
+		if e.IsExpired(now) {
+			expired++
+		}
+		if len(titles) < maxNotifiedTitles {
+			titles = append(titles, e.Title)
+		}
+	}
+	if len(expiring) > maxNotifiedTitles {
+		titles = append(titles, fmt.Sprintf("e altre %d", len(expiring)-maxNotifiedTitles))
+	}
+
+	days := mw.App.Preferences().IntWithFallback(expiryWarningDaysPref, defaultExpiryWarningDays)
+	title := fmt.Sprintf("%d password scadute, %d in scadenza entro %d giorni", expired, len(expiring)-expired, days)
+	mw.App.SendNotification(fyne.NewNotification(title, strings.Join(titles, ", ")))
+}
+
+// expiryText descrive la scadenza di una entry per il pannello dettagli
+func expiryText(e kdbx.Entry, now time.Time) string {
+	if !e.Expires || e.ExpiryTime.IsZero() {
+		return "Nessuna scadenza"
+	}
+
+	date := e.ExpiryTime.Local().Format(expiryDateFormat)
+	if e.IsExpired(now) {
+		return fmt.Sprintf("Scaduta il %s", date)
+	}
+
+	days := int(e.ExpiryTime.Sub(now).Hours() / 24)
+	switch days {
+	case 0:
+		return fmt.Sprintf("%s (oggi)", date)
+	case 1:
+		return fmt.Sprintf("%s (domani)", date)
+	default:
+		return fmt.Sprintf("%s (tra %d giorni)", date, days)
+	}
+}
+
+// showExpirySettings permette di configurare il preavviso delle scadenze
+func (mw *MainWindow) showExpirySettings() {
+	days := mw.App.Preferences().IntWithFallback(expiryWarningDaysPref, defaultExpiryWarningDays)
+	daysEntry := numberEntry(days, fmt.Sprintf("%d", defaultExpiryWarningDays))
+
+	dialog.ShowForm("Avvisi di scadenza", "Salva", "Annulla",
+		[]*widget.FormItem{
+			widget.NewFormItem("Preavviso (giorni)", daysEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+
+			days := defaultExpiryWarningDays
+			if daysEntry.Text != "" {
+				days = numberValue(daysEntry)
+			}
+			mw.App.Preferences().SetInt(expiryWarningDaysPref, days)
+			mw.refreshEntries()
+		},
+		mw.Window,
+	)
+}
@@ -0,0 +1,183 @@
+package kdbx
+
+import (
+	"fmt"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+// ExportOptions seleziona cosa esportare in un nuovo database. Una entry
+// viene esportata se sta in uno dei gruppi (o nei loro sottogruppi) o se
+// ha uno dei tag
+type ExportOptions struct {
+	Groups []string // Path completi dei gruppi, come Group.Path
+	Tags   []string
+
+	StripHistory     bool // Esporta solo la versione corrente delle entries
+	StripAttachments bool // Esporta le entries senza allegati
+}
+
+// ExportResult riassume un'esportazione
+type ExportResult struct {
+	Entries     int
+	Groups      int
+	Attachments int
+}
+
+// ExportSelection salva le entries selezionate in un nuovo database .kdbx,
+// con la sua password e le sue SaveOptions. La struttura dei gruppi, gli
+// UUID e i tempi vengono mantenuti; il database di origine non viene
+// modificato. Le entries nel cestino non vengono esportate
+func (db *Database) ExportSelection(sel ExportOptions, opts SaveOptions) (ExportResult, error) {
+	var result ExportResult
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return result, fmt.Errorf("database non inizializzato correttamente")
+	}
+	if len(sel.Groups) == 0 && len(sel.Tags) == 0 {
+		return result, fmt.Errorf("seleziona almeno un gruppo o un tag da esportare")
+	}
+
+	selectedGroups := map[*gokeepasslib.Group]bool{}
+	for _, path := range sel.Groups {
+		group, _ := db.findGroup(path)
+		if group == nil {
+			return result, fmt.Errorf("gruppo non trovato: %s", path)
+		}
+		selectedGroups[group] = true
+	}
+
+	target, err := CreateNewDatabase(opts)
+	if err != nil {
+		return result, err
+	}
+
+	root := &db.Content.Root.Groups[0]
+	export := selectionExport{
+		source:   db,
+		target:   target,
+		sel:      sel,
+		selected: selectedGroups,
+		tags:     sel.Tags,
+		linked:   map[int]int{},
+		result:   &result,
+	}
+	if db.Content.Meta != nil && db.Content.Meta.RecycleBinEnabled.Bool {
+		export.recycleBin = db.Content.Meta.RecycleBinUUID
+	}
+
+	targetRoot := &target.Content.Root.Groups[0]
+	targetRoot.Name = root.Name
+	export.group(targetRoot, root, selectedGroups[root])
+	if result.Entries == 0 {
+		return result, fmt.Errorf("nessuna entry corrisponde alla selezione")
+	}
+
+	if meta := db.Content.Meta; meta != nil {
+		target.Content.Meta.MemoryProtection = meta.MemoryProtection
+		target.Content.Meta.CustomIcons = meta.CustomIcons
+	}
+
+	if err := target.Save(opts); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// selectionExport è lo stato della copia delle entries selezionate
+type selectionExport struct {
+	source     *Database
+	target     *Database
+	sel        ExportOptions
+	selected   map[*gokeepasslib.Group]bool
+	tags       []string
+	recycleBin gokeepasslib.UUID
+	linked     map[int]int // ID dell'allegato nell'origine -> ID nel nuovo database
+	result     *ExportResult
+}
+
+// group copia in dst le entries selezionate di src e dei suoi sottogruppi;
+// i sottogruppi vengono creati solo se contengono entries esportate
+func (x selectionExport) group(dst, src *gokeepasslib.Group, included bool) {
+	for _, entry := range src.Entries {
+		if included || x.hasTag(entry) {
+			dst.Entries = append(dst.Entries, x.entry(entry))
+			x.result.Entries++
+		}
+	}
+
+	for i := range src.Groups {
+		sub := &src.Groups[i]
+		if sub.UUID.Compare(x.recycleBin) {
+			continue
+		}
+
+		group := *sub
+		group.Entries, group.Groups = nil, nil
+		x.group(&group, sub, included || x.selected[sub])
+		if len(group.Entries) > 0 || len(group.Groups) > 0 {
+			dst.Groups = append(dst.Groups, group)
+			x.result.Groups++
+		}
+	}
+}
+
+// hasTag indica se la entry ha uno dei tag selezionati
+func (x selectionExport) hasTag(entry gokeepasslib.Entry) bool {
+	e := Entry{Tags: ParseTags(entry.Tags)}
+	for _, tag := range x.tags {
+		if e.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// entry ritorna una copia della entry per il nuovo database, senza
+// condividere valori con l'origine
+func (x selectionExport) entry(entry gokeepasslib.Entry) gokeepasslib.Entry {
+	histories := entry.Histories
+	entry = x.copyEntry(entry)
+
+	entry.Histories = nil
+	if !x.sel.StripHistory {
+		for _, history := range histories {
+			copied := gokeepasslib.History{}
+			for _, version := range history.Entries {
+				copied.Entries = append(copied.Entries, x.copyEntry(version))
+			}
+			entry.Histories = append(entry.Histories, copied)
+		}
+	}
+	return entry
+}
+
+// copyEntry copia valori, CustomData e allegati di una singola versione
+func (x selectionExport) copyEntry(entry gokeepasslib.Entry) gokeepasslib.Entry {
+	entry.Values = append([]gokeepasslib.ValueData(nil), entry.Values...)
+	entry.CustomData = append([]gokeepasslib.CustomData(nil), entry.CustomData...)
+	entry.AutoType.Associations = append([]gokeepasslib.AutoTypeAssociation(nil), entry.AutoType.Associations...)
+
+	refs := entry.Binaries
+	entry.Binaries = nil
+	if x.sel.StripAttachments {
+		return entry
+	}
+	for _, ref := range refs {
+		if id, ok := x.linked[ref.Value.ID]; ok {
+			entry.Binaries = append(entry.Binaries, gokeepasslib.NewBinaryReference(ref.Name, id))
+			continue
+		}
+		binary := x.source.binaries().Find(ref.Value.ID)
+		if binary == nil {
+			continue
+		}
+		data, err := binary.GetContentBytes()
+		if err != nil {
+			continue
+		}
+		x.target.addAttachment(&entry, Attachment{Name: ref.Name, Data: data})
+		x.linked[ref.Value.ID] = entry.Binaries[len(entry.Binaries)-1].Value.ID
+		x.result.Attachments++
+	}
+	return entry
+}
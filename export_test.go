@@ -0,0 +1,125 @@
+package kdbx
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// newExportSource crea un database con due gruppi, un'entry con tag, history
+// e allegato, e un'entry nel cestino
+func newExportSource(t *testing.T, format KDBXFormat) *Database {
+	t.Helper()
+	opts := testSaveOptions(t, "origine.kdbx", "origine")
+	opts.Format = format
+	if format == FormatKDBX31 {
+		opts.CipherType = CipherAES256
+	}
+	db, err := CreateNewDatabase(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shared := db.addEntry(Entry{
+		Title: "Wi-Fi", Password: "vecchia", GroupPath: "Casa", Tags: []string{"condivisa"},
+		Attachments: []Attachment{{Name: "router.txt", Data: []byte("admin")}},
+	})
+	shared.Password = "nuova"
+	shared.Attachments = nil // Già aggiunto
+	if err := db.UpdateEntry(shared); err != nil {
+		t.Fatal(err)
+	}
+	db.addEntry(Entry{Title: "Banca", Password: "privata", GroupPath: "Finanza"})
+	db.addEntry(Entry{Title: "Server", Password: "lavoro", GroupPath: "Lavoro / Server"})
+	return db
+}
+
+func TestExportSelection(t *testing.T) {
+	for _, formats := range [][2]KDBXFormat{
+		{FormatKDBX4, FormatKDBX31},
+		{FormatKDBX31, FormatKDBX4},
+	} {
+		source, target := formats[0], formats[1]
+		t.Run(KDBXFormats[source]+" in "+KDBXFormats[target], func(t *testing.T) {
+			db := newExportSource(t, source)
+
+			opts := testSaveOptions(t, "condivisione.kdbx", "condivisa")
+			opts.Format = target
+			if target == FormatKDBX31 {
+				opts.CipherType = CipherAES256
+			}
+			sel := ExportOptions{Groups: []string{"Root / Lavoro"}, Tags: []string{"condivisa"}}
+			result, err := db.ExportSelection(sel, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Entries != 2 || result.Attachments != 1 {
+				t.Errorf("risultato %+v", result)
+			}
+
+			exported, err := OpenDatabase(opts.FilePath, "condivisa")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exported.Header.IsKdbx4() != (target == FormatKDBX4) {
+				t.Errorf("formato del file esportato: KDBX 4 = %v", exported.Header.IsKdbx4())
+			}
+			entries := exported.GetAllEntries()
+			if len(entries) != 2 {
+				t.Fatalf("entries esportate: %+v", entries)
+			}
+
+			wifi := entryByTitle(t, entries, "Wi-Fi")
+			original := entryByTitle(t, db.GetAllEntries(), "Wi-Fi")
+			if wifi.UUID != original.UUID || wifi.GroupPath != "Root / Casa" || wifi.Password != "nuova" {
+				t.Errorf("Wi-Fi: %+v", wifi)
+			}
+			// I KDBX salvano i tempi al secondo
+			if !wifi.Modified.Equal(original.Modified.Truncate(time.Second)) {
+				t.Errorf("Wi-Fi: modificata %v, attesa %v", wifi.Modified, original.Modified)
+			}
+			if len(wifi.Attachments) != 1 || wifi.Attachments[0].Name != "router.txt" {
+				t.Errorf("Wi-Fi: allegati %+v", wifi.Attachments)
+			}
+			if history := historyPasswords(t, exported, wifi.UUID); !history["vecchia"] {
+				t.Errorf("Wi-Fi: history %v senza la password precedente", history)
+			}
+			if server := entryByTitle(t, entries, "Server"); server.GroupPath != "Root / Lavoro / Server" {
+				t.Errorf("Server nel gruppo %q", server.GroupPath)
+			}
+		})
+	}
+}
+
+func TestExportSelectionStrip(t *testing.T) {
+	db := newExportSource(t, FormatKDBX4)
+	opts := testSaveOptions(t, "condivisione.kdbx", "condivisa")
+	sel := ExportOptions{Tags: []string{"condivisa"}, StripHistory: true, StripAttachments: true}
+	if _, err := db.ExportSelection(sel, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := OpenDatabase(opts.FilePath, "condivisa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wifi := entryByTitle(t, exported.GetAllEntries(), "Wi-Fi")
+	if len(wifi.Attachments) != 0 || len(historyPasswords(t, exported, wifi.UUID)) != 0 {
+		t.Errorf("Wi-Fi esportata con history o allegati: %+v", wifi)
+	}
+}
+
+func TestExportSelectionNoMatch(t *testing.T) {
+	db := newExportSource(t, FormatKDBX4)
+	opts := testSaveOptions(t, "condivisione.kdbx", "condivisa")
+
+	if _, err := db.ExportSelection(ExportOptions{Tags: []string{"inesistente"}}, opts); err == nil {
+		t.Error("nessuna entry selezionata: nessun errore")
+	}
+	if _, err := db.ExportSelection(ExportOptions{Groups: []string{"Inesistente"}}, opts); err == nil {
+		t.Error("gruppo inesistente: nessun errore")
+	}
+	if _, err := os.Stat(opts.FilePath); !os.IsNotExist(err) {
+		t.Errorf("creato il file di un'esportazione fallita: %v", err)
+	}
+}
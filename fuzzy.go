@@ -0,0 +1,167 @@
+package kdbx
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Pesi usati dal ranking della ricerca rapida
+const (
+	fuzzyMatchScore       = 1
+	fuzzyConsecutiveBonus = 5
+	fuzzyBoundaryBonus    = 8
+	fuzzyFirstCharBonus   = 10
+	fuzzyGapPenalty       = 1
+	fuzzyMaxGapPenalty    = 10
+
+	quickFindRecencyWeight = 10.0 // Bonus massimo per entries usate di recente
+	quickFindUsageWeight   = 2.0  // Bonus per ogni raddoppio degli utilizzi
+)
+
+// QuickFindResult è una entry trovata dalla ricerca rapida con il suo punteggio
+type QuickFindResult struct {
+	Entry Entry
+	Score float64
+}
+
+// FuzzyScore calcola quanto pattern corrisponde a text come sottosequenza.
+// Premia caratteri consecutivi e inizi di parola; ritorna false se non
+// tutti i caratteri del pattern sono presenti nell'ordine giusto
+func FuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(normalizeSearchText(pattern))
+
+	// Il testo originale serve a riconoscere gli inizi di parola in camelCase
+	orig := []rune(removeAccents(text))
+	t := make([]rune, len(orig))
+	for i, r := range orig {
+		t[i] = unicode.ToLower(r)
+	}
+
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	score := 0
+	ti := 0
+	lastMatch := -1
+
+	for _, pr := range p {
+		found := false
+		for ; ti < len(t); ti++ {
+			if t[ti] != pr {
+				continue
+			}
+
+			score += fuzzyMatchScore
+			switch {
+			case ti == 0:
+				score += fuzzyFirstCharBonus
+			case !isWordRune(t[ti-1]) || (unicode.IsUpper(orig[ti]) && unicode.IsLower(orig[ti-1])):
+				score += fuzzyBoundaryBonus
+			}
+
+			if lastMatch >= 0 {
+				if ti == lastMatch+1 {
+					score += fuzzyConsecutiveBonus
+				} else {
+					score -= min((ti-lastMatch-1)*fuzzyGapPenalty, fuzzyMaxGapPenalty)
+				}
+			}
+
+			lastMatch = ti
+			ti++
+			found = true
+			break
+		}
+
+		if !found {
+			return 0, false
+		}
+	}
+
+	return score, true
+}
+
+// isWordRune indica se il carattere fa parte di una parola
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// QuickFind ordina le entries per corrispondenza fuzzy su titolo, URL e
+// username, frequenza e recenza di utilizzo. Con pattern vuoto ritorna
+// tutte le entries ordinate solo per utilizzo
+func QuickFind(entries []Entry, pattern string, now time.Time) []QuickFindResult {
+	pattern = strings.TrimSpace(pattern)
+	var results []QuickFindResult
+
+	for _, e := range entries {
+		matchScore, ok := bestFieldScore(e, pattern)
+		if !ok {
+			continue
+		}
+
+		results = append(results, QuickFindResult{
+			Entry: e,
+			Score: matchScore + usageScore(e, now),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return strings.ToLower(results[i].Entry.Title) < strings.ToLower(results[j].Entry.Title)
+	})
+
+	return results
+}
+
+// bestFieldScore ritorna il punteggio migliore tra i campi della entry.
+// Il titolo ha peso pieno, URL e username un peso ridotto
+func bestFieldScore(e Entry, pattern string) (float64, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+
+	fields := []struct {
+		value  string
+		weight float64
+	}{
+		{e.Title, 1.0},
+		{e.URL, 0.8},
+		{e.Username, 0.8},
+	}
+
+	best, matched := 0.0, false
+	for _, f := range fields {
+		score, ok := FuzzyScore(pattern, f.value)
+		if !ok {
+			continue
+		}
+		if weighted := float64(score) * f.weight; !matched || weighted > best {
+			best = weighted
+			matched = true
+		}
+	}
+
+	return best, matched
+}
+
+// usageScore calcola il bonus di utilizzo dai campi KDBX UsageCount e
+// LastAccessTime della entry
+func usageScore(e Entry, now time.Time) float64 {
+	score := quickFindUsageWeight * math.Log2(1+float64(e.UsageCount))
+
+	if !e.LastAccessTime.IsZero() && e.UsageCount > 0 {
+		days := now.Sub(e.LastAccessTime).Hours() / 24
+		if days < 0 {
+			days = 0
+		}
+		score += quickFindRecencyWeight / (1 + days)
+	}
+
+	return score
+}
@@ -0,0 +1,564 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// Limiti del generatore nel dialog
+const (
+	generatorMinLength  = 8
+	generatorMaxLength  = 128
+	generatorMaxHistory = 20
+
+	passphraseMinWords  = 3
+	passphraseMaxWords  = 20
+	passphraseMaxDigits = 5
+)
+
+// Modalità del generatore, nell'ordine delle tab
+const (
+	generatorModePassword = iota
+	generatorModePassphrase
+	generatorModePattern
+)
+
+// Modalità di maiuscole della passphrase, nell'ordine di kdbx.CapitalizationMode
+var capitalizationLabels = []string{"minuscolo", "Iniziale maiuscola", "MAIUSCOLO", "Maiuscole casuali"}
+
+// showGenerator mostra il generatore di password configurabile. Ogni
+// modifica alle opzioni rigenera la password; se onUse non è nil il
+// dialog offre anche il pulsante per inserirla nella entry in modifica.
+// Se il gruppo groupPath ha un profilo di default, il dialog parte da quello
+func (mw *MainWindow) showGenerator(onUse func(string), groupPath string) {
+	opts := mw.generatorOptions
+	phraseOpts := mw.passphraseOptions
+	mode := mw.generatorMode
+	password := ""
+
+	// reloaders riallineano i widget a opts quando si applica un profilo;
+	// loading sopprime le rigenerazioni scatenate nel frattempo
+	var reloaders []func()
+	loading := false
+
+	passwordField := widget.NewEntry()
+	passwordField.TextStyle = fyne.TextStyle{Monospace: true}
+	passwordField.Disable()
+
+	infoLabel := widget.NewLabel("")
+	strengthBar, strengthLabel := newStrengthMeter()
+	errorLabel := widget.NewLabel("")
+	errorLabel.Hide()
+
+	historyList := widget.NewList(
+		func() int {
+			return len(mw.generatorHistory)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Template")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(mw.generatorHistory[id])
+		},
+	)
+	// Copia e Usa restano disattivati finché non c'è una password valida
+	copyBtn := widget.NewButton("Copia", nil)
+	var useBtn *widget.Button
+	setPassword := func(generated string) {
+		password = generated
+		passwordField.SetText(password)
+		updateStrengthMeter(strengthBar, strengthLabel, password)
+		for _, btn := range []*widget.Button{copyBtn, useBtn} {
+			if btn == nil {
+				continue
+			}
+			if password == "" {
+				btn.Disable()
+			} else {
+				btn.Enable()
+			}
+		}
+	}
+
+	historyList.OnSelected = func(id widget.ListItemID) {
+		setPassword(mw.generatorHistory[id])
+		infoLabel.SetText(fmt.Sprintf("Dalla cronologia, %d caratteri", len(password)))
+		errorLabel.Hide()
+		historyList.UnselectAll()
+	}
+
+	regenerate := func() {
+		if loading {
+			return
+		}
+		mw.generatorOptions = opts
+		mw.passphraseOptions = phraseOpts
+		mw.generatorMode = mode
+
+		var generated, info string
+		var err error
+		switch mode {
+		case generatorModePassphrase:
+			generated, err = kdbx.GeneratePassphrase(phraseOpts)
+			info = fmt.Sprintf("%d parole da %d · Entropia: %.1f bit",
+				phraseOpts.WordCount, len(phraseOpts.WordlistOrDefault().Words), kdbx.PassphraseEntropy(phraseOpts))
+		case generatorModePattern:
+			// Senza pattern GeneratePassword userebbe le classi della tab
+			// Password: qui va segnalato
+			if opts.Pattern == "" {
+				err = fmt.Errorf("pattern vuoto")
+				break
+			}
+			generated, err = kdbx.GeneratePassword(opts)
+			info = fmt.Sprintf("Lunghezza: %d caratteri · Entropia: %.1f bit",
+				len([]rune(generated)), kdbx.CalculateEntropy(generated, opts))
+		default:
+			// Il pattern resta memorizzato ma vale solo nella sua tab
+			classOpts := opts
+			classOpts.Pattern = ""
+			generated, err = kdbx.GeneratePassword(classOpts)
+			info = fmt.Sprintf("Lunghezza: %d caratteri · Entropia: ~%.0f bit",
+				classOpts.Length, kdbx.CalculateEntropy(generated, classOpts))
+		}
+		if err != nil {
+			// Una password generata con le opzioni precedenti non va
+			// copiata né usata come se rispettasse quelle nuove
+			setPassword("")
+			infoLabel.SetText("")
+			errorLabel.SetText(err.Error())
+			errorLabel.Show()
+			return
+		}
+		errorLabel.Hide()
+
+		setPassword(generated)
+		infoLabel.SetText(info)
+	}
+
+	// remember salva la password corrente nella cronologia. Solo le azioni
+	// esplicite (Rigenera, Copia, Usa) la aggiornano: le modifiche alle
+	// opzioni generano password intermedie che la riempirebbero
+	remember := func() {
+		if password == "" {
+			return
+		}
+		mw.addToGeneratorHistory(password)
+		historyList.Refresh()
+	}
+
+	lengthLabel := widget.NewLabel(fmt.Sprintf("%d", opts.Length))
+	lengthSlider := widget.NewSlider(generatorMinLength, generatorMaxLength)
+	lengthSlider.Step = 1
+	lengthSlider.SetValue(float64(opts.Length))
+	lengthSlider.OnChanged = func(v float64) {
+		lengthLabel.SetText(fmt.Sprintf("%d", int(v)))
+	}
+	lengthSlider.OnChangeEnded = func(v float64) {
+		opts.Length = int(v)
+		regenerate()
+	}
+	reloaders = append(reloaders, func() {
+		lengthSlider.SetValue(float64(opts.Length))
+	})
+
+	option := func(label string, value *bool) *widget.Check {
+		check := widget.NewCheck(label, nil)
+		check.SetChecked(*value)
+		check.OnChanged = func(checked bool) {
+			*value = checked
+			regenerate()
+		}
+		reloaders = append(reloaders, func() {
+			check.SetChecked(*value)
+		})
+		return check
+	}
+
+	textOption := func(value *string) *widget.Entry {
+		entry := charsEntry(value, regenerate)
+		reloaders = append(reloaders, func() {
+			entry.SetText(*value)
+		})
+		return entry
+	}
+
+	minOption := func(placeholder string, value *int) *widget.Entry {
+		entry := minCountEntry(placeholder, value, regenerate)
+		reloaders = append(reloaders, func() {
+			entry.SetText(formatMinCount(*value))
+		})
+		return entry
+	}
+
+	options := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Lunghezza"), lengthLabel, lengthSlider),
+		container.NewGridWithColumns(2,
+			option("Minuscole (a-z)", &opts.UseLowercase),
+			option("Maiuscole (A-Z)", &opts.UseUppercase),
+			option("Cifre (0-9)", &opts.UseDigits),
+			option("Speciali (!@#...)", &opts.UseSpecial),
+		),
+		option("Escludi caratteri ambigui (0, O, l, 1...)", &opts.ExcludeAmbiguous),
+		widget.NewForm(
+			widget.NewFormItem("Caratteri extra", textOption(&opts.CustomChars)),
+			widget.NewFormItem("Escludi", textOption(&opts.ExcludeChars)),
+		),
+		container.NewGridWithColumns(4,
+			minOption("Min a-z", &opts.MinLowercase),
+			minOption("Min A-Z", &opts.MinUppercase),
+			minOption("Min 0-9", &opts.MinDigits),
+			minOption("Min !@#", &opts.MinSpecial),
+		),
+	)
+
+	phraseOptions := mw.createPassphraseOptions(&phraseOpts, regenerate)
+
+	patternEntry := textOption(&opts.Pattern)
+	patternEntry.PlaceHolder = `ullldd\-d{4}`
+	patternEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	patternOptions := container.NewVBox(
+		patternEntry,
+		option("Permuta i caratteri generati", &opts.ShufflePattern),
+		widget.NewLabel(kdbx.PatternHelp),
+	)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Password", options),
+		container.NewTabItem("Passphrase", phraseOptions),
+		container.NewTabItem("Pattern", patternOptions),
+	)
+	tabs.SelectIndex(mode)
+	tabs.OnSelected = func(tab *container.TabItem) {
+		mode = tabs.SelectedIndex()
+		regenerate()
+	}
+
+	// applyProfile carica le opzioni di un profilo nei widget, senza rigenerare
+	applyProfile := func(profile kdbx.GeneratorProfile) {
+		loading = true
+		opts = profile.Options
+		mode = generatorModePassword
+		if opts.Pattern != "" {
+			mode = generatorModePattern
+		}
+		for _, reload := range reloaders {
+			reload()
+		}
+		lengthLabel.SetText(fmt.Sprintf("%d", opts.Length))
+		tabs.SelectIndex(mode)
+		loading = false
+	}
+
+	var profileBar fyne.CanvasObject = widget.NewLabel("")
+	if mw.Database != nil {
+		profileBar = mw.createProfileBar(groupPath, &opts, &mode, applyProfile, regenerate)
+	}
+
+	buttons := container.NewHBox(
+		widget.NewButton("Rigenera", func() {
+			regenerate()
+			remember()
+		}),
+		copyBtn,
+	)
+	copyBtn.OnTapped = func() {
+		if password == "" {
+			return
+		}
+		remember()
+		mw.Window.Clipboard().SetContent(password)
+		dialog.ShowInformation("Copiato", "Password copiata negli appunti", mw.Window)
+	}
+
+	var d dialog.Dialog
+	if onUse != nil {
+		useBtn = widget.NewButton("Usa", func() {
+			if password == "" {
+				return
+			}
+			remember()
+			onUse(password)
+			d.Hide()
+		})
+		buttons.Add(useBtn)
+	}
+
+	content := container.NewBorder(
+		container.NewVBox(
+			profileBar,
+			tabs,
+			widget.NewSeparator(),
+			passwordField,
+			infoLabel,
+			strengthBar,
+			strengthLabel,
+			errorLabel,
+			buttons,
+			widget.NewSeparator(),
+			widget.NewLabel("Generate di recente (solo in memoria)"),
+		),
+		nil,
+		nil,
+		nil,
+		historyList,
+	)
+
+	d = dialog.NewCustom("Generatore Password", "Chiudi", content, mw.Window)
+	d.Resize(fyne.NewSize(560, 720))
+	regenerate()
+	d.Show()
+}
+
+// createProfileBar crea la barra dei profili salvati nel database: la
+// selezione applica il profilo, "Salva profilo" memorizza le opzioni
+// correnti. Se il gruppo ha un profilo di default viene preselezionato
+func (mw *MainWindow) createProfileBar(groupPath string, opts *kdbx.PasswordOptions, mode *int, apply func(kdbx.GeneratorProfile), regenerate func()) fyne.CanvasObject {
+	profileSelect := widget.NewSelect(nil, nil)
+	profileSelect.PlaceHolder = "Nessun profilo"
+
+	reloadProfiles := func(selected string) {
+		profiles, err := mw.Database.GeneratorProfiles()
+		if err != nil {
+			dialog.ShowError(err, mw.Window)
+		}
+		names := make([]string, len(profiles))
+		for i, profile := range profiles {
+			names[i] = profile.Name
+		}
+		profileSelect.SetOptions(names)
+		if selected != "" {
+			profileSelect.SetSelected(selected)
+		}
+	}
+
+	if profile, _, ok := mw.Database.GroupGeneratorProfile(groupPath); ok {
+		apply(profile)
+		reloadProfiles(profile.Name)
+	} else {
+		reloadProfiles("")
+	}
+
+	profileSelect.OnChanged = func(name string) {
+		if profile, ok := mw.Database.GeneratorProfile(name); ok {
+			apply(profile)
+			regenerate()
+		}
+	}
+
+	saveBtn := widget.NewButton("Salva profilo", func() {
+		if *mode == generatorModePassphrase {
+			dialog.ShowInformation("Profili", "I profili salvano le opzioni delle tab Password e Pattern", mw.Window)
+			return
+		}
+
+		nameEntry := widget.NewSelectEntry(profileSelect.Options)
+		nameEntry.SetText(profileSelect.Selected)
+		dialog.ShowForm("Salva profilo", "Salva", "Annulla",
+			[]*widget.FormItem{widget.NewFormItem("Nome", nameEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+
+				profile := kdbx.GeneratorProfile{Name: nameEntry.Text, Options: *opts}
+				if *mode == generatorModePassword {
+					profile.Options.Pattern = ""
+				}
+				if err := mw.Database.SaveGeneratorProfile(profile); err != nil {
+					dialog.ShowError(err, mw.Window)
+					return
+				}
+				reloadProfiles(strings.TrimSpace(nameEntry.Text))
+			}, mw.Window)
+	})
+
+	deleteBtn := widget.NewButton("Elimina", func() {
+		name := profileSelect.Selected
+		if name == "" {
+			return
+		}
+		dialog.ShowConfirm("Elimina profilo",
+			fmt.Sprintf("Eliminare il profilo %q? I gruppi che lo usano torneranno senza profilo.", name),
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				if err := mw.Database.DeleteGeneratorProfile(name); err != nil {
+					dialog.ShowError(err, mw.Window)
+					return
+				}
+				profileSelect.ClearSelected()
+				reloadProfiles("")
+			}, mw.Window)
+	})
+
+	return container.NewBorder(nil, nil, widget.NewLabel("Profilo"), container.NewHBox(saveBtn, deleteBtn), profileSelect)
+}
+
+// createPassphraseOptions crea il pannello delle opzioni passphrase
+func (mw *MainWindow) createPassphraseOptions(opts *kdbx.PassphraseOptions, regenerate func()) fyne.CanvasObject {
+	wordsLabel := widget.NewLabel(fmt.Sprintf("%d", opts.WordCount))
+	wordsSlider := widget.NewSlider(passphraseMinWords, passphraseMaxWords)
+	wordsSlider.Step = 1
+	wordsSlider.SetValue(float64(opts.WordCount))
+	wordsSlider.OnChanged = func(v float64) {
+		wordsLabel.SetText(fmt.Sprintf("%d", int(v)))
+	}
+	wordsSlider.OnChangeEnded = func(v float64) {
+		opts.WordCount = int(v)
+		regenerate()
+	}
+
+	separatorEntry := widget.NewEntry()
+	separatorEntry.SetText(opts.Separator)
+	separatorEntry.OnChanged = func(s string) {
+		opts.Separator = s
+		regenerate()
+	}
+
+	capitalizationSelect := widget.NewSelect(capitalizationLabels, nil)
+	capitalizationSelect.SetSelectedIndex(int(opts.Capitalization))
+	capitalizationSelect.OnChanged = func(string) {
+		opts.Capitalization = kdbx.CapitalizationMode(capitalizationSelect.SelectedIndex())
+		regenerate()
+	}
+
+	digitsLabel := widget.NewLabel(fmt.Sprintf("%d", opts.DigitCount))
+	digitsSlider := widget.NewSlider(0, passphraseMaxDigits)
+	digitsSlider.Step = 1
+	digitsSlider.SetValue(float64(opts.DigitCount))
+	digitsSlider.OnChanged = func(v float64) {
+		digitsLabel.SetText(fmt.Sprintf("%d", int(v)))
+	}
+	digitsSlider.OnChangeEnded = func(v float64) {
+		opts.DigitCount = int(v)
+		regenerate()
+	}
+
+	wordlists := []*kdbx.Wordlist{kdbx.EFFLargeWordlist(), kdbx.EFFShortWordlist()}
+	if mw.customWordlist != nil {
+		wordlists = append(wordlists, mw.customWordlist)
+	}
+
+	wordlistSelect := widget.NewSelect(nil, nil)
+	refreshWordlists := func() {
+		names := make([]string, len(wordlists))
+		selected := 0
+		for i, list := range wordlists {
+			names[i] = fmt.Sprintf("%s (%d parole)", list.Name, len(list.Words))
+			if list == opts.WordlistOrDefault() {
+				selected = i
+			}
+		}
+		wordlistSelect.SetOptions(names)
+		wordlistSelect.SetSelectedIndex(selected)
+	}
+	refreshWordlists()
+	wordlistSelect.OnChanged = func(string) {
+		if index := wordlistSelect.SelectedIndex(); index >= 0 && wordlists[index] != opts.WordlistOrDefault() {
+			opts.Wordlist = wordlists[index]
+			regenerate()
+		}
+	}
+
+	loadBtn := widget.NewButton("Carica...", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+
+			list, err := kdbx.ReadWordlist(reader.URI().Name(), reader)
+			if err != nil {
+				dialog.ShowError(err, mw.Window)
+				return
+			}
+
+			mw.customWordlist = list
+			wordlists = append(wordlists[:2], list)
+			opts.Wordlist = list
+			refreshWordlists()
+			regenerate()
+		}, mw.Window)
+	})
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Parole"), wordsLabel, wordsSlider),
+		container.NewBorder(nil, nil, widget.NewLabel("Wordlist"), loadBtn, wordlistSelect),
+		widget.NewForm(
+			widget.NewFormItem("Separatore", separatorEntry),
+			widget.NewFormItem("Maiuscole", capitalizationSelect),
+		),
+		container.NewBorder(nil, nil, widget.NewLabel("Cifre"), digitsLabel, digitsSlider),
+	)
+}
+
+// charsEntry crea un campo di testo legato a value che rigenera a ogni modifica
+func charsEntry(value *string, regenerate func()) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(*value)
+	entry.OnChanged = func(s string) {
+		*value = s
+		regenerate()
+	}
+	return entry
+}
+
+// minCountEntry crea un campo numerico per il minimo di una classe
+func minCountEntry(placeholder string, value *int, regenerate func()) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.PlaceHolder = placeholder
+	entry.SetText(formatMinCount(*value))
+	entry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		if n, err := strconv.Atoi(s); err != nil || n < 0 {
+			return fmt.Errorf("numero non valido")
+		}
+		return nil
+	}
+	entry.OnChanged = func(s string) {
+		n, err := strconv.Atoi(s)
+		if s == "" {
+			n, err = 0, nil
+		}
+		if err != nil || n < 0 {
+			return
+		}
+		*value = n
+		regenerate()
+	}
+	return entry
+}
+
+// formatMinCount mostra un minimo per classe, lasciando vuoto lo zero
+func formatMinCount(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// addToGeneratorHistory aggiunge una password alla cronologia in memoria,
+// mantenendo solo le più recenti. Una password già presente torna in cima
+func (mw *MainWindow) addToGeneratorHistory(password string) {
+	history := []string{password}
+	for _, p := range mw.generatorHistory {
+		if p != password {
+			history = append(history, p)
+		}
+	}
+	mw.generatorHistory = history
+	if len(mw.generatorHistory) > generatorMaxHistory {
+		mw.generatorHistory = mw.generatorHistory[:generatorMaxHistory]
+	}
+}
@@ -0,0 +1,174 @@
+package kdbx
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Chiavi CustomData dei profili del generatore
+const (
+	generatorProfilesKey = customDataPrefix + "GeneratorProfiles"
+	groupProfileKeyName  = "GroupGeneratorProfile"
+)
+
+// GeneratorProfile è un insieme di opzioni del generatore con un nome
+// (es. "Account AD", "Utenti DB"), salvato nel database
+type GeneratorProfile struct {
+	Name    string
+	Options PasswordOptions
+}
+
+// GeneratorProfiles ritorna i profili salvati nel database, in ordine
+// alfabetico
+func (db *Database) GeneratorProfiles() ([]GeneratorProfile, error) {
+	data, ok := db.customData(generatorProfilesKey)
+	if !ok {
+		return nil, nil
+	}
+
+	var profiles []GeneratorProfile
+	if err := json.Unmarshal([]byte(data), &profiles); err != nil {
+		return nil, fmt.Errorf("profili del generatore non validi: %w", err)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
+	})
+	return profiles, nil
+}
+
+// GeneratorProfile ritorna il profilo con il nome indicato
+func (db *Database) GeneratorProfile(name string) (GeneratorProfile, bool) {
+	profiles, err := db.GeneratorProfiles()
+	if err != nil {
+		return GeneratorProfile{}, false
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return GeneratorProfile{}, false
+}
+
+// SaveGeneratorProfile aggiunge un profilo o sostituisce quello con lo
+// stesso nome. Le opzioni devono essere valide per il generatore
+func (db *Database) SaveGeneratorProfile(profile GeneratorProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return fmt.Errorf("il nome del profilo è obbligatorio")
+	}
+	if _, err := newPasswordPlan(profile.Options); err != nil {
+		return fmt.Errorf("profilo %q: %w", profile.Name, err)
+	}
+
+	profiles, err := db.GeneratorProfiles()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range profiles {
+		if profiles[i].Name == profile.Name {
+			profiles[i] = profile
+			replaced = true
+		}
+	}
+	if !replaced {
+		profiles = append(profiles, profile)
+	}
+
+	return db.storeGeneratorProfiles(profiles)
+}
+
+// DeleteGeneratorProfile elimina un profilo e le assegnazioni ai gruppi
+// che lo usano
+func (db *Database) DeleteGeneratorProfile(name string) error {
+	profiles, err := db.GeneratorProfiles()
+	if err != nil {
+		return err
+	}
+
+	kept := profiles[:0]
+	for _, profile := range profiles {
+		if profile.Name != name {
+			kept = append(kept, profile)
+		}
+	}
+	if len(kept) == len(profiles) {
+		return fmt.Errorf("profilo non trovato: %s", name)
+	}
+
+	if err := db.storeGeneratorProfiles(kept); err != nil {
+		return err
+	}
+
+	prefix := customDataPrefix + groupProfileKeyName + "/"
+	if db.Content.Meta != nil {
+		items := db.Content.Meta.CustomData[:0]
+		for _, item := range db.Content.Meta.CustomData {
+			if !strings.HasPrefix(item.Key, prefix) || item.Value != name {
+				items = append(items, item)
+			}
+		}
+		db.Content.Meta.CustomData = items
+	}
+
+	return nil
+}
+
+// storeGeneratorProfiles serializza i profili nel CustomData
+func (db *Database) storeGeneratorProfiles(profiles []GeneratorProfile) error {
+	if db.Content == nil {
+		return fmt.Errorf("database non inizializzato correttamente")
+	}
+	if len(profiles) == 0 {
+		db.setCustomData(generatorProfilesKey, "")
+		return nil
+	}
+
+	data, err := json.Marshal(profiles)
+	if err != nil {
+		return fmt.Errorf("errore salvataggio profili: %w", err)
+	}
+
+	db.setCustomData(generatorProfilesKey, string(data))
+	return nil
+}
+
+// SetGroupGeneratorProfile assegna un profilo di default al gruppo; un
+// nome vuoto rimuove l'assegnazione
+func (db *Database) SetGroupGeneratorProfile(groupPath, name string) error {
+	group, _ := db.findGroup(groupPath)
+	if group == nil {
+		return fmt.Errorf("gruppo non trovato: %s", groupPath)
+	}
+	if name != "" {
+		if _, ok := db.GeneratorProfile(name); !ok {
+			return fmt.Errorf("profilo non trovato: %s", name)
+		}
+	}
+
+	db.setCustomData(groupCustomDataKey(groupProfileKeyName, group.UUID), name)
+	return nil
+}
+
+// GroupGeneratorProfile ritorna il profilo di default del gruppo. Se il
+// gruppo non ne ha uno assegnato eredita quello del gruppo padre più vicino;
+// inherited indica se il profilo arriva da un padre
+func (db *Database) GroupGeneratorProfile(groupPath string) (profile GeneratorProfile, inherited, ok bool) {
+	chain := db.groupChain(groupPath)
+	for i := len(chain) - 1; i >= 0; i-- {
+		name, found := db.customData(groupCustomDataKey(groupProfileKeyName, chain[i].UUID))
+		if !found {
+			continue
+		}
+		if profile, ok := db.GeneratorProfile(name); ok {
+			return profile, i < len(chain)-1, true
+		}
+	}
+	return GeneratorProfile{}, false, false
+}
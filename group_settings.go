@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// noProfileLabel è l'opzione per togliere il profilo di default a un gruppo
+const noProfileLabel = "(nessuno)"
+
+// Azioni della policy, nell'ordine di kdbx.PolicyEnforcement
+var enforcementLabels = []string{"Avvisa", "Rifiuta il salvataggio"}
+
+// showGroupSettings mostra le impostazioni del gruppo selezionato, salvate
+// nel CustomData del database
+func (mw *MainWindow) showGroupSettings() {
+	if mw.Database == nil || mw.selectedGroup == "" {
+		dialog.ShowInformation("Gruppo", "Seleziona prima un gruppo", mw.Window)
+		return
+	}
+	groupPath := mw.selectedGroup
+
+	profiles, err := mw.Database.GeneratorProfiles()
+	if err != nil {
+		dialog.ShowError(err, mw.Window)
+		return
+	}
+
+	names := []string{noProfileLabel}
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+
+	profileSelect := widget.NewSelect(names, nil)
+	profileSelect.SetSelected(noProfileLabel)
+
+	inheritedLabel := widget.NewLabel("")
+	inheritedLabel.Wrapping = fyne.TextWrapWord
+	if profile, inherited, ok := mw.Database.GroupGeneratorProfile(groupPath); ok {
+		if inherited {
+			inheritedLabel.SetText(fmt.Sprintf("Ereditato da un gruppo padre: %s", profile.Name))
+		} else {
+			profileSelect.SetSelected(profile.Name)
+		}
+	}
+	if len(profiles) == 0 {
+		inheritedLabel.SetText("Nessun profilo salvato: creane uno dal generatore con \"Salva profilo\"")
+	}
+
+	// Si modifica solo la policy propria del gruppo; quella ereditata è
+	// indicata ma resta sul gruppo padre
+	var policy kdbx.PasswordPolicy
+	policyInfo := widget.NewLabel("Nessuna policy: i campi vuoti non impongono regole")
+	policyInfo.Wrapping = fyne.TextWrapWord
+	if current, sourcePath, ok := mw.Database.GroupPasswordPolicy(groupPath); ok {
+		switch {
+		case sourcePath == groupPath:
+			policy = current
+			policyInfo.SetText("Vale anche per i sottogruppi senza una policy propria")
+		case current.None:
+			policyInfo.SetText(fmt.Sprintf("Nessuna policy: %s non eredita quella dei padri", sourcePath))
+		default:
+			policyInfo.SetText(fmt.Sprintf("Ereditata da %s; impostane una qui per sostituirla", sourcePath))
+		}
+	}
+
+	noneCheck := widget.NewCheck("Nessuna policy, anche se il padre ne ha una", nil)
+	noneCheck.SetChecked(policy.None)
+
+	minLengthEntry := numberEntry(policy.MinLength, "nessun minimo")
+	maxAgeEntry := numberEntry(policy.MaxAgeDays, "nessuna scadenza")
+
+	lowerCheck := widget.NewCheck("Minuscole", nil)
+	lowerCheck.SetChecked(policy.RequireLowercase)
+	upperCheck := widget.NewCheck("Maiuscole", nil)
+	upperCheck.SetChecked(policy.RequireUppercase)
+	digitCheck := widget.NewCheck("Cifre", nil)
+	digitCheck.SetChecked(policy.RequireDigits)
+	specialCheck := widget.NewCheck("Speciali", nil)
+	specialCheck.SetChecked(policy.RequireSpecial)
+
+	reuseCheck := widget.NewCheck("Vieta password ripetute nel gruppo", nil)
+	reuseCheck.SetChecked(policy.NoReuse)
+
+	enforcementSelect := widget.NewSelect(enforcementLabels, nil)
+	enforcementSelect.SetSelectedIndex(int(policy.Enforcement))
+
+	form := dialog.NewForm("Impostazioni gruppo: "+groupPath, "Salva", "Annulla",
+		[]*widget.FormItem{
+			widget.NewFormItem("Profilo generatore", profileSelect),
+			widget.NewFormItem("", inheritedLabel),
+			widget.NewFormItem("Policy password", policyInfo),
+			widget.NewFormItem("", noneCheck),
+			widget.NewFormItem("Lunghezza minima", minLengthEntry),
+			widget.NewFormItem("Classi richieste", container.NewGridWithColumns(2, lowerCheck, upperCheck, digitCheck, specialCheck)),
+			widget.NewFormItem("Durata massima (giorni)", maxAgeEntry),
+			widget.NewFormItem("", reuseCheck),
+			widget.NewFormItem("Se violata", enforcementSelect),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+
+			name := profileSelect.Selected
+			if name == noProfileLabel {
+				name = ""
+			}
+			if err := mw.Database.SetGroupGeneratorProfile(groupPath, name); err != nil {
+				dialog.ShowError(err, mw.Window)
+				return
+			}
+
+			policy := kdbx.PasswordPolicy{
+				None:             noneCheck.Checked,
+				MinLength:        numberValue(minLengthEntry),
+				RequireLowercase: lowerCheck.Checked,
+				RequireUppercase: upperCheck.Checked,
+				RequireDigits:    digitCheck.Checked,
+				RequireSpecial:   specialCheck.Checked,
+				MaxAgeDays:       numberValue(maxAgeEntry),
+				NoReuse:          reuseCheck.Checked,
+				Enforcement:      kdbx.PolicyEnforcement(enforcementSelect.SelectedIndex()),
+			}
+			if err := mw.Database.SetGroupPasswordPolicy(groupPath, policy); err != nil {
+				dialog.ShowError(err, mw.Window)
+			}
+		},
+		mw.Window,
+	)
+	form.Resize(fyne.NewSize(520, 560))
+	form.Show()
+}
+
+// numberEntry crea un campo per un intero non negativo; lo zero è vuoto
+func numberEntry(value int, placeholder string) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.PlaceHolder = placeholder
+	if value > 0 {
+		entry.SetText(strconv.Itoa(value))
+	}
+	entry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		if n, err := strconv.Atoi(s); err != nil || n < 0 {
+			return fmt.Errorf("numero non valido")
+		}
+		return nil
+	}
+	return entry
+}
+
+// numberValue legge il valore di un numberEntry; il validator garantisce
+// che il testo sia vuoto o un numero valido
+func numberValue(entry *widget.Entry) int {
+	n, err := strconv.Atoi(entry.Text)
+	if err != nil {
+		return 0
+	}
+	return n
+}
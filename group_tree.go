@@ -0,0 +1,362 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// groupNode è la riga del tree dei gruppi; ricorda il gruppo che mostra
+// per poter ricevere le entries trascinate dalla lista
+type groupNode struct {
+	widget.Label
+	groupPath string
+}
+
+func newGroupNode() *groupNode {
+	node := &groupNode{}
+	node.ExtendBaseWidget(node)
+	return node
+}
+
+// contains verifica se una posizione assoluta cade sopra il nodo
+func (n *groupNode) contains(driver fyne.Driver, pos fyne.Position) bool {
+	if n.groupPath == "" || !n.Visible() {
+		return false
+	}
+
+	origin := driver.AbsolutePositionForObject(n)
+	size := n.Size()
+	return pos.X >= origin.X && pos.X <= origin.X+size.Width &&
+		pos.Y >= origin.Y && pos.Y <= origin.Y+size.Height
+}
+
+// entryRow è la riga della lista password; può essere trascinata su un
+// gruppo e viene barrata quando la entry è scaduta
+type entryRow struct {
+	widget.Label
+	mw       *MainWindow
+	id       widget.ListItemID
+	dragging bool
+	dragPos  fyne.Position
+	struck   bool
+}
+
+func newEntryRow(mw *MainWindow) *entryRow {
+	row := &entryRow{mw: mw}
+	row.ExtendBaseWidget(row)
+	return row
+}
+
+// SetStruck barra o meno il testo della riga
+func (r *entryRow) SetStruck(struck bool) {
+	if r.struck != struck {
+		r.struck = struck
+		r.Refresh()
+	}
+}
+
+// CreateRenderer aggiunge al renderer della label la linea del barrato,
+// che Fyne non offre tra gli stili del testo
+func (r *entryRow) CreateRenderer() fyne.WidgetRenderer {
+	line := canvas.NewLine(theme.Color(theme.ColorNameForeground))
+	line.StrokeWidth = 1
+	return &entryRowRenderer{WidgetRenderer: r.Label.CreateRenderer(), row: r, line: line}
+}
+
+// entryRowRenderer disegna la label e, se la riga è barrata, una linea a
+// metà altezza lunga quanto il testo
+type entryRowRenderer struct {
+	fyne.WidgetRenderer
+	row  *entryRow
+	line *canvas.Line
+}
+
+func (r *entryRowRenderer) Layout(size fyne.Size) {
+	r.WidgetRenderer.Layout(size)
+
+	padding := theme.InnerPadding()
+	width := fyne.MeasureText(r.row.Text, theme.TextSize(), r.row.TextStyle).Width
+	y := size.Height / 2
+	r.line.Position1 = fyne.NewPos(padding, y)
+	r.line.Position2 = fyne.NewPos(fyne.Min(padding+width, size.Width), y)
+}
+
+func (r *entryRowRenderer) Objects() []fyne.CanvasObject {
+	return append(r.WidgetRenderer.Objects(), r.line)
+}
+
+func (r *entryRowRenderer) Refresh() {
+	r.WidgetRenderer.Refresh()
+	r.line.StrokeColor = theme.Color(theme.ColorNameForeground)
+	r.line.Hidden = !r.row.struck
+	r.Layout(r.row.Size())
+	r.line.Refresh()
+}
+
+// Dragged registra la posizione corrente del trascinamento
+func (r *entryRow) Dragged(ev *fyne.DragEvent) {
+	r.dragging = true
+	r.dragPos = ev.AbsolutePosition
+}
+
+// DragEnd sposta la entry nel gruppo su cui è stata rilasciata
+func (r *entryRow) DragEnd() {
+	if !r.dragging {
+		return
+	}
+	r.dragging = false
+	r.mw.dropEntry(r.id, r.dragPos)
+}
+
+// createGroupSidebar crea la sidebar con il tree dei gruppi
+func (mw *MainWindow) createGroupSidebar() fyne.CanvasObject {
+	mw.groupTree = mw.createGroupTree()
+
+	recursiveCheck := widget.NewCheck("Includi sottogruppi", func(checked bool) {
+		mw.recursiveView = checked
+		mw.refreshEntries()
+	})
+	recursiveCheck.SetChecked(mw.recursiveView)
+
+	allBtn := widget.NewButton("Tutte le password", func() {
+		mw.groupTree.UnselectAll()
+		mw.selectedGroup = ""
+		mw.refreshEntries()
+	})
+
+	expiringCheck := widget.NewCheck("Solo scadute o in scadenza", func(checked bool) {
+		mw.expiringOnly = checked
+		mw.refreshEntries()
+	})
+
+	mw.tagSelect = widget.NewSelect(nil, nil)
+	mw.tagSelect.PlaceHolder = allTagsLabel
+
+	settingsBtn := widget.NewButton("Impostazioni gruppo", mw.showGroupSettings)
+
+	return container.NewBorder(
+		widget.NewLabel("Gruppi"),
+		container.NewVBox(recursiveCheck, expiringCheck, widget.NewLabel("Tag"), mw.tagSelect, allBtn, settingsBtn),
+		nil,
+		nil,
+		mw.groupTree,
+	)
+}
+
+// createGroupTree crea il tree basato sulla gerarchia dei gruppi del database
+func (mw *MainWindow) createGroupTree() *widget.Tree {
+	tree := widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			var children []kdbx.Group
+			if uid == "" {
+				children = mw.groups
+			} else if group, ok := mw.groupIndex[uid]; ok {
+				children = group.SubGroups
+			}
+
+			ids := make([]widget.TreeNodeID, 0, len(children))
+			for _, child := range children {
+				ids = append(ids, child.Path)
+			}
+			return ids
+		},
+		func(uid widget.TreeNodeID) bool {
+			if uid == "" {
+				return true
+			}
+			group, ok := mw.groupIndex[uid]
+			return ok && len(group.SubGroups) > 0
+		},
+		func(branch bool) fyne.CanvasObject {
+			return newGroupNode()
+		},
+		func(uid widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+			node := obj.(*groupNode)
+			// Il tree ricicla i nodi: l'indice punta solo al nodo che
+			// mostra il gruppo adesso
+			if mw.groupNodes[node.groupPath] == node {
+				delete(mw.groupNodes, node.groupPath)
+			}
+			node.groupPath = uid
+			mw.groupNodes[uid] = node
+
+			group, ok := mw.groupIndex[uid]
+			if !ok {
+				node.SetText(uid)
+				return
+			}
+			node.SetText(fmt.Sprintf("%s (%d)", group.Name, group.CountEntries(mw.recursiveView)))
+		},
+	)
+
+	tree.OnSelected = func(uid widget.TreeNodeID) {
+		mw.selectedGroup = uid
+		mw.refreshEntries()
+	}
+
+	// Salva lo stato espanso/collassato nel database (campo IsExpanded)
+	tree.OnBranchOpened = func(uid widget.TreeNodeID) {
+		if mw.Database != nil {
+			mw.Database.SetGroupExpanded(uid, true)
+		}
+	}
+	tree.OnBranchClosed = func(uid widget.TreeNodeID) {
+		if mw.Database != nil {
+			mw.Database.SetGroupExpanded(uid, false)
+		}
+	}
+
+	return tree
+}
+
+// refreshEntries ricarica gruppi ed entries dal database applicando il
+// filtro del gruppo selezionato, quelli di scadenza e tag e la ricerca
+func (mw *MainWindow) refreshEntries() {
+	// Prima dei filtri: azzera il tag selezionato se non esiste più
+	mw.refreshTagFilter()
+
+	mw.groups = nil
+	mw.groupIndex = map[string]*kdbx.Group{}
+	mw.entries = []kdbx.Entry{}
+
+	if mw.Database != nil {
+		mw.groups = mw.Database.GetAllGroups()
+		indexGroups(mw.groups, mw.groupIndex)
+		for path := range mw.groupNodes {
+			if _, ok := mw.groupIndex[path]; !ok {
+				delete(mw.groupNodes, path)
+			}
+		}
+
+		if _, ok := mw.groupIndex[mw.selectedGroup]; ok {
+			mw.entries = mw.Database.GetEntriesInGroup(mw.selectedGroup, mw.recursiveView)
+		} else {
+			mw.selectedGroup = ""
+			mw.entries = mw.Database.GetAllEntries()
+		}
+
+		if mw.expiringOnly {
+			now := time.Now()
+			expiring := []kdbx.Entry{}
+			for _, e := range mw.entries {
+				if mw.isExpiringEntry(e, now) {
+					expiring = append(expiring, e)
+				}
+			}
+			mw.entries = expiring
+		}
+
+		if mw.selectedTag != "" {
+			tagged := []kdbx.Entry{}
+			for _, e := range mw.entries {
+				if e.HasTag(mw.selectedTag) {
+					tagged = append(tagged, e)
+				}
+			}
+			mw.entries = tagged
+		}
+
+		mw.entries = mw.applySearch(mw.entries)
+	}
+
+	mw.entryList.UnselectAll()
+	mw.entryList.Refresh()
+	mw.groupTree.Refresh()
+}
+
+// restoreExpandedGroups riapre i gruppi salvati come espansi nel database
+func (mw *MainWindow) restoreExpandedGroups() {
+	mw.groupTree.CloseAllBranches()
+	for path, group := range mw.groupIndex {
+		if group.IsExpanded {
+			mw.groupTree.OpenBranch(path)
+		}
+	}
+}
+
+// indexGroups indicizza ricorsivamente i gruppi per path
+func indexGroups(groups []kdbx.Group, index map[string]*kdbx.Group) {
+	for i := range groups {
+		index[groups[i].Path] = &groups[i]
+		indexGroups(groups[i].SubGroups, index)
+	}
+}
+
+// dropEntry sposta la entry trascinata nel gruppo sotto la posizione di rilascio
+func (mw *MainWindow) dropEntry(id widget.ListItemID, pos fyne.Position) {
+	if mw.Database == nil || id < 0 || id >= len(mw.entries) {
+		return
+	}
+
+	entry := mw.entries[id]
+	driver := mw.App.Driver()
+
+	for path, node := range mw.groupNodes {
+		if !node.contains(driver, pos) {
+			continue
+		}
+		if path == entry.GroupPath {
+			return
+		}
+
+		err := mw.Database.MoveEntry(entry.UUID, path)
+		if err != nil {
+			dialog.ShowError(err, mw.Window)
+			return
+		}
+
+		mw.refreshEntries()
+		return
+	}
+}
+
+// allTagsLabel è l'opzione del filtro tag che mostra tutte le entries
+const allTagsLabel = "Tutti i tag"
+
+// refreshTagFilter aggiorna le opzioni del filtro tag con i tag del
+// database e il numero di entries che li usano
+func (mw *MainWindow) refreshTagFilter() {
+	var tags []kdbx.TagCount
+	if mw.Database != nil {
+		tags = mw.Database.AllTags()
+	}
+
+	options := []string{allTagsLabel}
+	selected := 0
+	for _, tag := range tags {
+		options = append(options, fmt.Sprintf("%s (%d)", tag.Tag, tag.Count))
+		if strings.EqualFold(tag.Tag, mw.selectedTag) {
+			selected = len(options) - 1
+		}
+	}
+	if selected == 0 {
+		// Il tag filtrato non esiste più
+		mw.selectedTag = ""
+	}
+
+	mw.tagSelect.OnChanged = nil
+	mw.tagSelect.SetOptions(options)
+	mw.tagSelect.SetSelectedIndex(selected)
+	mw.tagSelect.OnChanged = func(string) {
+		index := mw.tagSelect.SelectedIndex()
+		if index < 0 {
+			return
+		}
+
+		mw.selectedTag = ""
+		if index > 0 {
+			mw.selectedTag = tags[index-1].Tag
+		}
+		mw.refreshEntries()
+	}
+}
@@ -0,0 +1,221 @@
+package kdbx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HealthIssueKind è il tipo di problema trovato dal controllo di salute
+type HealthIssueKind string
+
+const (
+	IssueWeak       HealthIssueKind = "weak"        // Password debole o vuota
+	IssueReused     HealthIssueKind = "reused"      // Stessa password in più entries
+	IssueOld        HealthIssueKind = "old"         // Password non cambiata da troppo tempo
+	IssueExpired    HealthIssueKind = "expired"     // Entry scaduta
+	IssueMissingURL HealthIssueKind = "missing_url" // Entry senza URL
+)
+
+// HealthIssueKinds elenca i tipi di problema in ordine di gravità
+var HealthIssueKinds = []HealthIssueKind{IssueWeak, IssueReused, IssueExpired, IssueOld, IssueMissingURL}
+
+// Label ritorna la descrizione del tipo di problema per l'interfaccia
+func (k HealthIssueKind) Label() string {
+	switch k {
+	case IssueWeak:
+		return "Password deboli"
+	case IssueReused:
+		return "Password riutilizzate"
+	case IssueOld:
+		return "Password vecchie"
+	case IssueExpired:
+		return "Entries scadute"
+	case IssueMissingURL:
+		return "Senza URL"
+	default:
+		return string(k)
+	}
+}
+
+// HealthIssue è un problema di una entry
+type HealthIssue struct {
+	Kind   HealthIssueKind `json:"kind"`
+	Detail string          `json:"detail"`
+}
+
+// EntryHealth è il risultato del controllo per una entry con problemi.
+// Il report non contiene mai le password
+type EntryHealth struct {
+	Entry           Entry         `json:"-"`
+	UUID            string        `json:"uuid"`
+	Title           string        `json:"title"`
+	Username        string        `json:"username"`
+	GroupPath       string        `json:"group"`
+	Score           StrengthScore `json:"score"`
+	PasswordAgeDays int           `json:"password_age_days"`
+	Issues          []HealthIssue `json:"issues"`
+}
+
+// HasIssue indica se la entry ha un problema del tipo indicato
+func (h EntryHealth) HasIssue(kind HealthIssueKind) bool {
+	for _, issue := range h.Issues {
+		if issue.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthOptions configura le soglie del controllo di salute
+type HealthOptions struct {
+	MinScore   StrengthScore // Sotto questo punteggio la password è debole
+	MaxAgeDays int           // Oltre questi giorni la password è vecchia (0 = ignora)
+	Now        time.Time     // Istante di riferimento (zero = adesso)
+}
+
+// DefaultHealthOptions ritorna soglie ragionevoli: almeno "discreta" e
+// cambiata nell'ultimo anno
+func DefaultHealthOptions() HealthOptions {
+	return HealthOptions{
+		MinScore:   ScoreFair,
+		MaxAgeDays: 365,
+	}
+}
+
+// HealthReport è il risultato del controllo di salute del database
+type HealthReport struct {
+	GeneratedAt  time.Time               `json:"generated_at"`
+	TotalEntries int                     `json:"total_entries"`
+	Counts       map[HealthIssueKind]int `json:"counts"`
+	Entries      []EntryHealth           `json:"entries"`
+}
+
+// HealthReport controlla tutte le entries del database
+func (db *Database) HealthReport(opts HealthOptions) HealthReport {
+	return CheckHealth(db.GetAllEntries(), opts)
+}
+
+// CheckHealth controlla le entries e ritorna solo quelle con problemi,
+// dalla più problematica
+func CheckHealth(entries []Entry, opts HealthOptions) HealthReport {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	report := HealthReport{
+		GeneratedAt:  now,
+		TotalEntries: len(entries),
+		Counts:       map[HealthIssueKind]int{},
+	}
+
+	// Raggruppa le entries per password e stima ogni password una volta sola
+	byPassword := map[string][]int{}
+	for i, e := range entries {
+		if e.Password != "" {
+			byPassword[e.Password] = append(byPassword[e.Password], i)
+		}
+	}
+	strengths := make(map[string]PasswordStrength, len(byPassword))
+	for password := range byPassword {
+		strengths[password] = EstimateStrength(password)
+	}
+
+	for i, e := range entries {
+		health := EntryHealth{
+			Entry:     e,
+			UUID:      hex.EncodeToString(e.UUID[:]),
+			Title:     e.Title,
+			Username:  e.Username,
+			GroupPath: e.GroupPath,
+		}
+		if !e.PasswordChanged.IsZero() {
+			health.PasswordAgeDays = int(now.Sub(e.PasswordChanged).Hours() / 24)
+		}
+
+		if e.Password == "" {
+			health.Issues = append(health.Issues, HealthIssue{IssueWeak, "password vuota"})
+		} else {
+			strength := strengths[e.Password]
+			health.Score = strength.Score
+			if strength.Score < opts.MinScore {
+				detail := fmt.Sprintf("%s (~%.0f bit)", strength.Score, strength.Entropy)
+				if len(strength.Warnings) > 0 {
+					detail += ": " + strength.Warnings[0]
+				}
+				health.Issues = append(health.Issues, HealthIssue{IssueWeak, detail})
+			}
+
+			if others := byPassword[e.Password]; len(others) > 1 {
+				var titles []string
+				for _, j := range others {
+					if j != i {
+						titles = append(titles, entries[j].Title)
+					}
+				}
+				health.Issues = append(health.Issues, HealthIssue{IssueReused,
+					fmt.Sprintf("usata anche da: %s", strings.Join(titles, ", "))})
+			}
+		}
+
+		if e.IsExpired(now) {
+			health.Issues = append(health.Issues, HealthIssue{IssueExpired,
+				fmt.Sprintf("scaduta il %s", e.ExpiryTime.Local().Format("2006-01-02"))})
+		}
+
+		if opts.MaxAgeDays > 0 && !e.PasswordChanged.IsZero() && health.PasswordAgeDays > opts.MaxAgeDays {
+			health.Issues = append(health.Issues, HealthIssue{IssueOld,
+				fmt.Sprintf("non cambiata da %d giorni", health.PasswordAgeDays)})
+		}
+
+		if strings.TrimSpace(e.URL) == "" {
+			health.Issues = append(health.Issues, HealthIssue{IssueMissingURL, "nessun URL"})
+		}
+
+		if len(health.Issues) == 0 {
+			continue
+		}
+		for _, issue := range health.Issues {
+			report.Counts[issue.Kind]++
+		}
+		report.Entries = append(report.Entries, health)
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i], report.Entries[j]
+		if sa, sb := issueSeverity(a), issueSeverity(b); sa != sb {
+			return sa > sb
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+
+	return report
+}
+
+// issueSeverity pesa i problemi di una entry secondo HealthIssueKinds
+func issueSeverity(h EntryHealth) int {
+	severity := 0
+	for _, issue := range h.Issues {
+		for rank, kind := range HealthIssueKinds {
+			if issue.Kind == kind {
+				severity += 1 << (len(HealthIssueKinds) - rank)
+			}
+		}
+	}
+	return severity
+}
+
+// WriteJSON scrive il report in JSON, per script e strumenti esterni
+func (r HealthReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("errore scrittura report: %w", err)
+	}
+	return nil
+}
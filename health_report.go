@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// allIssuesLabel è il filtro del report che mostra tutti i problemi
+const allIssuesLabel = "Tutti i problemi"
+
+// showHealthReport mostra il controllo di salute del database: entries
+// deboli, riutilizzate, vecchie, scadute e senza URL, con esportazione JSON
+func (mw *MainWindow) showHealthReport() {
+	if mw.Database == nil {
+		dialog.ShowInformation("Report sicurezza", "Apri prima un database", mw.Window)
+		return
+	}
+
+	opts := kdbx.DefaultHealthOptions()
+	var report kdbx.HealthReport
+	var visible []kdbx.EntryHealth
+	filter := ""
+
+	summaryLabel := widget.NewLabel("")
+	summaryLabel.Wrapping = fyne.TextWrapWord
+
+	list := widget.NewList(
+		func() int {
+			return len(visible)
+		},
+		func() fyne.CanvasObject {
+			title := widget.NewLabel("Template")
+			title.TextStyle = fyne.TextStyle{Bold: true}
+			issues := widget.NewLabel("Template")
+			issues.Wrapping = fyne.TextWrapWord
+			return container.NewVBox(title, issues)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			health := visible[id]
+			rows := obj.(*fyne.Container).Objects
+			rows[0].(*widget.Label).SetText(fmt.Sprintf("%s — %s", health.Title, health.GroupPath))
+
+			details := make([]string, len(health.Issues))
+			for i, issue := range health.Issues {
+				details[i] = fmt.Sprintf("• %s: %s", issue.Kind.Label(), issue.Detail)
+			}
+			rows[1].(*widget.Label).SetText(strings.Join(details, "\n"))
+		},
+	)
+
+	filterSelect := widget.NewSelect(nil, nil)
+
+	applyFilter := func() {
+		visible = visible[:0]
+		for _, health := range report.Entries {
+			if filter == "" || health.HasIssue(kdbx.HealthIssueKind(filter)) {
+				visible = append(visible, health)
+			}
+		}
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	// Le opzioni del filtro riportano il conteggio di ogni tipo
+	filterKinds := []string{""}
+	run := func() {
+		report = mw.Database.HealthReport(opts)
+		summaryLabel.SetText(fmt.Sprintf("%d entries controllate, %d con problemi",
+			report.TotalEntries, len(report.Entries)))
+
+		labels := []string{allIssuesLabel}
+		filterKinds = filterKinds[:1]
+		for _, kind := range kdbx.HealthIssueKinds {
+			labels = append(labels, fmt.Sprintf("%s (%d)", kind.Label(), report.Counts[kind]))
+			filterKinds = append(filterKinds, string(kind))
+		}
+		filterSelect.SetOptions(labels)
+		for i, kind := range filterKinds {
+			if kind == filter {
+				filterSelect.SetSelectedIndex(i)
+			}
+		}
+		applyFilter()
+	}
+	filterSelect.OnChanged = func(string) {
+		if index := filterSelect.SelectedIndex(); index >= 0 {
+			filter = filterKinds[index]
+			applyFilter()
+		}
+	}
+
+	maxAgeEntry := numberEntry(opts.MaxAgeDays, "ignora")
+	maxAgeEntry.OnChanged = func(string) {
+		if maxAgeEntry.Validate() == nil {
+			opts.MaxAgeDays = numberValue(maxAgeEntry)
+			run()
+		}
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		entry := visible[id].Entry
+		mw.showEntryEditor(&entry)
+	}
+
+	exportBtn := widget.NewButton("Esporta JSON...", func() {
+		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if err := report.WriteJSON(writer); err != nil {
+				dialog.ShowError(err, mw.Window)
+			}
+		}, mw.Window)
+	})
+	refreshBtn := widget.NewButton("Ricontrolla", run)
+
+	run()
+
+	content := container.NewBorder(
+		container.NewVBox(
+			summaryLabel,
+			container.NewBorder(nil, nil, widget.NewLabel("Mostra"), nil, filterSelect),
+			container.NewBorder(nil, nil, widget.NewLabel("Password vecchie dopo (giorni)"), nil, maxAgeEntry),
+			widget.NewLabel("Seleziona una entry per modificarla. Il report esportato non contiene password."),
+		),
+		container.NewHBox(refreshBtn, exportBtn),
+		nil,
+		nil,
+		list,
+	)
+
+	d := dialog.NewCustom("Report sicurezza", "Chiudi", content, mw.Window)
+	d.Resize(fyne.NewSize(760, 600))
+	d.Show()
+}
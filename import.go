@@ -0,0 +1,493 @@
+package kdbx
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// Errori degli export cifrati, per chiedere (di nuovo) la password
+var (
+	ErrImportPasswordRequired = errors.New("l'export è protetto da password")
+	ErrImportWrongPassword    = errors.New("password dell'export errata")
+)
+
+// templatesGroupName è il nome del gruppo dei modelli creato importando
+// dei modelli in un database che non ne ha uno
+const templatesGroupName = "Modelli"
+
+// ImportResult riassume un'importazione
+type ImportResult struct {
+	Imported int
+	Updated  int      // Entries esistenti unite o sovrascritte
+	Skipped  int      // Entries già presenti e non importate
+	Groups   []string // Gruppi di destinazione, nell'ordine di comparsa
+
+	// Conflicts conta le entries unite o sovrascritte con una password
+	// diversa: quella non usata resta nella history
+	Conflicts int
+
+	Templates int // Modelli aggiunti al gruppo dei modelli
+}
+
+// DuplicateAction è cosa fare di una entry importata che ha lo stesso host
+// e username di una entry esistente
+type DuplicateAction int
+
+const (
+	// DuplicateMerge completa i campi vuoti della entry esistente e
+	// aggiunge i campi aggiuntivi mancanti. La password esistente resta;
+	// una password importata diversa va nella history
+	DuplicateMerge DuplicateAction = iota
+	// DuplicateSkip ignora la entry importata
+	DuplicateSkip
+	// DuplicateOverwrite sostituisce username, password, URL, note, TOTP e
+	// campi della entry esistente (titolo e gruppo restano); la versione
+	// precedente va nella history
+	DuplicateOverwrite
+	// DuplicateKeep importa comunque la entry, creando un doppione
+	DuplicateKeep
+)
+
+// DuplicateActions elenca le azioni nell'ordine in cui mostrarle
+var DuplicateActions = []DuplicateAction{DuplicateMerge, DuplicateSkip, DuplicateOverwrite, DuplicateKeep}
+
+// Label ritorna il nome dell'azione da mostrare nell'interfaccia
+func (a DuplicateAction) Label() string {
+	switch a {
+	case DuplicateMerge:
+		return "Unisci (completa i campi vuoti, le password diverse vanno nella history)"
+	case DuplicateSkip:
+		return "Ignora"
+	case DuplicateOverwrite:
+		return "Sovrascrivi (la versione attuale va nella history)"
+	case DuplicateKeep:
+		return "Importa comunque (crea doppioni)"
+	}
+	return ""
+}
+
+// ImportEntries aggiunge le entries importate nei rispettivi gruppi,
+// creando quelli mancanti. Le entries con lo stesso host e username di una
+// esistente (o di una importata prima) sono trattate secondo duplicates;
+// nessuna password viene persa, quelle non usate restano nella history.
+// Le entries con Template vanno nel gruppo dei modelli del database, se
+// non c'è già un modello con lo stesso titolo.
+// Le policy dei gruppi non vengono applicate: le password importate
+// esistono già e il report sicurezza segnala le deboli
+func (db *Database) ImportEntries(entries []Entry, duplicates DuplicateAction) (ImportResult, error) {
+	var result ImportResult
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return result, fmt.Errorf("database non inizializzato correttamente")
+	}
+
+	existing := db.duplicateIndex()
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if e.Template {
+			if db.addTemplate(e) {
+				result.Templates++
+			}
+			continue
+		}
+
+		key := duplicateKey(e)
+		if match, ok := existing[key]; ok && duplicates != DuplicateKeep {
+			if duplicates == DuplicateSkip {
+				result.Skipped++
+				continue
+			}
+
+			updated := mergeImportedEntry(match, e)
+			if duplicates == DuplicateOverwrite {
+				updated = overwriteImportedEntry(match, e)
+			}
+			parent, index := findEntry(&db.Content.Root.Groups[0], match.UUID)
+			if parent == nil {
+				return result, fmt.Errorf("entry non trovata")
+			}
+			if err := db.updateEntry(parent, index, updated, duplicates == DuplicateOverwrite); err != nil {
+				return result, err
+			}
+			if passwordConflict(match, e) {
+				result.Conflicts++
+				if duplicates == DuplicateMerge {
+					addImportedPassword(&parent.Entries[index], e.Password)
+				}
+			}
+			existing[key] = updated
+			result.Updated++
+			continue
+		}
+
+		added := db.addEntry(e)
+		if key != "" {
+			existing[key] = added
+		}
+		result.Imported++
+
+		if !seen[e.GroupPath] {
+			seen[e.GroupPath] = true
+			result.Groups = append(result.Groups, e.GroupPath)
+		}
+	}
+
+	return result, nil
+}
+
+// addTemplate aggiunge un modello al gruppo dei modelli, a meno che ne
+// contenga già uno con lo stesso titolo. Ritorna se l'ha aggiunto
+func (db *Database) addTemplate(e Entry) bool {
+	group := db.templatesGroup()
+	for i := range group.Entries {
+		if group.Entries[i].GetTitle() == e.Title {
+			return false
+		}
+	}
+
+	entry := gokeepasslib.NewEntry()
+	applyEntryFields(&entry, e)
+	group.Entries = append(group.Entries, entry)
+	return true
+}
+
+// templatesGroup ritorna il gruppo dei modelli indicato da Meta
+// EntryTemplatesGroup, quello che KeePass 2 propone per le nuove entries.
+// Se il database non ne ha uno crea "Modelli" sotto il root e lo registra
+func (db *Database) templatesGroup() *gokeepasslib.Group {
+	if db.Content.Meta == nil {
+		db.Content.Meta = gokeepasslib.NewMetaData()
+	}
+	meta := db.Content.Meta
+
+	var uuid gokeepasslib.UUID
+	if err := uuid.UnmarshalText([]byte(meta.EntryTemplatesGroup)); err == nil && uuid != (gokeepasslib.UUID{}) {
+		if group := findGroupByUUID(&db.Content.Root.Groups[0], uuid); group != nil {
+			return group
+		}
+	}
+
+	group := db.findOrCreateGroup(templatesGroupName)
+	text, _ := group.UUID.MarshalText()
+	meta.EntryTemplatesGroup = string(text)
+	changed := w.Now()
+	meta.EntryTemplatesGroupChanged = &changed
+	return group
+}
+
+// duplicateIndex indicizza le entries del database per host e username
+func (db *Database) duplicateIndex() map[string]Entry {
+	index := map[string]Entry{}
+	for _, e := range db.GetAllEntries() {
+		if key := duplicateKey(e); key != "" {
+			if _, ok := index[key]; !ok {
+				index[key] = e
+			}
+		}
+	}
+	return index
+}
+
+// duplicateKey è la chiave con cui riconoscere i doppioni importati: host
+// dell'URL e username. Vuota per le entries senza URL
+func duplicateKey(e Entry) string {
+	host := urlHost(e.URL)
+	if host == "" {
+		return ""
+	}
+	return host + "\x00" + strings.ToLower(strings.TrimSpace(e.Username))
+}
+
+// urlHost ritorna l'host di un URL in minuscolo e senza "www.", anche se
+// manca lo schema ("example.com/login")
+func urlHost(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// mergeImportedEntry completa la entry esistente con i dati importati:
+// campi vuoti, note diverse (in coda), campi aggiuntivi, tag e allegati
+func mergeImportedEntry(existing, imported Entry) Entry {
+	merged := existing
+	if merged.Password == "" {
+		merged.Password = imported.Password
+	}
+	if merged.URL == "" {
+		merged.URL = imported.URL
+	}
+	if merged.OTP == "" {
+		merged.OTP = imported.OTP
+	}
+	if notes := strings.TrimSpace(imported.Notes); notes != "" && !strings.Contains(merged.Notes, notes) {
+		merged.Notes = strings.TrimSpace(merged.Notes + "\n\n" + notes)
+	}
+
+	merged.Fields = append([]CustomField(nil), existing.Fields...)
+	keys := map[string]bool{}
+	for _, field := range merged.Fields {
+		keys[field.Key] = true
+	}
+	for _, field := range imported.Fields {
+		if !keys[field.Key] {
+			merged.Fields = append(merged.Fields, field)
+		}
+	}
+
+	merged.Tags = ParseTags(JoinTags(existing.Tags) + ";" + JoinTags(imported.Tags))
+
+	// Gli allegati con lo stesso nome di uno esistente sono già presenti:
+	// aggiungerli di nuovo li duplicherebbe a ogni importazione
+	merged.Attachments = append([]Attachment(nil), existing.Attachments...)
+	names := map[string]bool{}
+	for _, attachment := range existing.Attachments {
+		names[attachment.Name] = true
+	}
+	for _, attachment := range imported.Attachments {
+		if !names[attachment.Name] {
+			names[attachment.Name] = true
+			merged.Attachments = append(merged.Attachments, attachment)
+		}
+	}
+	return merged
+}
+
+// passwordConflict indica se la entry importata ha una password diversa da
+// quella della entry esistente
+func passwordConflict(existing, imported Entry) bool {
+	return imported.Password != "" && existing.Password != "" && imported.Password != existing.Password
+}
+
+// addImportedPassword salva nella history una versione della entry con la
+// password importata, se non c'è già (importando di nuovo lo stesso file),
+// lasciando invariata la password attuale
+func addImportedPassword(entry *gokeepasslib.Entry, password string) {
+	for _, history := range entry.Histories {
+		for _, version := range history.Entries {
+			if version.GetPassword() == password {
+				return
+			}
+		}
+	}
+
+	current := entry.GetPassword()
+	setEntryValue(entry, "Password", password, true)
+	addToHistory(entry)
+	setEntryValue(entry, "Password", current, true)
+}
+
+// overwriteImportedEntry sostituisce i dati della entry esistente con
+// quelli importati, mantenendo titolo, gruppo e UUID. Anche gli allegati
+// vengono sostituiti (ImportEntries toglie quelli esistenti)
+func overwriteImportedEntry(existing, imported Entry) Entry {
+	updated := existing
+	updated.Username = imported.Username
+	updated.Password = imported.Password
+	updated.URL = imported.URL
+	updated.Notes = imported.Notes
+	updated.OTP = imported.OTP
+	updated.Fields = imported.Fields
+	updated.Tags = ParseTags(JoinTags(existing.Tags) + ";" + JoinTags(imported.Tags))
+	updated.Attachments = imported.Attachments
+	return updated
+}
+
+// ImportGroupSummary è un gruppo di destinazione nel riepilogo
+type ImportGroupSummary struct {
+	Path    string
+	Entries int
+	New     bool // Il gruppo non esiste e verrà creato
+}
+
+// ImportSummary descrive cosa farebbe un'importazione, senza eseguirla
+type ImportSummary struct {
+	Entries      int
+	WithPassword int
+	WithTOTP     int
+	WithFields   int
+	Attachments  int
+	Duplicates   int                  // Stesso host e username di una entry esistente
+	Conflicts    int                  // Doppioni con una password diversa
+	Templates    int                  // Modelli per il gruppo dei modelli
+	Groups       []ImportGroupSummary // In ordine alfabetico
+}
+
+// DryRunImport calcola il riepilogo dell'importazione senza modificare il
+// database
+func (db *Database) DryRunImport(entries []Entry) ImportSummary {
+	var summary ImportSummary
+	index := map[string]int{}
+	existing := db.duplicateIndex()
+
+	for _, e := range entries {
+		if e.Template {
+			summary.Templates++
+			continue
+		}
+		summary.Entries++
+
+		if key := duplicateKey(e); key != "" {
+			if match, ok := existing[key]; ok {
+				summary.Duplicates++
+				if passwordConflict(match, e) {
+					summary.Conflicts++
+				}
+			} else {
+				existing[key] = e
+			}
+		}
+		if e.Password != "" {
+			summary.WithPassword++
+		}
+		if e.OTP != "" {
+			summary.WithTOTP++
+		}
+		if len(e.Fields) > 0 {
+			summary.WithFields++
+		}
+		summary.Attachments += len(e.Attachments)
+
+		i, ok := index[e.GroupPath]
+		if !ok {
+			group, _ := db.findGroup(e.GroupPath)
+			i = len(summary.Groups)
+			index[e.GroupPath] = i
+			summary.Groups = append(summary.Groups, ImportGroupSummary{Path: e.GroupPath, New: group == nil})
+		}
+		summary.Groups[i].Entries++
+	}
+
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		return summary.Groups[i].Path < summary.Groups[j].Path
+	})
+	return summary
+}
+
+// importTitle sceglie un titolo per una entry importata che non lo ha:
+// l'host dell'URL, altrimenti lo username
+func importTitle(e Entry) string {
+	if title := strings.TrimSpace(e.Title); title != "" {
+		return title
+	}
+	if u, err := url.Parse(strings.TrimSpace(e.URL)); err == nil && u.Host != "" {
+		return strings.TrimPrefix(u.Hostname(), "www.")
+	}
+	if e.Username != "" {
+		return e.Username
+	}
+	return "(senza titolo)"
+}
+
+// importOTP converte il TOTP di un export (URI otpauth:// o seed base32)
+// nell'URI salvato nella entry; un valore illeggibile viene ignorato
+func importOTP(value string, e Entry) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if strings.HasPrefix(strings.ToLower(value), "otpauth://") {
+		if _, err := ParseOTPURI(value); err != nil {
+			return ""
+		}
+		return value
+	}
+
+	totp, err := ParseLegacyTOTP(value, "")
+	if err != nil {
+		return ""
+	}
+	totp.Issuer, totp.Account = e.Title, e.Username
+	return totp.URI()
+}
+
+// importGroupPath converte un percorso di gruppo di un export ("a/b",
+// "a\b" o "a / b") nel formato di GroupPath
+func importGroupPath(path string) string {
+	var names []string
+	for _, name := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '\\'
+	}) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, PathSeparator)
+}
+
+// importField associa una chiave di un export al nome del campo aggiuntivo
+type importField struct {
+	source    string
+	key       string
+	protected bool
+}
+
+// addImportFields aggiunge i valori non vuoti di un oggetto dell'export
+// come campi aggiuntivi, nell'ordine di fields
+func addImportFields(e *Entry, values map[string]any, fields []importField) {
+	for _, field := range fields {
+		addImportField(e, field.key, stringValue(values[field.source]), field.protected)
+	}
+}
+
+// addImportField aggiunge un campo aggiuntivo non vuoto; un nome già usato
+// (o riservato ai campi standard) riceve un suffisso numerico
+func addImportField(e *Entry, key, value string, protected bool) {
+	key = strings.TrimSpace(key)
+	if value == "" {
+		return
+	}
+	if key == "" {
+		key = "Campo"
+	}
+
+	taken := func(name string) bool {
+		if standardFields[name] || isOTPField(name) {
+			return true
+		}
+		for _, field := range e.Fields {
+			if field.Key == name {
+				return true
+			}
+		}
+		return false
+	}
+	unique := key
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%s (%d)", key, i)
+	}
+
+	e.Fields = append(e.Fields, CustomField{Key: unique, Value: value, Protected: protected})
+}
+
+// secretFieldWords sono le parole che identificano, nel nome, i campi
+// importati da proteggere (numeri di carte e conti, PIN, chiavi...)
+var secretFieldWords = []string{
+	"password", "passphrase", "pin", "secret", "token", "private key",
+	"security code", "number", "recovery",
+}
+
+// isSecretFieldKey indica se un campo importato va protetto, in base al
+// nome, per i formati che non lo indicano
+func isSecretFieldKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range secretFieldWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
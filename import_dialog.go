@@ -0,0 +1,254 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// importParser converte il contenuto di un export in entries; password è
+// vuota al primo tentativo
+type importParser func(data []byte, password string) ([]kdbx.Entry, error)
+
+// showFileImport sceglie il file da importare, chiede la password se
+// l'export è cifrato e mostra il riepilogo prima dell'importazione
+func (mw *MainWindow) showFileImport(title string, parse importParser) {
+	if mw.Database == nil {
+		dialog.ShowInformation(title, "Apri o crea prima un database", mw.Window)
+		return
+	}
+
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("errore lettura file: %w", err), mw.Window)
+			return
+		}
+		mw.parseImport(title, data, "", parse)
+	}, mw.Window)
+}
+
+// parseImport esegue il parser, chiedendo la password finché serve
+func (mw *MainWindow) parseImport(title string, data []byte, password string, parse importParser) {
+	entries, err := parse(data, password)
+	switch {
+	case errors.Is(err, kdbx.ErrImportPasswordRequired), errors.Is(err, kdbx.ErrImportWrongPassword):
+		prompt := "Password dell'export"
+		if errors.Is(err, kdbx.ErrImportWrongPassword) {
+			prompt = "Password errata, riprova"
+		}
+		mw.promptPassword(prompt, func(password string) {
+			mw.parseImport(title, data, password, parse)
+		})
+	case err != nil:
+		dialog.ShowError(err, mw.Window)
+	case len(entries) == 0:
+		dialog.ShowInformation(title, "Il file non contiene entries da importare", mw.Window)
+	default:
+		mw.confirmImport(title, entries)
+	}
+}
+
+// confirmImport mostra il riepilogo dell'importazione (entries per
+// gruppo, gruppi da creare, password, TOTP, campi, allegati e doppioni) e
+// importa dopo la conferma. Il riepilogo segue il gruppo di destinazione
+// scelto; per i doppioni si sceglie se unire, ignorare o sovrascrivere
+func (mw *MainWindow) confirmImport(title string, entries []kdbx.Entry) {
+	header := widget.NewLabel("")
+	summary := widget.NewLabel("")
+
+	defaultGroup := widget.NewSelectEntry(mw.groupPaths())
+	defaultGroup.PlaceHolder = "Root"
+
+	labels := make([]string, len(kdbx.DuplicateActions))
+	for i, action := range kdbx.DuplicateActions {
+		labels[i] = action.Label()
+	}
+	duplicatesSelect := widget.NewSelect(labels, nil)
+	duplicatesSelect.SetSelectedIndex(0)
+
+	form := widget.NewForm(widget.NewFormItem("Gruppo di destinazione", defaultGroup))
+	duplicates := mw.Database.DryRunImport(entries).Duplicates
+	if duplicates > 0 {
+		form.Append(fmt.Sprintf("Doppioni (%d)", duplicates), duplicatesSelect)
+	}
+
+	update := func() {
+		s := mw.Database.DryRunImport(withDefaultGroup(entries, defaultGroup.Text))
+		text := fmt.Sprintf("%d entries in %d gruppi (%d con password, %d con TOTP, %d con campi aggiuntivi, %d allegati).\n"+
+			"%d hanno lo stesso sito e username di una entry esistente, %d con una password diversa.",
+			s.Entries, len(s.Groups), s.WithPassword, s.WithTOTP, s.WithFields, s.Attachments, s.Duplicates, s.Conflicts)
+		if s.Templates > 0 {
+			text += fmt.Sprintf("\n%d modelli per il gruppo dei modelli.", s.Templates)
+		}
+		header.SetText(text)
+
+		lines := make([]string, len(s.Groups))
+		for i, group := range s.Groups {
+			name := group.Path
+			if name == "" {
+				name = "Root"
+			}
+			if group.New {
+				name += " (nuovo)"
+			}
+			lines[i] = fmt.Sprintf("%s: %d", name, group.Entries)
+		}
+		summary.SetText(strings.Join(lines, "\n"))
+	}
+	defaultGroup.OnChanged = func(string) { update() }
+	update()
+
+	content := container.NewBorder(
+		header,
+		form,
+		nil,
+		nil,
+		container.NewVScroll(summary),
+	)
+
+	d := dialog.NewCustomConfirm(title, "Importa", "Annulla", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		action := kdbx.DuplicateActions[duplicatesSelect.SelectedIndex()]
+		mw.importEntries(title, withDefaultGroup(entries, defaultGroup.Text), action)
+	}, mw.Window)
+	d.Resize(fyne.NewSize(600, 460))
+	d.Show()
+}
+
+// withDefaultGroup ritorna una copia delle entries in cui quelle senza
+// gruppo vanno in defaultGroup
+func withDefaultGroup(entries []kdbx.Entry, defaultGroup string) []kdbx.Entry {
+	result := make([]kdbx.Entry, len(entries))
+	copy(result, entries)
+	for i := range result {
+		if result[i].GroupPath == "" {
+			result[i].GroupPath = defaultGroup
+		}
+	}
+	return result
+}
+
+// importEntries importa le entries e aggiorna la lista
+func (mw *MainWindow) importEntries(title string, entries []kdbx.Entry, duplicates kdbx.DuplicateAction) {
+	result, err := mw.Database.ImportEntries(entries, duplicates)
+	if err != nil {
+		dialog.ShowError(err, mw.Window)
+		return
+	}
+
+	mw.refreshEntries()
+	message := fmt.Sprintf("%d entries importate in %d gruppi.", result.Imported, len(result.Groups))
+	if result.Updated > 0 {
+		message += fmt.Sprintf("\n%d entries esistenti aggiornate.", result.Updated)
+	}
+	if result.Skipped > 0 {
+		message += fmt.Sprintf("\n%d doppioni ignorati.", result.Skipped)
+	}
+	if result.Conflicts > 0 {
+		message += fmt.Sprintf("\n%d doppioni avevano una password diversa: quella non usata è nella history.", result.Conflicts)
+	}
+	if result.Templates > 0 {
+		message += fmt.Sprintf("\n%d modelli aggiunti al gruppo dei modelli.", result.Templates)
+	}
+	dialog.ShowInformation(title, message+"\nSalva il database per renderle permanenti.", mw.Window)
+}
+
+// showBitwardenImport importa un export JSON di Bitwarden
+func (mw *MainWindow) showBitwardenImport() {
+	mw.showFileImport("Importa da Bitwarden", kdbx.ParseBitwardenJSON)
+}
+
+// showOnePuxImport importa un archivio .1pux di 1Password
+func (mw *MainWindow) showOnePuxImport() {
+	mw.showFileImport("Importa da 1Password", func(data []byte, _ string) ([]kdbx.Entry, error) {
+		return kdbx.ParseOnePux(data)
+	})
+}
+
+// showLastPassImport importa un export CSV di LastPass
+func (mw *MainWindow) showLastPassImport() {
+	mw.showFileImport("Importa da LastPass", kdbx.ParseLastPassCSV)
+}
+
+// showBrowserImport importa l'export CSV delle password di Chrome (e
+// derivati), Firefox o Safari, riconoscendo il formato
+func (mw *MainWindow) showBrowserImport() {
+	mw.showFileImport("Importa dal browser", kdbx.ParseBrowserCSV)
+}
+
+// showPassStoreImport importa un password-store di pass già decifrato,
+// scelto come cartella
+func (mw *MainWindow) showPassStoreImport() {
+	const title = "Importa da pass"
+	if mw.Database == nil {
+		dialog.ShowInformation(title, "Apri o crea prima un database", mw.Window)
+		return
+	}
+
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil || dir == nil {
+			return
+		}
+
+		entries, err := kdbx.ParsePassStore(os.DirFS(dir.Path()))
+		switch {
+		case err != nil:
+			dialog.ShowError(err, mw.Window)
+		case len(entries) == 0:
+			dialog.ShowInformation(title, "La cartella non contiene entries da importare", mw.Window)
+		default:
+			mw.confirmImport(title, entries)
+		}
+	}, mw.Window)
+}
+
+// showPassShowImport importa una entry di pass incollando l'output di
+// "pass show", con il suo percorso nel password-store
+func (mw *MainWindow) showPassShowImport() {
+	const title = "Importa output di pass show"
+	if mw.Database == nil {
+		dialog.ShowInformation(title, "Apri o crea prima un database", mw.Window)
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Email/lavoro")
+	contentEntry := widget.NewMultiLineEntry()
+	contentEntry.SetPlaceHolder("password\nlogin: mario\nurl: https://...")
+	contentEntry.SetMinRowsVisible(8)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Percorso", nameEntry),
+		widget.NewFormItem("Output", contentEntry),
+	)
+
+	d := dialog.NewCustomConfirm(title, "Avanti", "Annulla", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		if strings.TrimSpace(nameEntry.Text) == "" || contentEntry.Text == "" {
+			dialog.ShowError(fmt.Errorf("percorso e output sono obbligatori"), mw.Window)
+			return
+		}
+		mw.confirmImport(title, []kdbx.Entry{kdbx.ParsePassEntry(nameEntry.Text, contentEntry.Text)})
+	}, mw.Window)
+	d.Resize(fyne.NewSize(520, 380))
+	d.Show()
+}
@@ -0,0 +1,253 @@
+package kdbx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+// readTestdata legge un export di esempio da testdata
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// fieldByKey ritorna il campo personalizzato con il nome dato
+func fieldByKey(e Entry, key string) (CustomField, bool) {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return CustomField{}, false
+}
+
+// checkField verifica valore e protezione di un campo personalizzato
+func checkField(t *testing.T, e Entry, key, value string, protected bool) {
+	t.Helper()
+	f, ok := fieldByKey(e, key)
+	if !ok {
+		t.Errorf("%s: manca il campo %q in %+v", e.Title, key, e.Fields)
+		return
+	}
+	if f.Value != value || f.Protected != protected {
+		t.Errorf("%s: campo %q = %q (protetto %v), atteso %q (protetto %v)",
+			e.Title, key, f.Value, f.Protected, value, protected)
+	}
+}
+
+// attachmentNames ritorna i nomi degli allegati della entry nel database
+func attachmentNames(t *testing.T, db *Database, e Entry) []string {
+	t.Helper()
+	parent, index := findEntry(&db.Content.Root.Groups[0], e.UUID)
+	if parent == nil {
+		t.Fatalf("entry %q non trovata", e.Title)
+	}
+	var names []string
+	for _, ref := range parent.Entries[index].Binaries {
+		names = append(names, ref.Name)
+	}
+	return names
+}
+
+func TestImportEntriesDuplicateAttachments(t *testing.T) {
+	existing := Entry{
+		Title: "Posta", Username: "mario", Password: "segreta", URL: "https://mail.example.com",
+		Attachments: []Attachment{{Name: "contratto.pdf", Data: []byte("vecchio")}},
+	}
+	imported := Entry{
+		Title: "Posta", Username: "mario", Password: "nuova", URL: "https://mail.example.com/login",
+		Attachments: []Attachment{
+			{Name: "contratto.pdf", Data: []byte("nuovo")},
+			{Name: "ricevuta.pdf", Data: []byte("ricevuta")},
+		},
+	}
+
+	tests := []struct {
+		action   DuplicateAction
+		names    []string
+		password string // Password attuale; l'altra deve restare nella history
+		history  string
+	}{
+		// Gli allegati già presenti non vengono duplicati, nemmeno
+		// importando più volte lo stesso file
+		{DuplicateMerge, []string{"contratto.pdf", "ricevuta.pdf"}, "segreta", "nuova"},
+		// Gli allegati importati sostituiscono quelli esistenti
+		{DuplicateOverwrite, []string{"contratto.pdf", "ricevuta.pdf"}, "nuova", "segreta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action.Label(), func(t *testing.T) {
+			db, err := CreateNewDatabase(testSaveOptions(t, "test.kdbx", "master"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			added := db.addEntry(existing)
+
+			for i := 0; i < 2; i++ {
+				result, err := db.ImportEntries([]Entry{imported}, tt.action)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Updated != 1 || result.Imported != 0 {
+					t.Fatalf("importazione %d: %+v", i+1, result)
+				}
+				if i == 0 && result.Conflicts != 1 {
+					t.Errorf("conflitti %d, atteso 1", result.Conflicts)
+				}
+			}
+
+			if names := attachmentNames(t, db, added); !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("allegati %v, attesi %v", names, tt.names)
+			}
+
+			parent, index := findEntry(&db.Content.Root.Groups[0], added.UUID)
+			entry := &parent.Entries[index]
+			data := map[string]string{}
+			for _, ref := range entry.Binaries {
+				content, err := ref.Find(db.Database).GetContentBytes()
+				if err != nil {
+					t.Fatal(err)
+				}
+				data[ref.Name] = string(content)
+			}
+			want := "vecchio"
+			if tt.action == DuplicateOverwrite {
+				want = "nuovo"
+			}
+			if data["contratto.pdf"] != want {
+				t.Errorf("contratto.pdf = %q, atteso %q", data["contratto.pdf"], want)
+			}
+
+			// La versione originale resta nella history con il suo allegato
+			first := entry.Histories[0].Entries[0]
+			if len(first.Binaries) != 1 || first.Binaries[0].Name != "contratto.pdf" {
+				t.Errorf("history: allegati %+v", first.Binaries)
+			}
+
+			// Nessuna password va persa, nemmeno importando due volte
+			if password := entry.GetPassword(); password != tt.password {
+				t.Errorf("password %q, attesa %q", password, tt.password)
+			}
+			versions := 0
+			for _, version := range entry.Histories[0].Entries {
+				if version.GetPassword() == tt.history {
+					versions++
+				}
+			}
+			if versions == 0 {
+				t.Errorf("password %q non nella history", tt.history)
+			}
+			if tt.action == DuplicateMerge && versions != 1 {
+				t.Errorf("password importata %d volte nella history", versions)
+			}
+		})
+	}
+}
+
+func TestImportEntriesPasswordConflicts(t *testing.T) {
+	db, err := CreateNewDatabase(testSaveOptions(t, "test.kdbx", "master"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.addEntry(Entry{Title: "Banca", Username: "mario", Password: "attuale", URL: "https://banca.example.it"})
+
+	// Due righe dello stesso export per lo stesso account, più una nuova
+	entries := []Entry{
+		{Title: "Banca", Username: "mario", Password: "vecchia", URL: "https://banca.example.it/login"},
+		{Title: "Banca", Username: "Mario", Password: "vecchissima", URL: "banca.example.it"},
+		{Title: "Posta", Username: "mario", Password: "posta-1", URL: "https://mail.example.it"},
+		{Title: "Posta", Username: "mario", Password: "posta-2", URL: "https://mail.example.it"},
+	}
+
+	summary := db.DryRunImport(entries)
+	if summary.Duplicates != 3 || summary.Conflicts != 3 {
+		t.Errorf("riepilogo: doppioni %d, conflitti %d, attesi 3 e 3", summary.Duplicates, summary.Conflicts)
+	}
+
+	result, err := db.ImportEntries(entries, DuplicateMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 || result.Updated != 3 || result.Conflicts != 3 {
+		t.Errorf("risultato %+v", result)
+	}
+
+	all := db.GetAllEntries()
+	banca := entryByTitle(t, all, "Banca")
+	if banca.Password != "attuale" {
+		t.Errorf("Banca: password %q", banca.Password)
+	}
+	history := historyPasswords(t, db, banca.UUID)
+	if !history["vecchia"] || !history["vecchissima"] {
+		t.Errorf("Banca: history %v", history)
+	}
+
+	posta := entryByTitle(t, all, "Posta")
+	if posta.Password != "posta-1" || !historyPasswords(t, db, posta.UUID)["posta-2"] {
+		t.Errorf("Posta: password %q, history %v", posta.Password, historyPasswords(t, db, posta.UUID))
+	}
+}
+
+func TestImportEntriesTemplates(t *testing.T) {
+	opts := testSaveOptions(t, "test.kdbx", "master")
+	db, err := CreateNewDatabase(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []Entry{
+		{Title: "Visa", GroupPath: "Personale", Fields: []CustomField{{Key: "numero", Value: "4111"}}},
+		{Title: "Carta di credito", Template: true, Tags: []string{"Carta di credito"},
+			Fields: []CustomField{{Key: "numero"}, {Key: "codice di verifica", Protected: true}}},
+	}
+	if summary := db.DryRunImport(entries); summary.Entries != 1 || summary.Templates != 1 {
+		t.Errorf("riepilogo: entries %d, modelli %d, attesi 1 e 1", summary.Entries, summary.Templates)
+	}
+	result, err := db.ImportEntries(entries, DuplicateKeep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 || result.Templates != 1 {
+		t.Errorf("risultato %+v", result)
+	}
+
+	// Il gruppo dei modelli è registrato in Meta e sopravvive al salvataggio
+	if err := db.Save(opts); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenDatabase(opts.FilePath, "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uuid gokeepasslib.UUID
+	if err := uuid.UnmarshalText([]byte(reopened.Content.Meta.EntryTemplatesGroup)); err != nil {
+		t.Fatalf("EntryTemplatesGroup %q: %v", reopened.Content.Meta.EntryTemplatesGroup, err)
+	}
+	group := findGroupByUUID(&reopened.Content.Root.Groups[0], uuid)
+	if group == nil || group.Name != templatesGroupName || len(group.Entries) != 1 {
+		t.Fatalf("gruppo dei modelli %+v", group)
+	}
+	template := entryByTitle(t, reopened.GetAllEntries(), "Carta di credito")
+	if template.GroupPath != "Root"+PathSeparator+templatesGroupName {
+		t.Errorf("modello nel gruppo %q", template.GroupPath)
+	}
+	checkField(t, template, "numero", "", false)
+	checkField(t, template, "codice di verifica", "", true)
+
+	// Un secondo import usa lo stesso gruppo e non duplica il modello
+	result, err = reopened.ImportEntries(entries[1:], DuplicateKeep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Templates != 0 || len(reopened.Content.Root.Groups[0].Groups) != 2 {
+		t.Errorf("secondo import: %+v, gruppi %d", result, len(reopened.Content.Root.Groups[0].Groups))
+	}
+}
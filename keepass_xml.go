@@ -0,0 +1,425 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// Nell'XML di KeePass i valori protetti sono in chiaro e marcati con
+// ProtectInMemory; dentro un .kdbx lo stesso valore è cifrato e marcato
+// con Protected, che è l'attributo letto e scritto da gokeepasslib
+var (
+	xmlProtectedValue       = []byte(`<Value Protected="True">`)
+	xmlProtectInMemoryValue = []byte(`<Value ProtectInMemory="True">`)
+)
+
+// ExportXML scrive l'intero database (gruppi, entries con history,
+// allegati, metadati e CustomData) nel formato XML di KeePass 2.x.
+// ATTENZIONE: il documento contiene tutte le password in chiaro
+func (db *Database) ExportXML(out io.Writer) error {
+	if db.Content == nil || db.Content.Root == nil {
+		return fmt.Errorf("database non inizializzato correttamente")
+	}
+
+	// Copia profonda del contenuto, per cambiare formato a tempi e
+	// allegati senza toccare il database aperto
+	raw, err := xml.Marshal(db.Content)
+	if err != nil {
+		return fmt.Errorf("errore esportazione XML: %w", err)
+	}
+	var content gokeepasslib.DBContent
+	if err := xml.Unmarshal(raw, &content); err != nil {
+		return fmt.Errorf("errore esportazione XML: %w", err)
+	}
+	if content.Meta == nil {
+		content.Meta = gokeepasslib.NewMetaData()
+	}
+
+	// Nell'XML gli allegati stanno sempre nei metadati, compressi e in
+	// base64 come nei KDBX 3.1, con gli stessi ID dei riferimenti
+	content.Meta.Binaries = nil
+	content.Meta.HeaderHash = ""
+	for _, binary := range db.binaries() {
+		data, err := binary.GetContentBytes()
+		if err != nil {
+			return fmt.Errorf("errore lettura allegato %d: %w", binary.ID, err)
+		}
+		xmlBinary := gokeepasslib.Binary{ID: binary.ID}
+		gokeepasslib.WithKDBXv31Binary(&xmlBinary)
+		if err := xmlBinary.SetContent(data); err != nil {
+			return fmt.Errorf("errore esportazione allegato %d: %w", binary.ID, err)
+		}
+		content.Meta.Binaries = append(content.Meta.Binaries, xmlBinary)
+	}
+
+	// Tempi in formato ISO 8601, come nei KDBX 3.1
+	setContentTimeFormat(&content, true)
+
+	raw, err = xml.MarshalIndent(content, "", "\t")
+	if err != nil {
+		return fmt.Errorf("errore esportazione XML: %w", err)
+	}
+	raw = bytes.ReplaceAll(raw, xmlProtectedValue, xmlProtectInMemoryValue)
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return fmt.Errorf("errore scrittura XML: %w", err)
+	}
+	if _, err := out.Write(append(raw, '\n')); err != nil {
+		return fmt.Errorf("errore scrittura XML: %w", err)
+	}
+	return nil
+}
+
+// parseKeePassXML legge un documento XML esportato da KeePass 2.x (o da
+// KeePassXC, o da ExportXML)
+func parseKeePassXML(data []byte) (*gokeepasslib.DBContent, error) {
+	data = bytes.ReplaceAll(data, xmlProtectInMemoryValue, xmlProtectedValue)
+
+	var content gokeepasslib.DBContent
+	if err := xml.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("il file non è un XML di KeePass: %w", err)
+	}
+	if content.XMLName.Local != "KeePassFile" || content.Root == nil || len(content.Root.Groups) == 0 {
+		return nil, fmt.Errorf("il file non è un XML di KeePass 2.x")
+	}
+	if content.Meta == nil {
+		content.Meta = gokeepasslib.NewMetaData()
+	}
+	return &content, nil
+}
+
+// xmlBinaries decodifica gli allegati dei metadati XML, per ID
+func xmlBinaries(content *gokeepasslib.DBContent) (map[int][]byte, error) {
+	binaries := map[int][]byte{}
+	for _, binary := range content.Meta.Binaries {
+		data, err := binary.GetContentBytes()
+		if err != nil {
+			return nil, fmt.Errorf("allegato %d non valido: %w", binary.ID, err)
+		}
+		binaries[binary.ID] = data
+	}
+	return binaries, nil
+}
+
+// NewDatabaseFromXML crea un nuovo database con il contenuto di un XML
+// di KeePass 2.x, conservando UUID, tempi, history, allegati e
+// CustomData. Il database non viene salvato: va fatto con Save
+func NewDatabaseFromXML(data []byte, opts SaveOptions) (*Database, error) {
+	content, err := parseKeePassXML(data)
+	if err != nil {
+		return nil, err
+	}
+	binaries, err := xmlBinaries(content)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := CreateNewDatabase(opts)
+	if err != nil {
+		return nil, err
+	}
+	db.FilePath = opts.FilePath
+
+	kdbx4 := db.Header.IsKdbx4()
+	if kdbx4 {
+		// Nei KDBX 4 gli allegati stanno nell'header interno
+		if db.Content.InnerHeader == nil {
+			db.Content.InnerHeader = &gokeepasslib.InnerHeader{}
+		}
+		for _, binary := range content.Meta.Binaries {
+			innerBinary := gokeepasslib.Binary{ID: binary.ID}
+			gokeepasslib.WithKDBXv4Binary(&innerBinary)
+			if err := innerBinary.SetContent(binaries[binary.ID]); err != nil {
+				return nil, fmt.Errorf("errore importazione allegato %d: %w", binary.ID, err)
+			}
+			db.Content.InnerHeader.Binaries = append(db.Content.InnerHeader.Binaries, innerBinary)
+		}
+		content.Meta.Binaries = nil
+	}
+
+	db.Content.Meta = content.Meta
+	db.Content.Root = content.Root
+	setContentTimeFormat(db.Content, !kdbx4)
+
+	return db, nil
+}
+
+// ImportXML unisce al database il contenuto di un XML di KeePass 2.x. I
+// gruppi vengono uniti per nome, quelli nuovi mantengono UUID e tempi; le
+// entries mantengono UUID, tempi, history, allegati e CustomData. Una
+// entry già presente (stesso UUID) viene sostituita solo se la versione
+// nell'XML è più recente, ma le history vengono sempre unite (vedi
+// xmlMerge.entry). Le chiavi CustomData e le icone personalizzate
+// del database vengono aggiunte se mancanti
+func (db *Database) ImportXML(data []byte) (ImportResult, error) {
+	var result ImportResult
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return result, fmt.Errorf("database non inizializzato correttamente")
+	}
+
+	content, err := parseKeePassXML(data)
+	if err != nil {
+		return result, err
+	}
+	binaries, err := xmlBinaries(content)
+	if err != nil {
+		return result, err
+	}
+	if db.Content.Meta == nil {
+		db.Content.Meta = gokeepasslib.NewMetaData()
+	}
+
+	setContentTimeFormat(content, !db.Header.IsKdbx4())
+
+	root := &db.Content.Root.Groups[0]
+	merge := xmlMerge{
+		db:       db,
+		binaries: binaries,
+		result:   &result,
+		linked:   map[int]int{},
+		seen:     map[string]bool{},
+	}
+	merge.group(root, &content.Root.Groups[0], root.Name)
+
+	meta := db.Content.Meta
+	for _, item := range content.Meta.CustomData {
+		if _, ok := db.customData(item.Key); !ok {
+			meta.CustomData = append(meta.CustomData, item)
+		}
+	}
+	for _, icon := range content.Meta.CustomIcons {
+		if !hasCustomIcon(meta, icon.UUID) {
+			meta.CustomIcons = append(meta.CustomIcons, icon)
+		}
+	}
+
+	return result, nil
+}
+
+// xmlMerge è lo stato dell'unione di un XML nel database
+type xmlMerge struct {
+	db       *Database
+	binaries map[int][]byte
+	result   *ImportResult
+	linked   map[int]int // ID dell'allegato nell'XML -> ID nel database
+	seen     map[string]bool
+}
+
+// group unisce il gruppo src (dall'XML) in dst, ricorsivamente
+func (m xmlMerge) group(dst, src *gokeepasslib.Group, path string) {
+	for _, entry := range src.Entries {
+		m.entry(dst, entry, path)
+	}
+
+	for i := range src.Groups {
+		sub := &src.Groups[i]
+		target := findSubGroup(dst, sub.Name)
+		if target == nil {
+			group := *sub
+			group.Entries, group.Groups = nil, nil
+			dst.Groups = append(dst.Groups, group)
+			target = &dst.Groups[len(dst.Groups)-1]
+		}
+		m.group(target, sub, joinGroupPath(path, sub.Name))
+	}
+}
+
+// entry aggiunge una entry dell'XML al gruppo o, se il database ha già lo
+// stesso UUID, la sincronizza come KeePass: resta la versione modificata
+// più di recente, la history diventa l'unione delle due (più la versione
+// scartata, senza doppioni per data di modifica) e la entry va nel gruppo
+// dell'XML se è stata spostata lì dopo l'ultimo spostamento locale
+func (m xmlMerge) entry(dst *gokeepasslib.Group, entry gokeepasslib.Entry, path string) {
+	parent, index := findEntry(&m.db.Content.Root.Groups[0], entry.UUID)
+	if parent == nil {
+		m.relinkBinaries(&entry)
+		for i := range entry.Histories {
+			for j := range entry.Histories[i].Entries {
+				m.relinkBinaries(&entry.Histories[i].Entries[j])
+			}
+		}
+		dst.Entries = append(dst.Entries, entry)
+		m.result.Imported++
+		m.addGroup(path)
+		return
+	}
+
+	local := parent.Entries[index]
+	newer := modificationTime(&entry).After(modificationTime(&local))
+	moved := parent != dst && locationTime(&entry).After(locationTime(&local))
+
+	// Le versioni si riconoscono dalla data di modifica, salvata al secondo
+	known := map[int64]bool{modificationTime(&local).Unix(): true}
+	var versions []gokeepasslib.Entry
+	for _, h := range local.Histories {
+		for i := range h.Entries {
+			known[modificationTime(&h.Entries[i]).Unix()] = true
+			versions = append(versions, h.Entries[i])
+		}
+	}
+	added := false
+	addVersion := func(version gokeepasslib.Entry) {
+		if key := modificationTime(&version).Unix(); !known[key] {
+			known[key] = true
+			version.Histories = nil
+			m.relinkBinaries(&version)
+			versions = append(versions, version)
+			added = true
+		}
+	}
+	for _, h := range entry.Histories {
+		for _, version := range h.Entries {
+			addVersion(version)
+		}
+	}
+
+	merged := local
+	if newer {
+		m.relinkBinaries(&entry)
+		merged = entry
+		local.Histories = nil
+		versions = append(versions, local)
+		if !locationTime(&entry).After(locationTime(&local)) {
+			merged.Times.LocationChanged = local.Times.LocationChanged
+		}
+	} else {
+		addVersion(entry)
+		if moved {
+			merged.Times.LocationChanged = entry.Times.LocationChanged
+		}
+	}
+
+	if !newer && !added && !moved {
+		m.result.Skipped++
+		return
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return modificationTime(&versions[i]).Before(modificationTime(&versions[j]))
+	})
+	merged.Histories = nil
+	if len(versions) > 0 {
+		merged.Histories = []gokeepasslib.History{{Entries: versions}}
+	}
+
+	if moved {
+		parent.Entries = append(parent.Entries[:index], parent.Entries[index+1:]...)
+		dst.Entries = append(dst.Entries, merged)
+	} else {
+		parent.Entries[index] = merged
+	}
+
+	if newer {
+		m.result.Imported++
+	} else {
+		m.result.Updated++
+	}
+	m.addGroup(path)
+}
+
+// addGroup registra nel risultato un gruppo toccato dall'unione
+func (m xmlMerge) addGroup(path string) {
+	if !m.seen[path] {
+		m.seen[path] = true
+		m.result.Groups = append(m.result.Groups, path)
+	}
+}
+
+// locationTime ritorna quando la entry è stata spostata l'ultima volta
+func locationTime(entry *gokeepasslib.Entry) time.Time {
+	if entry.Times.LocationChanged == nil {
+		return time.Time{}
+	}
+	return entry.Times.LocationChanged.Time
+}
+
+// relinkBinaries copia nel database gli allegati della entry, che nell'XML
+// hanno ID riferiti ai metadati del documento. Ogni allegato viene copiato
+// una volta sola anche se condiviso (ad esempio con la history)
+func (m xmlMerge) relinkBinaries(entry *gokeepasslib.Entry) {
+	refs := entry.Binaries
+	entry.Binaries = nil
+	for _, ref := range refs {
+		if id, ok := m.linked[ref.Value.ID]; ok {
+			entry.Binaries = append(entry.Binaries, gokeepasslib.NewBinaryReference(ref.Name, id))
+			continue
+		}
+		if data, ok := m.binaries[ref.Value.ID]; ok {
+			m.db.addAttachment(entry, Attachment{Name: ref.Name, Data: data})
+			m.linked[ref.Value.ID] = entry.Binaries[len(entry.Binaries)-1].Value.ID
+		}
+	}
+}
+
+// hasCustomIcon indica se il database ha già l'icona con l'UUID dato
+func hasCustomIcon(meta *gokeepasslib.MetaData, uuid gokeepasslib.UUID) bool {
+	for _, icon := range meta.CustomIcons {
+		if icon.UUID.Compare(uuid) {
+			return true
+		}
+	}
+	return false
+}
+
+// setContentTimeFormat imposta il formato di tutti i tempi: ISO 8601
+// (KDBX 3.1 e XML) o secondi in base64 (KDBX 4)
+func setContentTimeFormat(content *gokeepasslib.DBContent, formatted bool) {
+	if meta := content.Meta; meta != nil {
+		for _, t := range []*w.TimeWrapper{
+			meta.SettingsChanged, meta.DatabaseNameChanged, meta.DatabaseDescriptionChanged,
+			meta.DefaultUserNameChanged, meta.MasterKeyChanged, meta.RecycleBinChanged,
+			meta.EntryTemplatesGroupChanged,
+		} {
+			setTimeFormat(t, formatted)
+		}
+	}
+	if content.Root != nil {
+		for i := range content.Root.Groups {
+			setGroupTimeFormat(&content.Root.Groups[i], formatted)
+		}
+		for i := range content.Root.DeletedObjects {
+			setTimeFormat(content.Root.DeletedObjects[i].DeletionTime, formatted)
+		}
+	}
+}
+
+func setGroupTimeFormat(group *gokeepasslib.Group, formatted bool) {
+	setTimeDataFormat(&group.Times, formatted)
+	for i := range group.Entries {
+		setEntryTimeFormat(&group.Entries[i], formatted)
+	}
+	for i := range group.Groups {
+		setGroupTimeFormat(&group.Groups[i], formatted)
+	}
+}
+
+func setEntryTimeFormat(entry *gokeepasslib.Entry, formatted bool) {
+	setTimeDataFormat(&entry.Times, formatted)
+	for i := range entry.Histories {
+		for j := range entry.Histories[i].Entries {
+			setEntryTimeFormat(&entry.Histories[i].Entries[j], formatted)
+		}
+	}
+}
+
+func setTimeDataFormat(times *gokeepasslib.TimeData, formatted bool) {
+	for _, t := range []*w.TimeWrapper{
+		times.CreationTime, times.LastModificationTime, times.LastAccessTime,
+		times.ExpiryTime, times.LocationChanged,
+	} {
+		setTimeFormat(t, formatted)
+	}
+}
+
+func setTimeFormat(t *w.TimeWrapper, formatted bool) {
+	if t != nil {
+		t.Formatted = formatted
+	}
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// showKeePassXMLImport unisce al database aperto un XML di KeePass 2.x,
+// conservando UUID, tempi, history e allegati
+func (mw *MainWindow) showKeePassXMLImport() {
+	const title = "Importa XML KeePass"
+	if mw.Database == nil {
+		dialog.ShowInformation(title, "Apri o crea prima un database", mw.Window)
+		return
+	}
+
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("errore lettura file: %w", err), mw.Window)
+			return
+		}
+
+		result, err := mw.Database.ImportXML(data)
+		if err != nil {
+			dialog.ShowError(err, mw.Window)
+			return
+		}
+
+		mw.refreshEntries()
+		message := fmt.Sprintf("%d entries importate in %d gruppi.", result.Imported, len(result.Groups))
+		if result.Skipped > 0 {
+			message += fmt.Sprintf("\n%d entries già presenti in una versione più recente sono state ignorate.", result.Skipped)
+		}
+		dialog.ShowInformation(title, message+"\nSalva il database per renderle permanenti.", mw.Window)
+	}, mw.Window)
+}
+
+// showKeePassXMLExport esporta l'intero database in XML di KeePass 2.x,
+// dopo aver avvisato che il file conterrà le password in chiaro
+func (mw *MainWindow) showKeePassXMLExport() {
+	const title = "Esporta XML KeePass"
+	if mw.Database == nil {
+		dialog.ShowError(fmt.Errorf("Nessun database aperto"), mw.Window)
+		return
+	}
+
+	warning := "Il file XML NON è cifrato: contiene tutte le password, i campi protetti,\n" +
+		"i seed TOTP e gli allegati in chiaro, leggibili da chiunque abbia accesso al file.\n\n" +
+		"Usalo solo per una migrazione e cancellalo subito dopo. Continuare?"
+	dialog.ShowConfirm(title, warning, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if err := mw.Database.ExportXML(writer); err != nil {
+				dialog.ShowError(err, mw.Window)
+				return
+			}
+			dialog.ShowInformation(title,
+				fmt.Sprintf("Database esportato in %s.\nRicorda che il file contiene le password in chiaro.", writer.URI().Path()),
+				mw.Window)
+		}, mw.Window)
+		name := strings.TrimSuffix(filepath.Base(mw.Database.FilePath), filepath.Ext(mw.Database.FilePath))
+		if name == "" || name == "." {
+			name = "database"
+		}
+		save.SetFileName(name + ".xml")
+		save.Show()
+	}, mw.Window)
+}
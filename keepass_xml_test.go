@@ -0,0 +1,295 @@
+package kdbx
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+// newXMLSource crea un database con un'entry completa: campo protetto,
+// tag, allegato e una versione precedente nella history
+func newXMLSource(t *testing.T, format KDBXFormat) (*Database, Entry) {
+	t.Helper()
+	opts := testSaveOptions(t, "origine.kdbx", "origine")
+	opts.Format = format
+	if format == FormatKDBX31 {
+		opts.CipherType = CipherAES256
+	}
+	db, err := CreateNewDatabase(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := db.addEntry(Entry{
+		Title: "Banca", Username: "mario", Password: "vecchia", GroupPath: "Finanza / Conti",
+		Tags:        []string{"soldi"},
+		Fields:      []CustomField{{Key: "PIN", Value: "1234", Protected: true}, {Key: "Filiale", Value: "Centro"}},
+		Attachments: []Attachment{{Name: "contratto.pdf", Data: []byte("%PDF contratto")}},
+	})
+	e.Password = "nuova"
+	e.Attachments = nil // Già aggiunto
+	if err := db.UpdateEntry(e); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tempi distinti e al secondo, come vengono salvati
+	parent, index := findEntry(&db.Content.Root.Groups[0], e.UUID)
+	entry := &parent.Entries[index]
+	setModified(&entry.Histories[0].Entries[0], time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC))
+	setModified(entry, time.Date(2024, 6, 2, 11, 30, 0, 0, time.UTC))
+	created := *entry.Times.LastModificationTime
+	created.Time = time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+	entry.Times.CreationTime = &created
+
+	return db, e
+}
+
+// rawEntry ritorna l'entry gokeepasslib con l'UUID dato
+func rawEntry(t *testing.T, db *Database, uuid gokeepasslib.UUID) *gokeepasslib.Entry {
+	t.Helper()
+	parent, index := findEntry(&db.Content.Root.Groups[0], uuid)
+	if parent == nil {
+		t.Fatal("entry non trovata")
+	}
+	return &parent.Entries[index]
+}
+
+// attachmentContent ritorna il contenuto dell'allegato con il nome dato
+func attachmentContent(t *testing.T, db *Database, entry *gokeepasslib.Entry, name string) string {
+	t.Helper()
+	for _, ref := range entry.Binaries {
+		if ref.Name == name {
+			binary := ref.Find(db.Database)
+			if binary == nil {
+				t.Fatalf("allegato %q senza contenuto", name)
+			}
+			data, err := binary.GetContentBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(data)
+		}
+	}
+	t.Fatalf("allegato %q non trovato", name)
+	return ""
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	for _, formats := range [][2]KDBXFormat{
+		{FormatKDBX4, FormatKDBX4},
+		{FormatKDBX4, FormatKDBX31},
+		{FormatKDBX31, FormatKDBX4},
+		{FormatKDBX31, FormatKDBX31},
+	} {
+		source, target := formats[0], formats[1]
+		t.Run(KDBXFormats[source]+" in "+KDBXFormats[target], func(t *testing.T) {
+			db, e := newXMLSource(t, source)
+
+			var xml bytes.Buffer
+			if err := db.ExportXML(&xml); err != nil {
+				t.Fatal(err)
+			}
+			// Nell'XML i valori protetti sono in chiaro, marcati ProtectInMemory
+			if bytes.Contains(xml.Bytes(), xmlProtectedValue) || !bytes.Contains(xml.Bytes(), xmlProtectInMemoryValue) {
+				t.Error("valori protetti non marcati con ProtectInMemory")
+			}
+			if !bytes.Contains(xml.Bytes(), []byte("<Value ProtectInMemory=\"True\">1234</Value>")) {
+				t.Error("PIN non in chiaro nell'XML")
+			}
+
+			opts := testSaveOptions(t, "migrato.kdbx", "migrato")
+			opts.Format = target
+			if target == FormatKDBX31 {
+				opts.CipherType = CipherAES256
+			}
+			imported, err := NewDatabaseFromXML(xml.Bytes(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := imported.Save(opts); err != nil {
+				t.Fatal(err)
+			}
+			reopened, err := OpenDatabase(opts.FilePath, "migrato")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reopened.Header.IsKdbx4() != (target == FormatKDBX4) {
+				t.Errorf("KDBX 4 = %v", reopened.Header.IsKdbx4())
+			}
+
+			got := entryByTitle(t, reopened.GetAllEntries(), "Banca")
+			if got.UUID != e.UUID || got.GroupPath != "Root / Finanza / Conti" ||
+				got.Username != "mario" || got.Password != "nuova" {
+				t.Errorf("entry: %+v", got)
+			}
+			if len(got.Tags) != 1 || got.Tags[0] != "soldi" {
+				t.Errorf("tag %v", got.Tags)
+			}
+			checkField(t, got, "PIN", "1234", true)
+			checkField(t, got, "Filiale", "Centro", false)
+
+			original := rawEntry(t, db, e.UUID)
+			entry := rawEntry(t, reopened, e.UUID)
+			for name, times := range map[string][2]*time.Time{
+				"creazione": {&original.Times.CreationTime.Time, &entry.Times.CreationTime.Time},
+				"modifica":  {&original.Times.LastModificationTime.Time, &entry.Times.LastModificationTime.Time},
+			} {
+				if !times[0].Equal(*times[1]) {
+					t.Errorf("tempo di %s %v, atteso %v", name, *times[1], *times[0])
+				}
+			}
+
+			if len(entry.Histories) != 1 || len(entry.Histories[0].Entries) != 1 {
+				t.Fatalf("history: %+v", entry.Histories)
+			}
+			version := &entry.Histories[0].Entries[0]
+			if version.GetPassword() != "vecchia" || !version.UUID.Compare(e.UUID) ||
+				!version.Times.LastModificationTime.Time.Equal(time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)) {
+				t.Errorf("versione nella history: password %q, modificata %v",
+					version.GetPassword(), version.Times.LastModificationTime.Time)
+			}
+			if pin := version.Get("PIN"); pin == nil || pin.Value.Content != "1234" || !pin.Value.Protected.Bool {
+				t.Errorf("PIN nella history: %+v", pin)
+			}
+
+			if content := attachmentContent(t, reopened, entry, "contratto.pdf"); content != "%PDF contratto" {
+				t.Errorf("allegato: %q", content)
+			}
+			if content := attachmentContent(t, reopened, version, "contratto.pdf"); content != "%PDF contratto" {
+				t.Errorf("allegato nella history: %q", content)
+			}
+		})
+	}
+}
+
+func TestImportXMLKeepsNewerVersion(t *testing.T) {
+	db, e := newXMLSource(t, FormatKDBX4)
+	var xml bytes.Buffer
+	if err := db.ExportXML(&xml); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := NewDatabaseFromXML(xml.Bytes(), testSaveOptions(t, "copia.kdbx", "copia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// La stessa versione viene saltata
+	result, err := target.ImportXML(xml.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Skipped != 1 || result.Imported != 0 {
+		t.Errorf("stessa versione: %+v", result)
+	}
+
+	// Una modifica più recente nel database non viene sovrascritta; la
+	// versione dell'XML è già nella history, come dopo UpdateEntry
+	copied := rawEntry(t, target, e.UUID)
+	addToHistory(copied)
+	setEntryValue(copied, "Password", "modificata nella copia", true)
+	setModified(copied, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if result, err := target.ImportXML(xml.Bytes()); err != nil || result.Skipped != 1 {
+		t.Errorf("versione più vecchia nell'XML: %+v %v", result, err)
+	}
+	if got := rawEntry(t, target, e.UUID).GetPassword(); got != "modificata nella copia" {
+		t.Errorf("password %q: la versione più recente è stata sovrascritta", got)
+	}
+
+	// Una versione più recente nell'XML sostituisce quella del database,
+	// una entry nuova viene aggiunta
+	original := rawEntry(t, db, e.UUID)
+	setEntryValue(original, "Password", "modificata nell'origine", true)
+	setModified(original, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	added := db.addEntry(Entry{Title: "Nuova", Password: "x", GroupPath: "Altro"})
+	xml.Reset()
+	if err := db.ExportXML(&xml); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = target.ImportXML(xml.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 2 || result.Skipped != 0 {
+		t.Errorf("versione più recente nell'XML: %+v", result)
+	}
+	if got := rawEntry(t, target, e.UUID).GetPassword(); got != "modificata nell'origine" {
+		t.Errorf("password %q, attesa quella dell'XML", got)
+	}
+	if got := entryByTitle(t, target.GetAllEntries(), "Nuova"); got.UUID != added.UUID {
+		t.Errorf("entry nuova con UUID %v, atteso %v", got.UUID, added.UUID)
+	}
+	if content := attachmentContent(t, target, rawEntry(t, target, e.UUID), "contratto.pdf"); content != "%PDF contratto" {
+		t.Errorf("allegato dopo la sostituzione: %q", content)
+	}
+}
+
+func TestImportXMLMergesHistoryAndLocation(t *testing.T) {
+	db, e := newXMLSource(t, FormatKDBX4)
+	var xml bytes.Buffer
+	if err := db.ExportXML(&xml); err != nil {
+		t.Fatal(err)
+	}
+	target, err := NewDatabaseFromXML(xml.Bytes(), testSaveOptions(t, "copia.kdbx", "copia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modifiche diverse alla stessa entry: nella copia la password,
+	// nell'origine dopo lo spostamento in un altro gruppo
+	copied := rawEntry(t, target, e.UUID)
+	addToHistory(copied)
+	setEntryValue(copied, "Password", "modificata nella copia", true)
+	setModified(copied, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	// Nella copia la entry è nel gruppo in cui è stata creata (i tempi
+	// dell'XML sono al secondo, lo spostamento avverrebbe nello stesso)
+	copied.Times.LocationChanged.Time = time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	if err := db.MoveEntry(e.UUID, "Archivio"); err != nil {
+		t.Fatal(err)
+	}
+	original := rawEntry(t, db, e.UUID)
+	addToHistory(original)
+	setEntryValue(original, "Password", "modificata nell'origine", true)
+	setModified(original, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	xml.Reset()
+	if err := db.ExportXML(&xml); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := target.ImportXML(xml.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 {
+		t.Errorf("risultato %+v", result)
+	}
+
+	merged := entryByTitle(t, target.GetAllEntries(), "Banca")
+	if merged.Password != "modificata nell'origine" || merged.GroupPath != "Root"+PathSeparator+"Archivio" {
+		t.Errorf("password %q nel gruppo %q", merged.Password, merged.GroupPath)
+	}
+
+	// La versione della copia resta nella history, senza doppioni
+	history := historyPasswords(t, target, e.UUID)
+	if !history["vecchia"] || !history["nuova"] || !history["modificata nella copia"] {
+		t.Errorf("history %v", history)
+	}
+	versions := rawEntry(t, target, e.UUID).Histories[0].Entries
+	if len(versions) != 3 {
+		t.Fatalf("history di %d versioni, attese 3", len(versions))
+	}
+	for i := 1; i < len(versions); i++ {
+		if modificationTime(&versions[i]).Before(modificationTime(&versions[i-1])) {
+			t.Errorf("history non in ordine cronologico")
+		}
+	}
+
+	// Reimportare lo stesso XML non cambia nulla
+	if result, err := target.ImportXML(xml.Bytes()); err != nil || result.Skipped != 1 {
+		t.Errorf("secondo import: %+v %v", result, err)
+	}
+}
@@ -0,0 +1,108 @@
+package kdbx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lastPassSecureNoteURL è l'URL fittizio delle note sicure di LastPass
+const lastPassSecureNoteURL = "http://sn"
+
+// lastPassColumns sono le colonne obbligatorie dell'export di LastPass
+var lastPassColumns = []string{"url", "username", "password", "extra", "name", "grouping"}
+
+// ParseLastPassCSV converte l'export CSV di LastPass in entries. Il campo
+// grouping ("Cartella\Sotto") diventa il gruppo; le note sicure con un
+// tipo ("NoteType:Credit Card") hanno i dati come coppie "Chiave:valore"
+// nel campo extra, che diventano campi aggiuntivi
+func ParseLastPassCSV(data []byte, _ string) ([]Entry, error) {
+	opts := DetectCSVOptions(data)
+	opts.Delimiter, opts.HasHeader = ',', true
+
+	parsed, err := ParseCSV(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range parsed.Header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range lastPassColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("il file non è un export CSV di LastPass: manca la colonna %q", name)
+		}
+	}
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return row[i]
+		}
+		return ""
+	}
+
+	entries := make([]Entry, 0, len(parsed.Rows))
+	for _, row := range parsed.Rows {
+		e := Entry{
+			Title:     strings.TrimSpace(value(row, "name")),
+			Username:  value(row, "username"),
+			Password:  value(row, "password"),
+			URL:       strings.TrimSpace(value(row, "url")),
+			GroupPath: importGroupPath(value(row, "grouping")),
+		}
+
+		extra := value(row, "extra")
+		if e.URL == lastPassSecureNoteURL {
+			e.URL = ""
+			if noteType, ok := parseLastPassNote(&e, extra); ok {
+				e.Tags = []string{noteType}
+			}
+		} else {
+			e.Notes = extra
+		}
+		if value(row, "fav") == "1" {
+			e.Tags = append(e.Tags, "Preferiti")
+		}
+
+		e.OTP = importOTP(value(row, "totp"), e)
+		e.Title = importTitle(e)
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// parseLastPassNote legge una nota sicura con tipo: ogni riga
+// "Chiave:valore" diventa un campo, tranne "Notes" che è l'ultima e
+// prosegue fino alla fine. Le note senza tipo restano testo libero
+func parseLastPassNote(e *Entry, extra string) (string, bool) {
+	if !strings.HasPrefix(extra, "NoteType:") {
+		e.Notes = extra
+		return "", false
+	}
+
+	noteType := ""
+	lines := strings.Split(strings.ReplaceAll(extra, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		key, val, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch key {
+		case "NoteType":
+			noteType = val
+		case "Language":
+		case "Notes":
+			e.Notes = strings.Join(append([]string{val}, lines[i+1:]...), "\n")
+			return noteType, true
+		case "Username":
+			e.Username = val
+		case "Password":
+			e.Password = val
+		case "Hostname", "URL":
+			e.URL = val
+		default:
+			addImportField(e, key, val, isSecretFieldKey(key))
+		}
+	}
+	return noteType, true
+}
@@ -0,0 +1,60 @@
+package kdbx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLastPassCSV(t *testing.T) {
+	entries, err := ParseLastPassCSV(readTestdata(t, "lastpass.csv"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("entries = %d, attese 4", len(entries))
+	}
+
+	amazon := entryByTitle(t, entries, "Amazon")
+	if amazon.Username != "mario@example.com" || amazon.Password != "amz-segreta" ||
+		amazon.Notes != "Account Prime" || amazon.GroupPath != "Acquisti" {
+		t.Errorf("Amazon: %+v", amazon)
+	}
+	if !reflect.DeepEqual(amazon.Tags, []string{"Preferiti"}) {
+		t.Errorf("Amazon: tag %v", amazon.Tags)
+	}
+
+	// I gruppi annidati usano la barra rovesciata
+	posta := entryByTitle(t, entries, "Posta")
+	if posta.GroupPath != "Lavoro"+PathSeparator+"Email" {
+		t.Errorf("Posta: gruppo %q", posta.GroupPath)
+	}
+	if !strings.Contains(posta.OTP, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("Posta: OTP %q", posta.OTP)
+	}
+
+	// Le note con NoteType diventano campi, il tipo un tag
+	visa := entryByTitle(t, entries, "Visa")
+	if visa.URL != "" || visa.GroupPath != "Finanza" {
+		t.Errorf("Visa: url %q gruppo %q", visa.URL, visa.GroupPath)
+	}
+	if !reflect.DeepEqual(visa.Tags, []string{"Credit Card"}) {
+		t.Errorf("Visa: tag %v", visa.Tags)
+	}
+	if visa.Notes != "Carta principale\nseconda riga" {
+		t.Errorf("Visa: note %q", visa.Notes)
+	}
+	checkField(t, visa, "Name on Card", "Mario Rossi", false)
+	checkField(t, visa, "Number", "4111111111111111", true)
+	checkField(t, visa, "Security Code", "123", true)
+	checkField(t, visa, "Expiration Date", "Dicembre,2027", false)
+	if _, ok := fieldByKey(visa, "Language"); ok {
+		t.Error("Visa: importato il campo Language")
+	}
+
+	// Una nota sicura senza NoteType resta una nota
+	note := entryByTitle(t, entries, "Armadietto palestra")
+	if note.URL != "" || note.Notes != "Codice del lucchetto: 1234" || len(note.Fields) != 0 {
+		t.Errorf("nota sicura: %+v", note)
+	}
+}
@@ -16,7 +16,7 @@ const (
 func main() {
 	fmt.Printf("%s v%s - Modern Password Manager\n", AppName, AppVersion)
 	fmt.Println("Compatible with KeePassXC .kdbx format")
-	fmt.Println("Using modern cryptography only (ChaCha20, AES-256, Argon2id)")
+	fmt.Println("Using modern cryptography only (ChaCha20, AES-256, Argon2d)")
 	fmt.Println()
 
 	// Crea applicazione
@@ -2,11 +2,15 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
 
 	"github.com/gabriel1/keepassgo/pkg/kdbx"
 )
@@ -18,9 +22,39 @@ type MainWindow struct {
 	Database *kdbx.Database
 
 	// UI Components
-	entryList    *widget.List
-	detailsPanel *fyne.Container
-	entries      []kdbx.Entry
+	entryList     *widget.List
+	detailsPanel  *fyne.Container
+	entries       []kdbx.Entry
+	selectedEntry int
+
+	// Sidebar gruppi
+	groupTree     *widget.Tree
+	groupNodes    map[string]*groupNode // Nodo visibile di ogni gruppo, per il drop
+	groups        []kdbx.Group
+	groupIndex    map[string]*kdbx.Group
+	selectedGroup string
+	recursiveView bool
+	expiringOnly  bool // Mostra solo le entries scadute o in scadenza
+	tagSelect     *widget.Select
+	selectedTag   string // Filtro per tag ("" = tutti)
+
+	// Ricerca
+	searchEntry  *shortcutEntry
+	searchStatus *widget.Label
+	searchQuery  string
+
+	// Generatore password (solo in memoria, mai salvati su disco)
+	generatorOptions  kdbx.PasswordOptions
+	passphraseOptions kdbx.PassphraseOptions
+	generatorMode     int // Ultima tab usata (generatorMode*)
+	customWordlist    *kdbx.Wordlist
+	generatorHistory  []string
+
+	// Password trovate nel dataset HIBP locale (UUID -> occorrenze)
+	breachHits map[gokeepasslib.UUID]int64
+
+	// Ferma il timer del codice TOTP mostrato nei dettagli
+	stopTOTP func()
 }
 
 // NewMainWindow crea una nuova finestra principale
@@ -28,9 +62,14 @@ func NewMainWindow(app fyne.App) *MainWindow {
 	win := app.NewWindow("KeePassGo - Modern Password Manager")
 
 	mw := &MainWindow{
-		App:     app,
-		Window:  win,
-		entries: []kdbx.Entry{},
+		App:               app,
+		Window:            win,
+		entries:           []kdbx.Entry{},
+		selectedEntry:     -1,
+		generatorOptions:  kdbx.DefaultPasswordOptions(),
+		passphraseOptions: kdbx.DefaultPassphraseOptions(),
+		groupIndex:        map[string]*kdbx.Group{},
+		groupNodes:        map[string]*groupNode{},
 	}
 
 	mw.setupUI()
@@ -45,25 +84,32 @@ func (mw *MainWindow) setupUI() {
 	// Menu bar
 	menu := mw.createMenu()
 	mw.Window.SetMainMenu(menu)
+	mw.registerQuickFindShortcut()
 
-	// Lista password (sinistra)
+	// Lista password (centro)
 	mw.entryList = mw.createEntryList()
 
+	// Tree dei gruppi (sinistra)
+	sidebar := mw.createGroupSidebar()
+
 	// Pannello dettagli (destra)
 	mw.detailsPanel = mw.createDetailsPanel()
 
 	// Layout principale: split view
-	splitView := container.NewHSplit(
+	listView := container.NewHSplit(
+		sidebar,
 		container.NewBorder(
-			widget.NewLabel("Password"),
+			container.NewVBox(widget.NewLabel("Password"), mw.createSearchBar()),
 			mw.createToolbar(),
 			nil,
 			nil,
 			mw.entryList,
 		),
-		mw.detailsPanel,
 	)
-	splitView.SetOffset(0.3)
+	listView.SetOffset(0.4)
+
+	splitView := container.NewHSplit(listView, mw.detailsPanel)
+	splitView.SetOffset(0.5)
 
 	mw.Window.SetContent(splitView)
 }
@@ -74,26 +120,52 @@ func (mw *MainWindow) createMenu() *fyne.MainMenu {
 	openItem := fyne.NewMenuItem("Apri Database", mw.openDatabase)
 	newItem := fyne.NewMenuItem("Nuovo Database", mw.newDatabase)
 	saveItem := fyne.NewMenuItem("Salva", mw.saveDatabase)
+	quickFindItem := fyne.NewMenuItem("Ricerca rapida", mw.showQuickFind)
+	quickFindItem.Shortcut = quickFindShortcut
+	importItem := fyne.NewMenuItem("Importa", nil)
+	importItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("CSV...", mw.showCSVImport),
+		fyne.NewMenuItem("Browser: Chrome, Firefox, Safari (CSV)...", mw.showBrowserImport),
+		fyne.NewMenuItem("Bitwarden (JSON)...", mw.showBitwardenImport),
+		fyne.NewMenuItem("1Password (.1pux)...", mw.showOnePuxImport),
+		fyne.NewMenuItem("LastPass (CSV)...", mw.showLastPassImport),
+		fyne.NewMenuItem("KeePass (XML)...", mw.showKeePassXMLImport),
+		fyne.NewMenuItem("pass (cartella decifrata)...", mw.showPassStoreImport),
+		fyne.NewMenuItem("pass (output di pass show)...", mw.showPassShowImport),
+	)
+	exportItem := fyne.NewMenuItem("Esporta", nil)
+	exportItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("KeePass (XML, non cifrato)...", mw.showKeePassXMLExport),
+		fyne.NewMenuItem("Nuovo database cifrato (selezione)...", mw.showSelectionExport),
+	)
 	quitItem := fyne.NewMenuItem("Esci", func() {
 		mw.App.Quit()
 	})
 
-	fileMenu := fyne.NewMenu("File", openItem, newItem, saveItem, fyne.NewMenuItemSeparator(), quitItem)
+	fileMenu := fyne.NewMenu("File", openItem, newItem, saveItem, fyne.NewMenuItemSeparator(), importItem, exportItem, fyne.NewMenuItemSeparator(), quickFindItem, fyne.NewMenuItemSeparator(), quitItem)
+
+	// Strumenti menu
+	healthItem := fyne.NewMenuItem("Report sicurezza", mw.showHealthReport)
+	breachItem := fyne.NewMenuItem("Controllo violazioni (offline)", mw.showBreachCheck)
+	duplicatesItem := fyne.NewMenuItem("Trova doppioni...", mw.showDuplicates)
+	expiryItem := fyne.NewMenuItem("Avvisi di scadenza...", mw.showExpirySettings)
+	toolsMenu := fyne.NewMenu("Strumenti", healthItem, breachItem, duplicatesItem, fyne.NewMenuItemSeparator(), expiryItem)
 
 	// Help menu
 	aboutItem := fyne.NewMenuItem("Info", mw.showAbout)
 	helpMenu := fyne.NewMenu("Aiuto", aboutItem)
 
-	return fyne.NewMainMenu(fileMenu, helpMenu)
+	return fyne.NewMainMenu(fileMenu, toolsMenu, helpMenu)
 }
 
 // createToolbar crea la toolbar con i pulsanti principali
 func (mw *MainWindow) createToolbar() *fyne.Container {
 	addBtn := widget.NewButton("Aggiungi Password", mw.addEntry)
+	editBtn := widget.NewButton("Modifica", mw.editEntry)
 	delBtn := widget.NewButton("Elimina", mw.deleteEntry)
 	genBtn := widget.NewButton("Genera Password", mw.generatePassword)
 
-	return container.NewHBox(addBtn, delBtn, genBtn)
+	return container.NewHBox(addBtn, editBtn, delBtn, genBtn)
 }
 
 // createEntryList crea la lista delle password
@@ -103,18 +175,30 @@ func (mw *MainWindow) createEntryList() *widget.List {
 			return len(mw.entries)
 		},
 		func() fyne.CanvasObject {
-			return widget.NewLabel("Template")
+			row := newEntryRow(mw)
+			row.SetText("Template")
+			return row
 		},
 		func(id widget.ListItemID, obj fyne.CanvasObject) {
-			label := obj.(*widget.Label)
+			row := obj.(*entryRow)
+			row.id = id
 			entry := mw.entries[id]
-			label.SetText(fmt.Sprintf("%s (%s)", entry.Title, entry.Username))
+			text := fmt.Sprintf("%s (%s)", entry.Title, entry.Username)
+			if _, breached := mw.breachHits[entry.UUID]; breached {
+				text = "⚠ " + text
+			}
+			row.SetText(text)
+			row.SetStruck(entry.IsExpired(time.Now()))
 		},
 	)
 
 	list.OnSelected = func(id widget.ListItemID) {
+		mw.selectedEntry = id
 		mw.showEntryDetails(id)
 	}
+	list.OnUnselected = func(id widget.ListItemID) {
+		mw.selectedEntry = -1
+	}
 
 	return list
 }
@@ -131,7 +215,7 @@ Password manager moderno con supporto .kdbx
 
 ### Caratteristiche di sicurezza:
 - ✅ Solo cifrari moderni (AES-256, ChaCha20)
-- ✅ Argon2id per key derivation
+- ✅ Argon2d per key derivation
 - ✅ Nessun supporto per cifrari obsoleti
 - ✅ Compatibile con KeePassXC
 
@@ -167,8 +251,11 @@ func (mw *MainWindow) openDatabase() {
 			}
 
 			mw.Database = db
-			mw.entries = db.GetAllEntries()
-			mw.entryList.Refresh()
+			mw.breachHits = nil
+			mw.selectedGroup = ""
+			mw.refreshEntries()
+			mw.restoreExpandedGroups()
+			mw.notifyExpiringEntries()
 
 			dialog.ShowInformation("Successo",
 				fmt.Sprintf("Database aperto: %d password trovate", len(mw.entries)),
@@ -179,38 +266,37 @@ func (mw *MainWindow) openDatabase() {
 
 // newDatabase crea un nuovo database
 func (mw *MainWindow) newDatabase() {
-	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
-		if err != nil || writer == nil {
-			return
-		}
-		defer writer.Close()
-
-		filePath := writer.URI().Path()
-
-		// Chiedi password per il nuovo database
-		mw.promptPassword("Crea password master per il database", func(password string) {
-			opts := kdbx.DefaultSaveOptions(filePath, password)
-			db, err := kdbx.CreateNewDatabase(opts)
-			if err != nil {
-				dialog.ShowError(fmt.Errorf("Errore creazione database: %w", err), mw.Window)
+	// Password e opzioni prima del file: annullando non resta un file vuoto
+	saveForm := newSaveOptionsForm()
+	mw.promptNewPassword("Nuovo database", saveForm.items(), func(password string) {
+		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
 				return
 			}
+			filePath := writer.URI().Path()
+			writer.Close()
 
-			err = db.Save(opts)
+			opts := saveForm.options(filePath, password)
+			db, err := kdbx.CreateNewDatabase(opts)
+			if err == nil {
+				err = db.Save(opts)
+			}
 			if err != nil {
-				dialog.ShowError(fmt.Errorf("Errore salvataggio database: %w", err), mw.Window)
+				os.Remove(filePath)
+				dialog.ShowError(fmt.Errorf("Errore creazione database: %w", err), mw.Window)
 				return
 			}
 
 			mw.Database = db
-			mw.entries = []kdbx.Entry{}
-			mw.entryList.Refresh()
+			mw.breachHits = nil
+			mw.selectedGroup = ""
+			mw.refreshEntries()
 
 			dialog.ShowInformation("Successo",
-				fmt.Sprintf("Nuovo database creato: %s\nCifratura: ChaCha20 + Argon2id", filePath),
+				fmt.Sprintf("Nuovo database creato: %s\nCifratura: %s", filePath, saveForm.summary()),
 				mw.Window)
-		})
-	}, mw.Window)
+		}, mw.Window)
+	})
 }
 
 // saveDatabase salva il database corrente
@@ -259,42 +345,22 @@ func (mw *MainWindow) addEntry() {
 		return
 	}
 
-	titleEntry := widget.NewEntry()
-	usernameEntry := widget.NewEntry()
-	passwordEntry := widget.NewPasswordEntry()
-	urlEntry := widget.NewEntry()
-	notesEntry := widget.NewMultiLineEntry()
+	mw.showEntryEditor(nil)
+}
 
-	dialog.ShowForm("Nuova Password", "Salva", "Annulla",
-		[]*widget.FormItem{
-			widget.NewFormItem("Titolo", titleEntry),
-			widget.NewFormItem("Username", usernameEntry),
-			widget.NewFormItem("Password", passwordEntry),
-			widget.NewFormItem("URL", urlEntry),
-			widget.NewFormItem("Note", notesEntry),
-		},
-		func(ok bool) {
-			if ok {
-				err := mw.Database.AddEntry("",
-					titleEntry.Text,
-					usernameEntry.Text,
-					passwordEntry.Text,
-					urlEntry.Text,
-					notesEntry.Text,
-				)
-				if err != nil {
-					dialog.ShowError(err, mw.Window)
-					return
-				}
-
-				// Ricarica entries
-				mw.entries = mw.Database.GetAllEntries()
-				mw.entryList.Refresh()
-				dialog.ShowInformation("Successo", "Password aggiunta", mw.Window)
-			}
-		},
-		mw.Window,
-	)
+// editEntry modifica la password selezionata
+func (mw *MainWindow) editEntry() {
+	if mw.Database == nil {
+		dialog.ShowError(fmt.Errorf("Apri o crea un database prima"), mw.Window)
+		return
+	}
+	if mw.selectedEntry < 0 || mw.selectedEntry >= len(mw.entries) {
+		dialog.ShowError(fmt.Errorf("Seleziona una password da modificare"), mw.Window)
+		return
+	}
+
+	entry := mw.entries[mw.selectedEntry]
+	mw.showEntryEditor(&entry)
 }
 
 // deleteEntry elimina la password selezionata
@@ -304,33 +370,7 @@ func (mw *MainWindow) deleteEntry() {
 
 // generatePassword genera una password casuale
 func (mw *MainWindow) generatePassword() {
-	opts := kdbx.DefaultPasswordOptions()
-	password, err := kdbx.GeneratePassword(opts)
-	if err != nil {
-		dialog.ShowError(err, mw.Window)
-		return
-	}
-
-	passwordLabel := widget.NewEntry()
-	passwordLabel.SetText(password)
-	passwordLabel.Disable()
-
-	entropy := kdbx.CalculateEntropy(password, opts)
-
-	dialog.ShowCustom("Password Generata",
-		"OK",
-		container.NewVBox(
-			widget.NewLabel(fmt.Sprintf("Lunghezza: %d caratteri", opts.Length)),
-			widget.NewLabel(fmt.Sprintf("Entropia: ~%.0f bit", entropy)),
-			widget.NewSeparator(),
-			passwordLabel,
-			widget.NewButton("Copia", func() {
-				mw.Window.Clipboard().SetContent(password)
-				dialog.ShowInformation("Copiato", "Password copiata negli appunti", mw.Window)
-			}),
-		),
-		mw.Window,
-	)
+	mw.showGenerator(nil, mw.selectedGroup)
 }
 
 // showEntryDetails mostra i dettagli di una password
@@ -340,6 +380,7 @@ func (mw *MainWindow) showEntryDetails(id int) {
 	}
 
 	entry := mw.entries[id]
+	mw.stopTOTPTimer()
 
 	titleLabel := widget.NewLabelWithStyle(entry.Title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 
@@ -359,29 +400,85 @@ func (mw *MainWindow) showEntryDetails(id int) {
 	notesEntry.SetText(entry.Notes)
 	notesEntry.Disable()
 
+	expiryLabel := widget.NewLabel(expiryText(entry, time.Now()))
+
+	tagsLabel := widget.NewLabel(strings.Join(entry.Tags, ", "))
+	tagsLabel.Wrapping = fyne.TextWrapWord
+
 	copyPasswordBtn := widget.NewButton("Copia Password", func() {
 		mw.Window.Clipboard().SetContent(entry.Password)
+		mw.recordUsage(entry)
 		dialog.ShowInformation("Copiato", "Password copiata negli appunti", mw.Window)
 	})
 
 	copyUsernameBtn := widget.NewButton("Copia Username", func() {
 		mw.Window.Clipboard().SetContent(entry.Username)
+		mw.recordUsage(entry)
+	})
+
+	editBtn := widget.NewButton("Modifica", func() {
+		mw.showEntryEditor(&entry)
 	})
 
 	mw.detailsPanel.Objects = []fyne.CanvasObject{
 		titleLabel,
-		widget.NewForm(
-			widget.NewFormItem("Username", usernameEntry),
-			widget.NewFormItem("Password", passwordEntry),
-			widget.NewFormItem("URL", urlEntry),
-			widget.NewFormItem("Note", notesEntry),
-		),
-		container.NewHBox(copyPasswordBtn, copyUsernameBtn),
 	}
+	if count, breached := mw.breachHits[entry.UUID]; breached {
+		warning := widget.NewLabel("⚠ Password compromessa: " + breachCountText(count) + ". Cambiala.")
+		warning.Wrapping = fyne.TextWrapWord
+		mw.detailsPanel.Objects = append(mw.detailsPanel.Objects, warning)
+	}
+	form := widget.NewForm(
+		widget.NewFormItem("Username", usernameEntry),
+		widget.NewFormItem("Password", passwordEntry),
+	)
+	if totp, err := entry.TOTP(); err != nil {
+		form.Append("TOTP", widget.NewLabel(err.Error()))
+	} else if totp != nil {
+		form.Append("TOTP", mw.newTOTPView(entry, totp))
+	}
+	form.Append("URL", urlEntry)
+	for _, field := range entry.Fields {
+		form.Append(field.Key, customFieldView(mw, entry, field))
+	}
+	form.Append("Tag", tagsLabel)
+	form.Append("Scadenza", expiryLabel)
+	form.Append("Note", notesEntry)
+	if len(entry.Attachments) > 0 {
+		names := make([]string, len(entry.Attachments))
+		for i, attachment := range entry.Attachments {
+			names[i] = attachment.Name
+		}
+		form.Append("Allegati", widget.NewLabel(strings.Join(names, "\n")))
+	}
+
+	mw.detailsPanel.Objects = append(mw.detailsPanel.Objects,
+		form,
+		container.NewHBox(copyPasswordBtn, copyUsernameBtn, editBtn),
+	)
 
 	mw.detailsPanel.Refresh()
 }
 
+// customFieldView mostra un campo aggiuntivo nei dettagli; i campi
+// protetti restano nascosti e si possono copiare
+func customFieldView(mw *MainWindow, entry kdbx.Entry, field kdbx.CustomField) fyne.CanvasObject {
+	if !field.Protected {
+		label := widget.NewLabel(field.Value)
+		label.Wrapping = fyne.TextWrapWord
+		return label
+	}
+
+	value := widget.NewPasswordEntry()
+	value.SetText(field.Value)
+	value.Disable()
+	copyBtn := widget.NewButton("Copia", func() {
+		mw.Window.Clipboard().SetContent(field.Value)
+		mw.recordUsage(entry)
+	})
+	return container.NewBorder(nil, nil, nil, copyBtn, value)
+}
+
 // showAbout mostra la finestra "Informazioni"
 func (mw *MainWindow) showAbout() {
 	dialog.ShowCustom("Informazioni su KeePassGo", "OK",
@@ -392,7 +489,7 @@ Password manager moderno scritto in Go
 
 ### Sicurezza
 - Cifrari: AES-256, ChaCha20
-- KDF: Argon2id
+- KDF: Argon2d
 - Compatibile con KeePassXC
 
 ### Licenza
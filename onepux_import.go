@@ -0,0 +1,361 @@
+package kdbx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// onePuxDataFile è il file JSON con i dati dentro l'archivio .1pux
+const onePuxDataFile = "export.data"
+
+// onePuxCategories associa le categorie di 1Password al nome usato per il
+// tag delle entries e per il modello della categoria
+var onePuxCategories = map[string]string{
+	"001": "",
+	"002": "Carta di credito",
+	"003": "Nota sicura",
+	"004": "Identità",
+	"005": "Password",
+	"006": "Documento",
+	"100": "Licenza software",
+	"101": "Conto bancario",
+	"102": "Database",
+	"103": "Patente",
+	"104": "Licenza",
+	"105": "Abbonamento",
+	"106": "Passaporto",
+	"107": "Programma fedeltà",
+	"108": "Previdenza sociale",
+	"109": "Router wireless",
+	"110": "Server",
+	"111": "Account email",
+	"112": "Credenziali API",
+	"113": "Cartella medica",
+	"114": "Chiave SSH",
+	"115": "Wallet crypto",
+}
+
+// onePuxUsernameIDs e onePuxPasswordIDs sono gli id dei campi di sezione
+// che fanno da username e password nelle categorie senza login
+var (
+	onePuxUsernameIDs = map[string]bool{"username": true, "pop_username": true, "smtp_username": true}
+	onePuxPasswordIDs = map[string]bool{"password": true, "wireless_password": true, "pop_password": true, "credential": true}
+)
+
+type onePuxExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePuxItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePuxItem struct {
+	State        string `json:"state"`
+	CategoryUUID string `json:"categoryUuid"`
+	Details      struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Name        string `json:"name"`
+			Designation string `json:"designation"`
+			FieldType   string `json:"fieldType"`
+		} `json:"loginFields"`
+		NotesPlain         string            `json:"notesPlain"`
+		Password           string            `json:"password"`
+		Sections           []onePuxSection   `json:"sections"`
+		DocumentAttributes *onePuxFileRecord `json:"documentAttributes"`
+	} `json:"details"`
+	Overview struct {
+		Title string   `json:"title"`
+		URL   string   `json:"url"`
+		Tags  []string `json:"tags"`
+		URLs  []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+	} `json:"overview"`
+}
+
+type onePuxSection struct {
+	Title  string `json:"title"`
+	Fields []struct {
+		Title string                     `json:"title"`
+		ID    string                     `json:"id"`
+		Value map[string]json.RawMessage `json:"value"`
+	} `json:"fields"`
+}
+
+type onePuxFileRecord struct {
+	FileName   string `json:"fileName"`
+	DocumentID string `json:"documentId"`
+}
+
+// ParseOnePux converte un archivio .1pux di 1Password in entries: ogni
+// vault diventa un gruppo (gli elementi archiviati vanno in un sottogruppo
+// "Archivio"), la categoria diventa un tag, i campi delle sezioni
+// diventano campi aggiuntivi e gli allegati vengono letti dall'archivio.
+// Per ogni categoria diversa dai login aggiunge in coda un modello
+// (Template) con i campi aggiuntivi delle sue entries
+func ParseOnePux(data []byte) ([]Entry, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("il file non è un archivio .1pux: %w", err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	dataFile, ok := files[onePuxDataFile]
+	if !ok {
+		return nil, fmt.Errorf("archivio .1pux senza %s", onePuxDataFile)
+	}
+
+	raw, err := readZipFile(dataFile)
+	if err != nil {
+		return nil, err
+	}
+	var export onePuxExport
+	if err := json.Unmarshal(raw, &export); err != nil {
+		return nil, fmt.Errorf("contenuto .1pux non valido: %w", err)
+	}
+
+	var entries, templates []Entry
+	categories := map[string]int{}
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				groupPath := importGroupPath(vault.Attrs.Name)
+				switch item.State {
+				case "deleted":
+					continue
+				case "archived":
+					groupPath = joinGroupPath(groupPath, "Archivio")
+				}
+
+				e, err := item.entry(groupPath, archive)
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, e)
+
+				if category := onePuxCategories[item.CategoryUUID]; category != "" {
+					index, ok := categories[category]
+					if !ok {
+						index = len(templates)
+						categories[category] = index
+						templates = append(templates, Entry{
+							Title:    category,
+							Tags:     []string{category},
+							Template: true,
+						})
+					}
+					addTemplateFields(&templates[index], e.Fields)
+				}
+			}
+		}
+	}
+
+	return append(entries, templates...), nil
+}
+
+// addTemplateFields aggiunge al modello, vuoti, i campi aggiuntivi di una
+// sua entry che non ha ancora. Gli URL extra sono propri della entry
+func addTemplateFields(template *Entry, fields []CustomField) {
+	for _, field := range fields {
+		if strings.HasPrefix(field.Key, "KP2A_URL_") {
+			continue
+		}
+		found := false
+		for _, existing := range template.Fields {
+			if existing.Key == field.Key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			template.Fields = append(template.Fields, CustomField{Key: field.Key, Protected: field.Protected})
+		}
+	}
+}
+
+// entry converte un elemento di 1Password
+func (item onePuxItem) entry(groupPath string, archive *zip.Reader) (Entry, error) {
+	details := item.Details
+	e := Entry{
+		Title:     item.Overview.Title,
+		URL:       item.Overview.URL,
+		Notes:     details.NotesPlain,
+		GroupPath: groupPath,
+		Password:  details.Password,
+	}
+
+	for _, field := range details.LoginFields {
+		switch {
+		case field.Designation == "username":
+			e.Username = field.Value
+		case field.Designation == "password":
+			e.Password = field.Value
+		case field.Value != "" && field.FieldType != "C" && field.FieldType != "B":
+			// Altri campi del modulo di login (checkbox e pulsanti esclusi)
+			addImportField(&e, field.Name, field.Value, field.FieldType == "P")
+		}
+	}
+
+	extraURLs := 0
+	for _, u := range item.Overview.URLs {
+		if e.URL == "" || u.URL == e.URL {
+			e.URL = u.URL
+			continue
+		}
+		extraURLs++
+		addImportField(&e, fmt.Sprintf("KP2A_URL_%d", extraURLs), u.URL, false)
+	}
+
+	var files []onePuxFileRecord
+	if details.DocumentAttributes != nil {
+		files = append(files, *details.DocumentAttributes)
+	}
+
+	for _, section := range details.Sections {
+		for _, field := range section.Fields {
+			value, kind := onePuxFieldValue(field.Value)
+			switch {
+			case kind == "file":
+				var file onePuxFileRecord
+				if json.Unmarshal(field.Value["file"], &file) == nil {
+					files = append(files, file)
+				}
+			case value == "":
+			case kind == "totp" && e.OTP == "":
+				e.OTP = importOTP(value, e)
+			case onePuxUsernameIDs[field.ID] && e.Username == "":
+				e.Username = value
+			case onePuxPasswordIDs[field.ID] && e.Password == "":
+				e.Password = value
+			default:
+				name := field.Title
+				if section.Title != "" {
+					name = section.Title + " - " + name
+				}
+				addImportField(&e, name, value, kind == "concealed" || kind == "totp")
+			}
+		}
+	}
+
+	for _, file := range files {
+		attachment, err := readOnePuxFile(archive, file)
+		if err != nil {
+			return Entry{}, err
+		}
+		if attachment != nil {
+			e.Attachments = append(e.Attachments, *attachment)
+		}
+	}
+
+	e.Tags = ParseTags(strings.Join(item.Overview.Tags, ";"))
+	if category := onePuxCategories[item.CategoryUUID]; category != "" {
+		e.Tags = ParseTags(JoinTags(e.Tags) + ";" + category)
+	}
+
+	e.Title = importTitle(e)
+	return e, nil
+}
+
+// onePuxFieldValue estrae il valore di un campo di sezione come testo,
+// con il tipo del valore (concealed, string, totp, date, address...)
+func onePuxFieldValue(value map[string]json.RawMessage) (string, string) {
+	for kind, raw := range value {
+		switch kind {
+		case "date":
+			var seconds int64
+			if json.Unmarshal(raw, &seconds) == nil && seconds != 0 {
+				return time.Unix(seconds, 0).UTC().Format("2006-01-02"), kind
+			}
+			return "", kind
+		case "monthYear":
+			var monthYear int
+			if json.Unmarshal(raw, &monthYear) == nil && monthYear != 0 {
+				return fmt.Sprintf("%02d/%d", monthYear%100, monthYear/100), kind
+			}
+			return "", kind
+		case "address":
+			var address map[string]string
+			json.Unmarshal(raw, &address)
+			var parts []string
+			for _, key := range []string{"street", "city", "state", "zip", "country"} {
+				if address[key] != "" {
+					parts = append(parts, address[key])
+				}
+			}
+			return strings.Join(parts, ", "), kind
+		case "email":
+			var email struct {
+				Address string `json:"email_address"`
+			}
+			json.Unmarshal(raw, &email)
+			return email.Address, kind
+		case "sshKey":
+			var key struct {
+				PrivateKey string `json:"privateKey"`
+			}
+			json.Unmarshal(raw, &key)
+			return key.PrivateKey, "concealed"
+		case "file":
+			return "", kind
+		}
+
+		// Gli altri tipi (string, concealed, totp, url, phone, menu,
+		// creditCardNumber...) sono stringhe o numeri
+		var text any
+		if json.Unmarshal(raw, &text) == nil {
+			return stringValue(text), kind
+		}
+	}
+	return "", ""
+}
+
+// readOnePuxFile legge un allegato dall'archivio, salvato in files/ con
+// il documentId come prefisso del nome
+func readOnePuxFile(archive *zip.Reader, record onePuxFileRecord) (*Attachment, error) {
+	if record.DocumentID == "" {
+		return nil, nil
+	}
+	for _, file := range archive.File {
+		if !strings.HasPrefix(file.Name, "files/"+record.DocumentID) {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		name := record.FileName
+		if name == "" {
+			name = strings.TrimPrefix(file.Name, "files/")
+		}
+		return &Attachment{Name: name, Data: data}, nil
+	}
+	return nil, nil
+}
+
+// readZipFile legge un file dell'archivio
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("errore lettura %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("errore lettura %s: %w", file.Name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,98 @@
+package kdbx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOnePux(t *testing.T) {
+	entries, err := ParseOnePux(readTestdata(t, "1password.1pux"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// L'item eliminato viene ignorato, quello archiviato no; in coda i
+	// modelli delle categorie diverse dai login
+	if len(entries) != 8 {
+		t.Fatalf("entries = %d, attese 5 più 3 modelli", len(entries))
+	}
+	var templates []string
+	for _, e := range entries[5:] {
+		if !e.Template {
+			t.Errorf("%s: non è un modello", e.Title)
+		}
+		templates = append(templates, e.Title)
+	}
+	if !reflect.DeepEqual(templates, []string{"Carta di credito", "Documento", "Server"}) {
+		t.Errorf("modelli %v", templates)
+	}
+	for _, e := range entries {
+		if e.Title == "Eliminato" {
+			t.Error("importato l'item eliminato")
+		}
+	}
+
+	github := entryByTitle(t, entries, "GitHub")
+	if github.Username != "mario" || github.Password != "gh-segreta" ||
+		github.URL != "https://github.com/login" || github.Notes != "Account principale" {
+		t.Errorf("GitHub: %+v", github)
+	}
+	if github.GroupPath != "Personale" || !reflect.DeepEqual(github.Tags, []string{"dev"}) {
+		t.Errorf("GitHub: gruppo %q tag %v", github.GroupPath, github.Tags)
+	}
+	if !strings.HasPrefix(github.OTP, "otpauth://totp/") || !strings.Contains(github.OTP, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("GitHub: OTP %q", github.OTP)
+	}
+	checkField(t, github, "KP2A_URL_1", "https://gist.github.com", false)
+	checkField(t, github, "Sicurezza - Codici di recupero", "abcd-efgh", true)
+	if len(github.Fields) != 2 {
+		t.Errorf("GitHub: campi %+v, attesi solo URL extra e codici di recupero", github.Fields)
+	}
+
+	// Le categorie diventano tag
+	visa := entryByTitle(t, entries, "Visa")
+	if !reflect.DeepEqual(visa.Tags, []string{"Carta di credito"}) {
+		t.Errorf("Visa: tag %v", visa.Tags)
+	}
+	checkField(t, visa, "titolare", "Mario Rossi", false)
+	checkField(t, visa, "numero", "4111111111111111", false)
+	checkField(t, visa, "codice di verifica", "123", true)
+	checkField(t, visa, "data di scadenza", "12/2027", false)
+
+	document := entryByTitle(t, entries, "Scansione passaporto")
+	if !reflect.DeepEqual(document.Tags, []string{"Documento"}) {
+		t.Errorf("documento: tag %v", document.Tags)
+	}
+	if len(document.Attachments) != 1 || document.Attachments[0].Name != "passaporto.pdf" ||
+		string(document.Attachments[0].Data) != "%PDF-1.4 passaporto\n" {
+		t.Errorf("documento: allegati %+v", document.Attachments)
+	}
+
+	if forum := entryByTitle(t, entries, "Forum"); forum.GroupPath != "Personale"+PathSeparator+"Archivio" {
+		t.Errorf("Forum archiviato nel gruppo %q", forum.GroupPath)
+	}
+
+	server := entryByTitle(t, entries, "Server di produzione")
+	if server.GroupPath != "Lavoro" || server.Username != "admin" || server.Password != "srv-segreta" {
+		t.Errorf("server: %+v", server)
+	}
+	if !reflect.DeepEqual(server.Tags, []string{"Server"}) {
+		t.Errorf("server: tag %v", server.Tags)
+	}
+	checkField(t, server, "Accesso - URL", "ssh://srv.example.com", false)
+
+	// I modelli hanno i campi aggiuntivi della categoria, vuoti
+	card := entryByTitle(t, entries[5:], "Carta di credito")
+	if !reflect.DeepEqual(card.Tags, []string{"Carta di credito"}) || len(card.Fields) != 4 {
+		t.Errorf("modello carta: %+v", card)
+	}
+	checkField(t, card, "numero", "", false)
+	checkField(t, card, "codice di verifica", "", true)
+	checkField(t, entryByTitle(t, entries[5:], "Server"), "Accesso - URL", "", false)
+}
+
+func TestParseOnePuxInvalid(t *testing.T) {
+	if _, err := ParseOnePux([]byte("non è uno zip")); err == nil {
+		t.Error("file non zip: nessun errore")
+	}
+}
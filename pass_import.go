@@ -0,0 +1,131 @@
+package kdbx
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// passEncryptedExt è l'estensione dei file cifrati con GPG di pass
+const passEncryptedExt = ".gpg"
+
+// passFieldAliases associa le chiavi usate per convenzione nei file di
+// pass ai campi standard della entry
+var passFieldAliases = map[string]string{
+	"login":    "username",
+	"user":     "username",
+	"username": "username",
+	"email":    "username",
+	"url":      "url",
+	"website":  "url",
+	"site":     "url",
+}
+
+// ParsePassStore converte un password-store di pass già decifrato in
+// entries: le directory diventano gruppi e ogni file una entry, con il
+// nome del file come titolo. I file ancora cifrati (.gpg) vengono
+// ignorati; se il tree contiene solo quelli viene ritornato un errore
+func ParsePassStore(store fs.FS) ([]Entry, error) {
+	var entries []Entry
+	encrypted := 0
+
+	err := fs.WalkDir(store, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// .git, .gpg-id, .extensions e simili non sono entries
+		if name != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(name, passEncryptedExt) {
+			encrypted++
+			return nil
+		}
+
+		data, err := fs.ReadFile(store, name)
+		if err != nil {
+			return fmt.Errorf("errore lettura %s: %w", name, err)
+		}
+		entries = append(entries, ParsePassEntry(strings.TrimSuffix(name, ".txt"), string(data)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 && encrypted > 0 {
+		return nil, fmt.Errorf("il password-store contiene solo file cifrati (%d): "+
+			"decifrali prima con gpg o pass show", encrypted)
+	}
+	return entries, nil
+}
+
+// ParsePassEntry converte il contenuto di una entry di pass (un file
+// decifrato o l'output di "pass show") nel percorso indicato
+// ("Email/lavoro"). La prima riga è la password; le righe "chiave: valore"
+// diventano campi (login, url e simili vanno nei campi standard), una riga
+// otpauth:// diventa il TOTP e il resto finisce nelle note
+func ParsePassEntry(name, content string) Entry {
+	name = strings.Trim(path.Clean(strings.ReplaceAll(name, "\\", "/")), "/")
+	dir, title := path.Split(name)
+	e := Entry{
+		Title:     title,
+		GroupPath: importGroupPath(dir),
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	e.Password = lines[0]
+
+	var notes []string
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(strings.ToLower(trimmed), "otpauth://") && e.OTP == "" {
+			e.OTP = importOTP(trimmed, e)
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if found && strings.HasPrefix(value, "//") && e.URL == "" {
+			// URL senza chiave ("https://...")
+			e.URL = trimmed
+			continue
+		}
+		if !found || key == "" || strings.Count(key, " ") > 2 || strings.HasPrefix(value, "//") {
+			// Testo libero, comprese le frasi con i due punti
+			notes = append(notes, line)
+			continue
+		}
+
+		switch passFieldAliases[strings.ToLower(key)] {
+		case "username":
+			if e.Username == "" {
+				e.Username = value
+				continue
+			}
+		case "url":
+			if e.URL == "" {
+				e.URL = value
+				continue
+			}
+		}
+		if strings.EqualFold(key, "otp") || strings.EqualFold(key, "totp") {
+			if otp := importOTP(value, e); otp != "" && e.OTP == "" {
+				e.OTP = otp
+				continue
+			}
+		}
+		addImportField(&e, key, value, isSecretFieldKey(key))
+	}
+
+	e.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+	e.Title = importTitle(e)
+	return e
+}
@@ -0,0 +1,48 @@
+package kdbx
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParsePassStore(t *testing.T) {
+	// Il file .gpg e il .gpg-id vengono ignorati
+	entries, err := ParsePassStore(os.DirFS("testdata/pass-store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, attese 2", len(entries))
+	}
+
+	lavoro := entryByTitle(t, entries, "lavoro")
+	if lavoro.GroupPath != "Email" || lavoro.Password != "posta-segreta" ||
+		lavoro.Username != "mario" || lavoro.URL != "https://mail.example.com" {
+		t.Errorf("lavoro: %+v", lavoro)
+	}
+	if !strings.Contains(lavoro.OTP, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("lavoro: OTP %q", lavoro.OTP)
+	}
+	checkField(t, lavoro, "PIN", "4321", true)
+	if lavoro.Notes != "Da cambiare una volta all anno: promemoria" {
+		t.Errorf("lavoro: note %q", lavoro.Notes)
+	}
+
+	// Un URL senza chiave finisce comunque nel campo URL
+	forum := entryByTitle(t, entries, "forum")
+	if forum.GroupPath != "Web" || forum.URL != "https://forum.example.com" || forum.Username != "vecchio" {
+		t.Errorf("forum: %+v", forum)
+	}
+}
+
+func TestParsePassStoreOnlyEncrypted(t *testing.T) {
+	store := fstest.MapFS{
+		".gpg-id":         {Data: []byte("ABCDEF12\n")},
+		"Email/posta.gpg": {Data: []byte("\x85\x02cifrato")},
+	}
+	if _, err := ParsePassStore(store); err == nil {
+		t.Error("solo file cifrati: nessun errore")
+	}
+}
@@ -0,0 +1,244 @@
+package kdbx
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed wordlists/eff_large_wordlist.txt
+var effLargeWordlistData string
+
+//go:embed wordlists/eff_short_wordlist_2_0.txt
+var effShortWordlistData string
+
+// minWordlistSize è il numero minimo di parole distinte di una wordlist
+const minWordlistSize = 2
+
+// Wordlist è una lista di parole per generare passphrase
+type Wordlist struct {
+	Name  string
+	Words []string
+}
+
+var (
+	effLargeOnce     sync.Once
+	effLargeWordlist *Wordlist
+	effShortOnce     sync.Once
+	effShortWordlist *Wordlist
+)
+
+// EFFLargeWordlist ritorna la wordlist lunga EFF (7776 parole, 5 dadi)
+func EFFLargeWordlist() *Wordlist {
+	effLargeOnce.Do(func() {
+		effLargeWordlist = &Wordlist{Name: "EFF lunga", Words: parseWordlist(effLargeWordlistData)}
+	})
+	return effLargeWordlist
+}
+
+// EFFShortWordlist ritorna la wordlist corta EFF 2.0 (1296 parole, 4 dadi)
+func EFFShortWordlist() *Wordlist {
+	effShortOnce.Do(func() {
+		effShortWordlist = &Wordlist{Name: "EFF corta", Words: parseWordlist(effShortWordlistData)}
+	})
+	return effShortWordlist
+}
+
+// LoadWordlist carica una wordlist personalizzata da file. Accetta sia il
+// formato diceware ("11111<tab>parola") sia una parola per riga
+func LoadWordlist(path string) (*Wordlist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("errore apertura wordlist: %w", err)
+	}
+	defer file.Close()
+
+	return ReadWordlist(filepath.Base(path), file)
+}
+
+// ReadWordlist legge una wordlist da un reader
+func ReadWordlist(name string, r io.Reader) (*Wordlist, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("errore lettura wordlist: %w", err)
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("la wordlist deve essere in UTF-8")
+	}
+
+	words := parseWordlist(string(data))
+	if len(words) < minWordlistSize {
+		return nil, fmt.Errorf("la wordlist deve contenere almeno %d parole distinte", minWordlistSize)
+	}
+
+	return &Wordlist{Name: name, Words: words}, nil
+}
+
+// parseWordlist estrae le parole distinte in minuscolo, ignorando righe
+// vuote, commenti (#) e gli indici dei dadi del formato diceware. Le maiuscole
+// le decide CapitalizationMode: "Roma" e "roma" sono la stessa parola, e
+// contarle due volte gonfierebbe l'entropia
+func parseWordlist(data string) []string {
+	var words []string
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		word := strings.ToLower(fields[len(fields)-1])
+		if len(fields) == 1 && isAllDigits(word) {
+			continue
+		}
+
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
+
+// CapitalizationMode indica come mettere le maiuscole nelle parole
+type CapitalizationMode int
+
+const (
+	CapitalizeNone   CapitalizationMode = iota // tutto minuscolo
+	CapitalizeFirst                            // Iniziale maiuscola per ogni parola
+	CapitalizeAll                              // TUTTO MAIUSCOLO
+	CapitalizeRandom                           // Iniziale maiuscola su parole casuali (+1 bit per parola)
+)
+
+// PassphraseOptions opzioni per generare passphrase diceware
+type PassphraseOptions struct {
+	WordCount      int
+	Separator      string
+	Capitalization CapitalizationMode
+	DigitCount     int       // Cifre casuali aggiunte in coda a parole casuali distinte
+	Wordlist       *Wordlist // nil = wordlist lunga EFF
+}
+
+// DefaultPassphraseOptions ritorna opzioni sicure di default (~77 bit)
+func DefaultPassphraseOptions() PassphraseOptions {
+	return PassphraseOptions{
+		WordCount:      6,
+		Separator:      "-",
+		Capitalization: CapitalizeNone,
+		DigitCount:     0,
+	}
+}
+
+// WordlistOrDefault ritorna la wordlist scelta o quella di default
+func (opts PassphraseOptions) WordlistOrDefault() *Wordlist {
+	if opts.Wordlist != nil {
+		return opts.Wordlist
+	}
+	return EFFLargeWordlist()
+}
+
+// GeneratePassphrase genera una passphrase diceware usando crypto/rand
+func GeneratePassphrase(opts PassphraseOptions) (string, error) {
+	if opts.WordCount < 1 {
+		return "", fmt.Errorf("la passphrase deve contenere almeno una parola")
+	}
+	if opts.DigitCount < 0 || opts.DigitCount > opts.WordCount {
+		return "", fmt.Errorf("il numero di cifre deve essere tra 0 e %d", opts.WordCount)
+	}
+
+	list := opts.WordlistOrDefault()
+	if len(list.Words) < minWordlistSize {
+		return "", fmt.Errorf("wordlist troppo corta")
+	}
+
+	// Una sola sorgente per tutta la passphrase: crypto/rand viene letto
+	// a blocchi invece che per ogni parola, cifra o maiuscola
+	var src randomSource
+	words := make([]string, opts.WordCount)
+	for i := range words {
+		index, err := src.intn(len(list.Words))
+		if err != nil {
+			return "", err
+		}
+		words[i] = list.Words[index]
+
+		switch opts.Capitalization {
+		case CapitalizeFirst:
+			words[i] = capitalizeFirst(words[i])
+		case CapitalizeAll:
+			words[i] = strings.ToUpper(words[i])
+		case CapitalizeRandom:
+			coin, err := src.intn(2)
+			if err != nil {
+				return "", err
+			}
+			if coin == 1 {
+				words[i] = capitalizeFirst(words[i])
+			}
+		}
+	}
+
+	// Sceglie DigitCount parole distinte (Fisher-Yates parziale)
+	positions := make([]int, opts.WordCount)
+	for i := range positions {
+		positions[i] = i
+	}
+	for i := 0; i < opts.DigitCount; i++ {
+		j, err := src.intn(opts.WordCount - i)
+		if err != nil {
+			return "", err
+		}
+		positions[i], positions[i+j] = positions[i+j], positions[i]
+
+		digit, err := src.intn(len(Digits))
+		if err != nil {
+			return "", err
+		}
+		words[positions[i]] += string(Digits[digit])
+	}
+
+	return strings.Join(words, opts.Separator), nil
+}
+
+// PassphraseEntropy calcola l'entropia esatta in bit di una passphrase
+// generata con queste opzioni, assumendo che l'attaccante conosca lo schema
+func PassphraseEntropy(opts PassphraseOptions) float64 {
+	listSize := len(opts.WordlistOrDefault().Words)
+	if opts.WordCount < 1 || listSize == 0 {
+		return 0
+	}
+
+	entropy := float64(opts.WordCount) * math.Log2(float64(listSize))
+
+	if opts.Capitalization == CapitalizeRandom {
+		entropy += float64(opts.WordCount)
+	}
+
+	if opts.DigitCount > 0 && opts.DigitCount <= opts.WordCount {
+		entropy += math.Log2(binomial(opts.WordCount, opts.DigitCount))
+		entropy += float64(opts.DigitCount) * math.Log2(float64(len(Digits)))
+	}
+
+	return entropy
+}
+
+// capitalizeFirst mette in maiuscolo la prima lettera della parola
+func capitalizeFirst(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError {
+		return word
+	}
+	return string(unicode.ToUpper(r)) + word[size:]
+}
@@ -0,0 +1,28 @@
+package kdbx
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadWordlistCaseInsensitive(t *testing.T) {
+	data := "# parole\n11111 Roma\n11112 roma\n11113 ROMA\nmare\nMare\n12345\nsole\n"
+	list, err := ReadWordlist("test", strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(list.Words, " "); got != "roma mare sole" {
+		t.Errorf("parole %q, attese \"roma mare sole\"", got)
+	}
+
+	// L'entropia conta le parole distinte, non le righe
+	opts := PassphraseOptions{WordCount: 4, Wordlist: list}
+	if entropy, want := PassphraseEntropy(opts), 4*math.Log2(3); math.Abs(entropy-want) > 1e-9 {
+		t.Errorf("entropia %.2f bit, attesa %.2f", entropy, want)
+	}
+
+	if _, err := ReadWordlist("test", strings.NewReader("Roma\nroma\n")); err == nil {
+		t.Error("wordlist di una sola parola distinta: nessun errore")
+	}
+}
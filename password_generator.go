@@ -2,8 +2,11 @@ package kdbx
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"fmt"
-	"math/big"
+	"io"
+	"math"
+	"strings"
 )
 
 const (
@@ -16,162 +19,302 @@ const (
 
 // PasswordOptions opzioni per generare password
 type PasswordOptions struct {
-	Length         int
-	UseLowercase   bool
-	UseUppercase   bool
-	UseDigits      bool
-	UseSpecial     bool
+	Length           int
+	UseLowercase     bool
+	UseUppercase     bool
+	UseDigits        bool
+	UseSpecial       bool
 	ExcludeAmbiguous bool // Escludi caratteri ambigui (0, O, l, 1, ecc.)
+
+	CustomChars  string // Caratteri aggiuntivi definiti dall'utente
+	ExcludeChars string // Caratteri da non usare mai (es. "&<>")
+
+	// Minimo di caratteri per classe attiva (0 = almeno uno)
+	MinLowercase int
+	MinUppercase int
+	MinDigits    int
+	MinSpecial   int
+
+	// Pattern, se non vuoto, descrive la password posizione per posizione
+	// e sostituisce Length e le classi (vedi PatternHelp)
+	Pattern        string
+	ShufflePattern bool // Permuta i caratteri generati dal pattern
 }
 
 // DefaultPasswordOptions ritorna opzioni sicure di default
 func DefaultPasswordOptions() PasswordOptions {
 	return PasswordOptions{
-		Length:         20,
-		UseLowercase:   true,
-		UseUppercase:   true,
-		UseDigits:      true,
-		UseSpecial:     true,
+		Length:           20,
+		UseLowercase:     true,
+		UseUppercase:     true,
+		UseDigits:        true,
+		UseSpecial:       true,
 		ExcludeAmbiguous: false,
 	}
 }
 
-// GeneratePassword genera una password casuale sicura usando crypto/rand
+// ambiguousChars sono i caratteri facili da confondere tra loro
+const ambiguousChars = "il1Lo0O"
+
+// charClass è una classe di caratteri con il numero minimo richiesto
+type charClass struct {
+	name  string
+	chars []rune
+	min   int
+}
+
+// passwordPlan è la forma compilata di PasswordOptions: si calcola una
+// volta sola e genera qualsiasi numero di password
+type passwordPlan struct {
+	required [][]rune // Set delle prime posizioni (minimi per classe o pattern)
+	pool     []rune   // Set delle posizioni restanti
+	length   int
+	shuffle  bool
+}
+
+// GeneratePassword genera una password casuale sicura usando crypto/rand.
+// I caratteri minimi di ogni classe occupano le prime posizioni, le altre
+// sono estratte dal set completo e il risultato viene mescolato con
+// Fisher-Yates: nessun tentativo a vuoto, qualunque sia la lunghezza
 func GeneratePassword(opts PasswordOptions) (string, error) {
+	plan, err := newPasswordPlan(opts)
+	if err != nil {
+		return "", err
+	}
+
+	var src randomSource
+	return plan.generate(&src)
+}
+
+// GeneratePasswords genera count password con le stesse opzioni,
+// compilando le opzioni e leggendo crypto/rand a blocchi una volta sola
+func GeneratePasswords(opts PasswordOptions, count int) ([]string, error) {
+	plan, err := newPasswordPlan(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var src randomSource
+	passwords := make([]string, count)
+	for i := range passwords {
+		if passwords[i], err = plan.generate(&src); err != nil {
+			return nil, err
+		}
+	}
+	return passwords, nil
+}
+
+// newPasswordPlan valida le opzioni e prepara i set di caratteri
+func newPasswordPlan(opts PasswordOptions) (*passwordPlan, error) {
+	if opts.Pattern != "" {
+		positions, err := parsePasswordPattern(opts.Pattern, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &passwordPlan{required: positions, length: len(positions), shuffle: opts.ShufflePattern}, nil
+	}
+
 	if opts.Length < 8 {
-		return "", fmt.Errorf("lunghezza password deve essere almeno 8 caratteri")
+		return nil, fmt.Errorf("lunghezza password deve essere almeno 8 caratteri")
+	}
+
+	classes, err := requiredClasses(opts)
+	if err != nil {
+		return nil, err
 	}
 
 	// Costruisci il set di caratteri
-	charset := buildCharset(opts)
-	if len(charset) == 0 {
-		return "", fmt.Errorf("nessun set di caratteri selezionato")
+	pool := buildCharset(opts)
+	if len(pool) == 0 {
+		return nil, fmt.Errorf("nessun set di caratteri selezionato")
 	}
 
-	// Genera password
-	password := make([]byte, opts.Length)
-	charsetLen := big.NewInt(int64(len(charset)))
+	plan := &passwordPlan{pool: pool, length: opts.Length, shuffle: true}
+	for _, class := range classes {
+		for i := 0; i < class.min; i++ {
+			plan.required = append(plan.required, class.chars)
+		}
+	}
+	return plan, nil
+}
 
+// generate estrae un carattere uniforme per ogni posizione e, se
+// richiesto, mescola il risultato
+func (p *passwordPlan) generate(src *randomSource) (string, error) {
+	password := make([]rune, p.length)
 	for i := range password {
-		// Usa crypto/rand per sicurezza crittografica
-		randomIndex, err := rand.Int(rand.Reader, charsetLen)
+		chars := p.pool
+		if i < len(p.required) {
+			chars = p.required[i]
+		}
+
+		index, err := src.intn(len(chars))
 		if err != nil {
-			return "", fmt.Errorf("errore generazione numero casuale: %w", err)
+			return "", err
 		}
-		password[i] = charset[randomIndex.Int64()]
+		password[i] = chars[index]
 	}
 
-	// Verifica che contenga almeno un carattere di ogni tipo richiesto
-	if !validatePassword(string(password), opts) {
-		// Ricorsione per rigenerare (molto raro)
-		return GeneratePassword(opts)
+	if p.shuffle {
+		for i := len(password) - 1; i > 0; i-- {
+			j, err := src.intn(i + 1)
+			if err != nil {
+				return "", err
+			}
+			password[i], password[j] = password[j], password[i]
+		}
 	}
 
 	return string(password), nil
 }
 
+// requiredClasses ritorna le classi attive con il loro minimo, verificando
+// che i minimi siano soddisfacibili
+func requiredClasses(opts PasswordOptions) ([]charClass, error) {
+	candidates := []struct {
+		name    string
+		chars   string
+		min     int
+		enabled bool
+	}{
+		{"minuscole", LowercaseLetters, opts.MinLowercase, opts.UseLowercase},
+		{"maiuscole", UppercaseLetters, opts.MinUppercase, opts.UseUppercase},
+		{"cifre", Digits, opts.MinDigits, opts.UseDigits},
+		{"speciali", SpecialChars, opts.MinSpecial, opts.UseSpecial},
+	}
+
+	var classes []charClass
+	total := 0
+	for _, c := range candidates {
+		if c.min < 0 {
+			return nil, fmt.Errorf("il minimo di %s non può essere negativo", c.name)
+		}
+		if !c.enabled {
+			if c.min > 0 {
+				return nil, fmt.Errorf("minimo di %s richiesto ma la classe è disattivata", c.name)
+			}
+			continue
+		}
+
+		chars := makeCharset(c.chars, opts)
+		if len(chars) == 0 {
+			return nil, fmt.Errorf("tutte le %s sono escluse", c.name)
+		}
+		if c.min == 0 {
+			c.min = 1
+		}
+		total += c.min
+		classes = append(classes, charClass{name: c.name, chars: chars, min: c.min})
+	}
+
+	if total > opts.Length {
+		return nil, fmt.Errorf("i minimi per classe richiedono %d caratteri, più della lunghezza %d", total, opts.Length)
+	}
+
+	return classes, nil
+}
+
 // buildCharset costruisce il set di caratteri basato sulle opzioni
-func buildCharset(opts PasswordOptions) string {
-	var charset string
+func buildCharset(opts PasswordOptions) []rune {
+	var charset strings.Builder
 
 	if opts.UseLowercase {
-		if opts.ExcludeAmbiguous {
-			charset += removeAmbiguous(LowercaseLetters)
-		} else {
-			charset += LowercaseLetters
-		}
+		charset.WriteString(LowercaseLetters)
 	}
 
 	if opts.UseUppercase {
-		if opts.ExcludeAmbiguous {
-			charset += removeAmbiguous(UppercaseLetters)
-		} else {
-			charset += UppercaseLetters
-		}
+		charset.WriteString(UppercaseLetters)
 	}
 
 	if opts.UseDigits {
-		if opts.ExcludeAmbiguous {
-			charset += removeAmbiguous(Digits)
-		} else {
-			charset += Digits
-		}
+		charset.WriteString(Digits)
 	}
 
 	if opts.UseSpecial {
-		charset += SpecialChars
+		charset.WriteString(SpecialChars)
 	}
 
-	return charset
+	charset.WriteString(opts.CustomChars)
+
+	return makeCharset(charset.String(), opts)
 }
 
-// removeAmbiguous rimuove caratteri ambigui
-func removeAmbiguous(s string) string {
-	ambiguous := "il1Lo0O"
-	result := ""
-	for _, c := range s {
-		isAmbiguous := false
-		for _, amb := range ambiguous {
-			if c == amb {
-				isAmbiguous = true
-				break
-			}
-		}
-		if !isAmbiguous {
-			result += string(c)
+// makeCharset ritorna i caratteri distinti di chars senza quelli esclusi
+// e, se richiesto, quelli ambigui. I duplicati (es. caratteri extra già
+// presenti in una classe) sono rimossi perché renderebbero quei
+// caratteri più probabili degli altri
+func makeCharset(chars string, opts PasswordOptions) []rune {
+	seen := make(map[rune]bool, len(chars))
+	set := make([]rune, 0, len(chars))
+	for _, c := range chars {
+		if seen[c] || strings.ContainsRune(opts.ExcludeChars, c) ||
+			(opts.ExcludeAmbiguous && strings.ContainsRune(ambiguousChars, c)) {
+			continue
 		}
+		seen[c] = true
+		set = append(set, c)
 	}
-	return result
+	return set
 }
 
-// validatePassword verifica che la password contenga almeno un carattere di ogni tipo richiesto
-func validatePassword(password string, opts PasswordOptions) bool {
-	hasLower, hasUpper, hasDigit, hasSpecial := false, false, false, false
-
-	for _, c := range password {
-		switch {
-		case isInCharset(c, LowercaseLetters):
-			hasLower = true
-		case isInCharset(c, UppercaseLetters):
-			hasUpper = true
-		case isInCharset(c, Digits):
-			hasDigit = true
-		case isInCharset(c, SpecialChars):
-			hasSpecial = true
-		}
-	}
+// randomSource estrae interi uniformi da crypto/rand leggendo a blocchi,
+// per non pagare una lettura e un big.Int per ogni carattere. Lo zero
+// value è pronto all'uso; non è sicuro per l'uso concorrente
+type randomSource struct {
+	buf [256]byte
+	pos int
+	end int
+}
 
-	if opts.UseLowercase && !hasLower {
-		return false
-	}
-	if opts.UseUppercase && !hasUpper {
-		return false
-	}
-	if opts.UseDigits && !hasDigit {
-		return false
-	}
-	if opts.UseSpecial && !hasSpecial {
-		return false
+// uint32 ritorna 32 bit casuali, ricaricando il buffer quando è esaurito
+func (s *randomSource) uint32() (uint32, error) {
+	if s.pos+4 > s.end {
+		if _, err := io.ReadFull(rand.Reader, s.buf[:]); err != nil {
+			return 0, fmt.Errorf("errore generazione numero casuale: %w", err)
+		}
+		s.pos, s.end = 0, len(s.buf)
 	}
 
-	return true
+	v := binary.LittleEndian.Uint32(s.buf[s.pos:])
+	s.pos += 4
+	return v, nil
 }
 
-// isInCharset verifica se un carattere è presente in un set
-func isInCharset(c rune, charset string) bool {
-	for _, ch := range charset {
-		if c == ch {
-			return true
+// intn ritorna un intero uniforme in [0, n). I valori sotto 2^32 mod n
+// vengono scartati, così quelli accettati sono un multiplo esatto di n e
+// il modulo non introduce bias
+func (s *randomSource) intn(n int) (int, error) {
+	if n <= 0 || uint64(n) > math.MaxUint32 {
+		return 0, fmt.Errorf("intervallo casuale non valido: %d", n)
+	}
+
+	bound := uint32(n)
+	threshold := -bound % bound
+	for {
+		v, err := s.uint32()
+		if err != nil {
+			return 0, err
+		}
+		if v >= threshold {
+			return int(v % bound), nil
 		}
 	}
-	return false
 }
 
-// CalculateEntropy calcola l'entropia della password in bit
+// CalculateEntropy calcola l'entropia in bit di una password generata con
+// le opzioni indicate, cioè l'incertezza del processo di generazione.
+// Per password inserite dall'utente usa EstimateStrength
 func CalculateEntropy(password string, opts PasswordOptions) float64 {
-	charset := buildCharset(opts)
-	charsetSize := float64(len(charset))
-	passwordLength := float64(len(password))
+	if opts.Pattern != "" {
+		positions, err := parsePasswordPattern(opts.Pattern, opts)
+		if err != nil {
+			return 0
+		}
+		return patternEntropy(positions)
+	}
+
+	charsetSize := float64(len(buildCharset(opts)))
+	passwordLength := float64(len([]rune(password)))
 
 	// Entropia = log2(charsetSize^passwordLength)
 	// = passwordLength * log2(charsetSize)
@@ -179,14 +322,5 @@ func CalculateEntropy(password string, opts PasswordOptions) float64 {
 		return 0
 	}
 
-	return passwordLength * log2(charsetSize)
-}
-
-// log2 calcola logaritmo base 2
-func log2(x float64) float64 {
-	if x <= 0 {
-		return 0
-	}
-	// log2(x) = ln(x) / ln(2)
-	return 1.44269504089 * 2.71828182846 // Approssimazione
+	return passwordLength * math.Log2(charsetSize)
 }
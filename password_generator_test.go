@@ -0,0 +1,213 @@
+package kdbx
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// chiSquareCritical approssima (Wilson-Hilferty) il valore critico del
+// chi quadro con df gradi di libertà per il quantile normale z. Con z = 4.75
+// un generatore corretto fallisce circa una volta su un milione per test
+func chiSquareCritical(df int, z float64) float64 {
+	k := float64(df)
+	return k * math.Pow(1-2/(9*k)+z*math.Sqrt(2/(9*k)), 3)
+}
+
+// expectedFrequencies calcola la probabilità esatta di ogni carattere in
+// una posizione qualsiasi: le posizioni vengono mescolate, quindi ognuna è
+// la media dei set delle posizioni richieste e del pool
+func expectedFrequencies(plan *passwordPlan) map[rune]float64 {
+	expected := map[rune]float64{}
+	for i := 0; i < plan.length; i++ {
+		chars := plan.pool
+		if i < len(plan.required) {
+			chars = plan.required[i]
+		}
+		for _, c := range chars {
+			expected[c] += 1 / float64(len(chars)) / float64(plan.length)
+		}
+	}
+	return expected
+}
+
+// checkPositionUniformity genera samples password e verifica con un test
+// del chi quadro che in ogni posizione i caratteri seguano la distribuzione
+// attesa
+func checkPositionUniformity(t *testing.T, opts PasswordOptions, samples int) {
+	t.Helper()
+
+	plan, err := newPasswordPlan(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := expectedFrequencies(plan)
+
+	passwords, err := GeneratePasswords(opts, samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make([]map[rune]int, plan.length)
+	for i := range counts {
+		counts[i] = map[rune]int{}
+	}
+	for _, password := range passwords {
+		runes := []rune(password)
+		if len(runes) != plan.length {
+			t.Fatalf("lunghezza %d, attesa %d", len(runes), plan.length)
+		}
+		for i, c := range runes {
+			if _, ok := expected[c]; !ok {
+				t.Fatalf("carattere %q fuori dal set in %q", c, password)
+			}
+			counts[i][c]++
+		}
+	}
+
+	critical := chiSquareCritical(len(expected)-1, 4.75)
+	for i, observed := range counts {
+		chi := 0.0
+		for c, p := range expected {
+			e := p * float64(samples)
+			d := float64(observed[c]) - e
+			chi += d * d / e
+		}
+		if chi > critical {
+			t.Errorf("posizione %d: chi quadro %.1f oltre il valore critico %.1f (%d caratteri)",
+				i, chi, critical, len(expected))
+		}
+	}
+}
+
+func TestGeneratePasswordUniformity(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PasswordOptions
+	}{
+		{"default", DefaultPasswordOptions()},
+		{"solo minuscole", PasswordOptions{Length: 12, UseLowercase: true}},
+		{"minimi per classe", PasswordOptions{
+			Length: 16, UseLowercase: true, UseUppercase: true, UseDigits: true, UseSpecial: true,
+			MinDigits: 4, MinSpecial: 3,
+		}},
+		{"esclusi e ambigui", PasswordOptions{
+			Length: 12, UseLowercase: true, UseDigits: true,
+			ExcludeChars: "aeiou", ExcludeAmbiguous: true,
+		}},
+		{"caratteri extra", PasswordOptions{
+			Length: 10, UseDigits: true, CustomChars: "€£¥0123",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkPositionUniformity(t, tt.opts, 20000)
+		})
+	}
+}
+
+func TestGeneratePasswordRequiredClasses(t *testing.T) {
+	opts := PasswordOptions{
+		Length: 12, UseLowercase: true, UseUppercase: true, UseDigits: true, UseSpecial: true,
+		MinUppercase: 3, MinDigits: 4, MinSpecial: 2,
+	}
+	passwords, err := GeneratePasswords(opts, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range passwords {
+		var lower, upper, digits, special int
+		for _, c := range password {
+			switch {
+			case unicode.IsLower(c):
+				lower++
+			case unicode.IsUpper(c):
+				upper++
+			case unicode.IsDigit(c):
+				digits++
+			case strings.ContainsRune(SpecialChars, c):
+				special++
+			}
+		}
+		if lower < 1 || upper < 3 || digits < 4 || special < 2 {
+			t.Fatalf("%q: minimi non rispettati (a-z %d, A-Z %d, 0-9 %d, speciali %d)",
+				password, lower, upper, digits, special)
+		}
+	}
+}
+
+func TestGeneratePasswordCharsets(t *testing.T) {
+	opts := PasswordOptions{
+		Length: 32, UseLowercase: true, UseDigits: true,
+		CustomChars: "#€abc", ExcludeChars: "xyz0",
+	}
+	passwords, err := GeneratePasswords(opts, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[rune]bool{}
+	for _, password := range passwords {
+		for _, c := range password {
+			if strings.ContainsRune(opts.ExcludeChars, c) {
+				t.Fatalf("%q contiene il carattere escluso %q", password, c)
+			}
+			seen[c] = true
+		}
+	}
+	for _, c := range "#€" {
+		if !seen[c] {
+			t.Errorf("il carattere extra %q non compare mai", c)
+		}
+	}
+
+	// I caratteri extra già presenti in una classe non contano doppio
+	plan, err := newPasswordPlan(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 26 - 3 + 10 - 1 + 2; len(plan.pool) != want {
+		t.Errorf("pool di %d caratteri, attesi %d", len(plan.pool), want)
+	}
+}
+
+func TestGeneratePasswordInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PasswordOptions
+	}{
+		{"troppo corta", PasswordOptions{Length: 7, UseLowercase: true}},
+		{"nessuna classe", PasswordOptions{Length: 12}},
+		{"minimi oltre la lunghezza", PasswordOptions{Length: 8, UseDigits: true, MinDigits: 9}},
+		{"minimo di classe disattivata", PasswordOptions{Length: 12, UseLowercase: true, MinDigits: 1}},
+		{"classe tutta esclusa", PasswordOptions{Length: 12, UseLowercase: true, UseDigits: true, ExcludeChars: Digits}},
+	}
+
+	for _, tt := range tests {
+		if _, err := GeneratePassword(tt.opts); err == nil {
+			t.Errorf("%s: nessun errore", tt.name)
+		}
+	}
+}
+
+func BenchmarkGeneratePassword(b *testing.B) {
+	opts := DefaultPasswordOptions()
+	for i := 0; i < b.N; i++ {
+		if _, err := GeneratePassword(opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGeneratePasswords(b *testing.B) {
+	opts := DefaultPasswordOptions()
+	const count = 1000
+	for i := 0; i < b.N; i++ {
+		if _, err := GeneratePasswords(opts, count); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
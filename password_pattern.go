@@ -0,0 +1,177 @@
+package kdbx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxPatternLength limita la lunghezza di una password generata da pattern
+const maxPatternLength = 1024
+
+// patternPlaceholders associa a ogni segnaposto del pattern il suo set,
+// sulla falsariga del generatore a pattern di KeePass
+var patternPlaceholders = map[rune]string{
+	'd': Digits,
+	'l': LowercaseLetters,
+	'u': UppercaseLetters,
+	'L': LowercaseLetters + UppercaseLetters,
+	'a': LowercaseLetters + Digits,
+	'U': UppercaseLetters + Digits,
+	'A': LowercaseLetters + UppercaseLetters + Digits,
+	'h': "0123456789abcdef",
+	'H': "0123456789ABCDEF",
+	's': SpecialChars,
+	'S': LowercaseLetters + UppercaseLetters + Digits + SpecialChars,
+	'v': "aeiou",
+	'V': "aeiouAEIOU",
+	'Z': "AEIOU",
+	'c': "bcdfghjklmnpqrstvwxyz",
+	'C': "bcdfghjklmnpqrstvwxyzBCDFGHJKLMNPQRSTVWXYZ",
+	'z': "BCDFGHJKLMNPQRSTVWXYZ",
+}
+
+// PatternHelp descrive la sintassi dei pattern per l'interfaccia
+const PatternHelp = `d cifra · l minuscola · u maiuscola · L lettera · a minuscola/cifra
+U maiuscola/cifra · A lettera/cifra · h/H esadecimale · s speciale · S qualsiasi
+v/V/Z vocale · c/C/z consonante · \x carattere letterale
+{n} ripete n volte l'elemento precedente · [dus^0O] set personalizzato (^ esclude)
+Esempio: ullldd\-d{4}`
+
+// parsePasswordPattern espande il pattern in un set di caratteri per ogni
+// posizione. Esclusioni e caratteri ambigui delle opzioni si applicano ai
+// segnaposto ma non ai caratteri letterali
+func parsePasswordPattern(pattern string, opts PasswordOptions) ([][]rune, error) {
+	var positions [][]rune
+	runes := []rune(pattern)
+
+	for i := 0; i < len(runes); i++ {
+		var chars []rune
+		switch c := runes[i]; c {
+		case '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("pattern: '\\' finale senza carattere")
+			}
+			i++
+			chars = runes[i : i+1]
+		case '[':
+			end := closingBracket(runes, i)
+			if end < 0 {
+				return nil, fmt.Errorf("pattern: '[' senza ']' alla posizione %d", i+1)
+			}
+			set, err := parsePatternSet(runes[i+1:end], opts)
+			if err != nil {
+				return nil, err
+			}
+			chars = set
+			i = end
+		case '{':
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("pattern: '{' senza '}' alla posizione %d", i+1)
+			}
+			if len(positions) == 0 {
+				return nil, fmt.Errorf("pattern: {n} deve seguire un elemento")
+			}
+			count, err := strconv.Atoi(string(runes[i+1 : end]))
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("pattern: ripetizione non valida {%s}", string(runes[i+1:end]))
+			}
+			if len(positions)+count-1 > maxPatternLength {
+				return nil, fmt.Errorf("pattern: la password supera %d caratteri", maxPatternLength)
+			}
+			last := positions[len(positions)-1]
+			for j := 1; j < count; j++ {
+				positions = append(positions, last)
+			}
+			i = end
+			continue
+		default:
+			placeholder, ok := patternPlaceholders[c]
+			if !ok {
+				return nil, fmt.Errorf("pattern: segnaposto sconosciuto '%c' (usa \\%c per il carattere letterale)", c, c)
+			}
+			chars = makeCharset(placeholder, opts)
+			if len(chars) == 0 {
+				return nil, fmt.Errorf("pattern: tutti i caratteri di '%c' sono esclusi", c)
+			}
+		}
+
+		if len(positions) >= maxPatternLength {
+			return nil, fmt.Errorf("pattern: la password supera %d caratteri", maxPatternLength)
+		}
+		positions = append(positions, chars)
+	}
+
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("pattern vuoto")
+	}
+
+	return positions, nil
+}
+
+// closingBracket ritorna l'indice della ']' che chiude il set aperto in
+// start, saltando quelle precedute da '\'
+func closingBracket(runes []rune, start int) int {
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parsePatternSet espande il contenuto di un set [..]: segnaposto e
+// caratteri letterali (\x) si sommano; i caratteri dopo '^' sono letterali
+// e vengono esclusi.
+// I caratteri ripetuti contano una sola volta
+func parsePatternSet(body []rune, opts PasswordOptions) ([]rune, error) {
+	var include, exclude strings.Builder
+	target := &include
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '^' && target == &include:
+			target = &exclude
+		case c == '\\':
+			if i+1 >= len(body) {
+				return nil, fmt.Errorf("pattern: '\\' finale senza carattere nel set")
+			}
+			i++
+			target.WriteRune(body[i])
+		case target == &exclude:
+			exclude.WriteRune(c)
+		default:
+			placeholder, ok := patternPlaceholders[c]
+			if !ok {
+				return nil, fmt.Errorf("pattern: segnaposto sconosciuto '%c' nel set", c)
+			}
+			target.WriteString(string(makeCharset(placeholder, opts)))
+		}
+	}
+
+	// I letterali non sono soggetti alle esclusioni delle opzioni
+	set := makeCharset(include.String(), PasswordOptions{ExcludeChars: exclude.String()})
+	if len(set) == 0 {
+		return nil, fmt.Errorf("pattern: set [%s] vuoto", string(body))
+	}
+	return set, nil
+}
+
+// patternEntropy calcola l'entropia in bit di una password generata dalle
+// posizioni del pattern (la permutazione non è conteggiata)
+func patternEntropy(positions [][]rune) float64 {
+	entropy := 0.0
+	for _, chars := range positions {
+		entropy += math.Log2(float64(len(chars)))
+	}
+	return entropy
+}
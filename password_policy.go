@@ -0,0 +1,189 @@
+package kdbx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+// groupPolicyKeyName è il nome della chiave CustomData della policy di gruppo
+const groupPolicyKeyName = "GroupPasswordPolicy"
+
+// PolicyEnforcement indica cosa succede quando una entry viola la policy
+type PolicyEnforcement int
+
+const (
+	PolicyWarn   PolicyEnforcement = iota // Salva comunque, mostrando gli avvisi
+	PolicyRefuse                          // Rifiuta il salvataggio
+)
+
+// PasswordPolicy sono le regole per le password di un gruppo e dei suoi
+// sottogruppi (salvo che un sottogruppo ne definisca una propria)
+type PasswordPolicy struct {
+	// None toglie al gruppo e ai suoi sottogruppi la policy ereditata dal
+	// padre, senza imporne un'altra; le altre regole sono ignorate
+	None bool
+
+	MinLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigits    bool
+	RequireSpecial   bool
+	MaxAgeDays       int  // 0 = nessuna scadenza
+	NoReuse          bool // Password uniche nel gruppo e nei sottogruppi
+	Enforcement      PolicyEnforcement
+}
+
+// IsZero indica se la policy non contiene regole
+func (p PasswordPolicy) IsZero() bool {
+	return p.MinLength == 0 && !p.RequireLowercase && !p.RequireUppercase &&
+		!p.RequireDigits && !p.RequireSpecial && p.MaxAgeDays == 0 && !p.NoReuse
+}
+
+// PolicyError è l'errore ritornato da CreateEntry e UpdateEntry quando la
+// entry viola una policy con PolicyRefuse
+type PolicyError struct {
+	GroupPath  string // Gruppo che definisce la policy
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("la password non rispetta la policy del gruppo %s: %s",
+		e.GroupPath, strings.Join(e.Violations, "; "))
+}
+
+// SetGroupPasswordPolicy salva la policy del gruppo; una policy senza
+// regole (e senza None) rimuove quella esistente, tornando a ereditare
+func (db *Database) SetGroupPasswordPolicy(groupPath string, policy PasswordPolicy) error {
+	group, _ := db.findGroup(groupPath)
+	if group == nil {
+		return fmt.Errorf("gruppo non trovato: %s", groupPath)
+	}
+	if policy.MinLength < 0 || policy.MaxAgeDays < 0 {
+		return fmt.Errorf("lunghezza minima e durata massima non possono essere negative")
+	}
+
+	key := groupCustomDataKey(groupPolicyKeyName, group.UUID)
+	if policy.None {
+		policy = PasswordPolicy{None: true}
+	} else if policy.IsZero() {
+		db.setCustomData(key, "")
+		return nil
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("errore salvataggio policy: %w", err)
+	}
+	db.setCustomData(key, string(data))
+	return nil
+}
+
+// GroupPasswordPolicy ritorna la policy che vale per il gruppo: la sua o,
+// se non ne ha, quella del padre più vicino. sourcePath è il gruppo che
+// la definisce; con None quel gruppo ha interrotto l'eredità e non valgono
+// regole
+func (db *Database) GroupPasswordPolicy(groupPath string) (policy PasswordPolicy, sourcePath string, ok bool) {
+	chain := db.groupChain(groupPath)
+	for i := len(chain) - 1; i >= 0; i-- {
+		data, found := db.customData(groupCustomDataKey(groupPolicyKeyName, chain[i].UUID))
+		if !found {
+			continue
+		}
+		if err := json.Unmarshal([]byte(data), &policy); err != nil {
+			continue
+		}
+		return policy, chainPath(chain[:i+1]), true
+	}
+	return PasswordPolicy{}, "", false
+}
+
+// chainPath ricostruisce il GroupPath dell'ultimo gruppo della catena
+func chainPath(chain []*gokeepasslib.Group) string {
+	path := ""
+	for _, group := range chain {
+		path = joinGroupPath(path, group.Name)
+	}
+	return path
+}
+
+// CheckPasswordPolicy verifica la entry contro la policy del gruppo
+// e.GroupPath e ritorna le regole violate. La durata massima conta solo
+// per le entry esistenti (UUID non vuoto) se la password non cambia, ed è
+// ritornata in warnings: anche con PolicyRefuse non blocca il salvataggio,
+// altrimenti una entry vecchia non si potrebbe modificare senza cambiarne
+// la password
+func (db *Database) CheckPasswordPolicy(e Entry) (policy PasswordPolicy, sourcePath string, violations, warnings []string) {
+	policy, sourcePath, ok := db.GroupPasswordPolicy(e.GroupPath)
+	if !ok || policy.None {
+		return PasswordPolicy{}, "", nil, nil
+	}
+
+	if length := utf8.RuneCountInString(e.Password); length < policy.MinLength {
+		violations = append(violations, fmt.Sprintf("almeno %d caratteri (ne ha %d)", policy.MinLength, length))
+	}
+
+	hasLower, hasUpper, hasDigit, hasSpecial := false, false, false, false
+	for _, c := range e.Password {
+		switch {
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case !unicode.IsLetter(c) && !unicode.IsSpace(c):
+			hasSpecial = true
+		}
+	}
+	if policy.RequireLowercase && !hasLower {
+		violations = append(violations, "serve una lettera minuscola")
+	}
+	if policy.RequireUppercase && !hasUpper {
+		violations = append(violations, "serve una lettera maiuscola")
+	}
+	if policy.RequireDigits && !hasDigit {
+		violations = append(violations, "serve una cifra")
+	}
+	if policy.RequireSpecial && !hasSpecial {
+		violations = append(violations, "serve un carattere speciale")
+	}
+
+	if policy.MaxAgeDays > 0 && e.UUID != (gokeepasslib.UUID{}) {
+		if parent, index := findEntry(&db.Content.Root.Groups[0], e.UUID); parent != nil {
+			existing := &parent.Entries[index]
+			if existing.GetPassword() == e.Password {
+				age := time.Since(passwordChangedAt(existing))
+				if days := int(age.Hours() / 24); days > policy.MaxAgeDays {
+					warnings = append(warnings, fmt.Sprintf("password di %d giorni, massimo %d: va cambiata", days, policy.MaxAgeDays))
+				}
+			}
+		}
+	}
+
+	if policy.NoReuse && e.Password != "" {
+		for _, other := range db.GetEntriesInGroup(sourcePath, true) {
+			if other.UUID != e.UUID && other.Password == e.Password {
+				violations = append(violations, fmt.Sprintf("password già usata da %q", other.Title))
+				break
+			}
+		}
+	}
+
+	return policy, sourcePath, violations, warnings
+}
+
+// enforcePasswordPolicy ritorna un *PolicyError se la entry viola una
+// policy che rifiuta il salvataggio
+func (db *Database) enforcePasswordPolicy(e Entry) error {
+	policy, sourcePath, violations, _ := db.CheckPasswordPolicy(e)
+	if len(violations) > 0 && policy.Enforcement == PolicyRefuse {
+		return &PolicyError{GroupPath: sourcePath, Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package kdbx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+func TestPasswordPolicyInheritance(t *testing.T) {
+	db, err := CreateNewDatabase(testSaveOptions(t, "test.kdbx", "master"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Content.Root.Groups[0].UUID == (gokeepasslib.UUID{}) {
+		t.Fatal("gruppo root senza UUID")
+	}
+	db.findOrCreateGroup("Lavoro" + PathSeparator + "Test")
+	db.findOrCreateGroup("Lavoro" + PathSeparator + "Test" + PathSeparator + "Locale")
+
+	strict := PasswordPolicy{MinLength: 12, Enforcement: PolicyRefuse}
+	if err := db.SetGroupPasswordPolicy("Root", strict); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetGroupPasswordPolicy("Lavoro"+PathSeparator+"Test", PasswordPolicy{None: true, MinLength: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		group      string
+		violations int
+	}{
+		{"Lavoro", 1},
+		{"Lavoro" + PathSeparator + "Test", 0},
+		{"Lavoro" + PathSeparator + "Test" + PathSeparator + "Locale", 0},
+	}
+	for _, tt := range tests {
+		_, _, violations, _ := db.CheckPasswordPolicy(Entry{Title: "Prova", Password: "abc", GroupPath: tt.group})
+		if len(violations) != tt.violations {
+			t.Errorf("%s: violazioni %v, attese %d", tt.group, violations, tt.violations)
+		}
+	}
+
+	// La policy None non conserva le altre regole
+	if policy, source, ok := db.GroupPasswordPolicy("Lavoro" + PathSeparator + "Test" + PathSeparator + "Locale"); !ok ||
+		policy != (PasswordPolicy{None: true}) || source != "Root"+PathSeparator+"Lavoro"+PathSeparator+"Test" {
+		t.Errorf("policy %+v da %q", policy, source)
+	}
+
+	if _, err := db.CreateEntry(Entry{Title: "Corta", Password: "abc", GroupPath: "Lavoro"}); !errors.As(err, new(*PolicyError)) {
+		t.Errorf("password corta in Lavoro: %v", err)
+	}
+}
+
+func TestPasswordPolicyMaxAgeWarns(t *testing.T) {
+	db, err := CreateNewDatabase(testSaveOptions(t, "test.kdbx", "master"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := PasswordPolicy{MinLength: 8, MaxAgeDays: 30, Enforcement: PolicyRefuse}
+	if err := db.SetGroupPasswordPolicy("Root", policy); err != nil {
+		t.Fatal(err)
+	}
+
+	e := db.addEntry(Entry{Title: "Vecchia", Password: "lunga-abbastanza"})
+	parent, index := findEntry(&db.Content.Root.Groups[0], e.UUID)
+	setModified(&parent.Entries[index], time.Now().AddDate(0, 0, -90))
+
+	// La password scaduta è un avviso: la entry resta modificabile
+	e.Notes = "aggiornata"
+	_, _, violations, warnings := db.CheckPasswordPolicy(e)
+	if len(violations) != 0 || len(warnings) != 1 {
+		t.Errorf("violazioni %v, avvisi %v", violations, warnings)
+	}
+	if err := db.UpdateEntry(e); err != nil {
+		t.Errorf("modifica delle note: %v", err)
+	}
+
+	// Cambiando la password la durata non conta più
+	e.Password = "nuova-password"
+	if _, _, _, warnings := db.CheckPasswordPolicy(e); len(warnings) != 0 {
+		t.Errorf("password nuova: avvisi %v", warnings)
+	}
+}
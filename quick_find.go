@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// quickFindMaxResults limita i risultati mostrati dal launcher
+const quickFindMaxResults = 50
+
+// quickFindShortcut apre la ricerca rapida (Ctrl+K / Cmd+K)
+var quickFindShortcut = &desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierShortcutDefault}
+
+// copyUsernameShortcut copia lo username dalla ricerca rapida (Ctrl+B come KeePassXC)
+var copyUsernameShortcut = &desktop.CustomShortcut{KeyName: fyne.KeyB, Modifier: fyne.KeyModifierShortcutDefault}
+
+// quickFindEntry è il campo di ricerca del launcher; intercetta i tasti di
+// navigazione prima che arrivino alla Entry
+type quickFindEntry struct {
+	widget.Entry
+	onKey      func(*fyne.KeyEvent) bool
+	onShortcut func(fyne.Shortcut) bool
+}
+
+func newQuickFindEntry() *quickFindEntry {
+	entry := &quickFindEntry{}
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+// TypedKey gestisce frecce, Invio ed Esc
+func (e *quickFindEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.onKey != nil && e.onKey(key) {
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
+// TypedShortcut gestisce le scorciatoie di copia
+func (e *quickFindEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	if e.onShortcut != nil && e.onShortcut(shortcut) {
+		return
+	}
+	e.Entry.TypedShortcut(shortcut)
+}
+
+// shortcutEntry è una Entry della finestra principale che apre la ricerca
+// rapida con Ctrl+K: Fyne manda le scorciatoie al widget con il focus e
+// non al canvas, dove è registrata per il resto della finestra
+type shortcutEntry struct {
+	widget.Entry
+	mw *MainWindow
+}
+
+func (mw *MainWindow) newShortcutEntry() *shortcutEntry {
+	entry := &shortcutEntry{mw: mw}
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+// TypedShortcut apre la ricerca rapida, le altre scorciatoie vanno alla Entry
+func (e *shortcutEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	if shortcut.ShortcutName() == quickFindShortcut.ShortcutName() {
+		e.mw.showQuickFind()
+		return
+	}
+	e.Entry.TypedShortcut(shortcut)
+}
+
+// registerQuickFindShortcut registra Ctrl+K sulla finestra principale; i
+// campi di testo della finestra sono shortcutEntry
+func (mw *MainWindow) registerQuickFindShortcut() {
+	mw.Window.Canvas().AddShortcut(quickFindShortcut, func(fyne.Shortcut) {
+		mw.showQuickFind()
+	})
+}
+
+// showQuickFind mostra il launcher di ricerca rapida. Si usa solo da
+// tastiera: frecce per scegliere, Invio copia la password, Ctrl+B copia
+// lo username, Esc chiude
+func (mw *MainWindow) showQuickFind() {
+	if mw.Database == nil {
+		dialog.ShowError(fmt.Errorf("Apri o crea un database prima"), mw.Window)
+		return
+	}
+
+	allEntries := mw.Database.GetAllEntries()
+	var results []kdbx.QuickFindResult
+	selected := 0
+
+	input := newQuickFindEntry()
+	input.PlaceHolder = "Cerca titolo, URL o username..."
+
+	list := widget.NewList(
+		func() int {
+			return len(results)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Template")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			entry := results[id].Entry
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s (%s) — %s", entry.Title, entry.Username, entry.URL))
+		},
+	)
+
+	hint := widget.NewLabel("↑↓ scegli · Invio copia password · Ctrl+B copia username · Esc chiudi")
+
+	var popup *widget.PopUp
+
+	update := func(pattern string) {
+		results = kdbx.QuickFind(allEntries, pattern, time.Now())
+		if len(results) > quickFindMaxResults {
+			results = results[:quickFindMaxResults]
+		}
+		selected = 0
+		list.Refresh()
+		if len(results) > 0 {
+			list.Select(selected)
+		}
+	}
+
+	copyField := func(password bool) {
+		if selected < 0 || selected >= len(results) {
+			return
+		}
+		entry := results[selected].Entry
+
+		if password {
+			mw.Window.Clipboard().SetContent(entry.Password)
+		} else {
+			mw.Window.Clipboard().SetContent(entry.Username)
+		}
+		mw.recordUsage(entry)
+		popup.Hide()
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+
+	input.OnChanged = update
+
+	input.onKey = func(key *fyne.KeyEvent) bool {
+		switch key.Name {
+		case fyne.KeyDown:
+			if selected < len(results)-1 {
+				selected++
+				list.Select(selected)
+			}
+		case fyne.KeyUp:
+			if selected > 0 {
+				selected--
+				list.Select(selected)
+			}
+		case fyne.KeyReturn, fyne.KeyEnter:
+			copyField(true)
+		case fyne.KeyEscape:
+			popup.Hide()
+		default:
+			return false
+		}
+		return true
+	}
+
+	input.onShortcut = func(shortcut fyne.Shortcut) bool {
+		if custom, ok := shortcut.(*desktop.CustomShortcut); ok &&
+			custom.KeyName == copyUsernameShortcut.KeyName &&
+			custom.Modifier == copyUsernameShortcut.Modifier {
+			copyField(false)
+			return true
+		}
+		return false
+	}
+
+	content := container.NewBorder(
+		container.NewVBox(widget.NewLabelWithStyle("Ricerca rapida", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), input),
+		hint,
+		nil,
+		nil,
+		list,
+	)
+
+	popup = widget.NewModalPopUp(content, mw.Window.Canvas())
+	popup.Resize(fyne.NewSize(600, 400))
+	update("")
+	popup.Show()
+	mw.Window.Canvas().Focus(input)
+}
+
+// recordUsage aggiorna le statistiche di utilizzo della entry nel database
+func (mw *MainWindow) recordUsage(entry kdbx.Entry) {
+	if mw.Database == nil {
+		return
+	}
+	mw.Database.RecordEntryUsage(entry.UUID)
+}
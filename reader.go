@@ -3,6 +3,8 @@ package kdbx
 import (
 	"fmt"
 	"os"
+	"sort"
+	"time"
 
 	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
 )
@@ -15,22 +17,49 @@ type Database struct {
 
 // Entry rappresenta una singola password/entry
 type Entry struct {
-	Title    string
-	Username string
-	Password string
-	URL      string
-	Notes    string
-	GroupPath string // Path completo del gruppo (categoria)
+	UUID        gokeepasslib.UUID
+	Title       string
+	Username    string
+	Password    string
+	URL         string
+	Notes       string
+	GroupPath   string        // Path completo del gruppo (categoria)
+	Tags        []string      // Tag della entry (campo KDBX Tags, separati da ";")
+	OTP         string        // Seed TOTP come URI otpauth:// (campo "otp" o legacy)
+	Fields      []CustomField // Campi aggiuntivi (stringhe KDBX non standard)
+	Attachments []Attachment  // Allegati (in lettura solo i nomi)
+	Template    bool          // Modello di entry da un import (vedi ImportEntries)
+
+	Expires    bool      // La entry ha una data di scadenza
+	ExpiryTime time.Time // Data di scadenza (valida solo se Expires)
+
+	UsageCount     int64     // Numero di utilizzi (campo KDBX UsageCount)
+	LastAccessTime time.Time // Ultimo utilizzo (campo KDBX LastAccessTime)
+
+	PasswordChanged time.Time // Ultimo cambio password (ricavato dalla history)
+	Modified        time.Time // Ultima modifica (o creazione) della entry
+}
+
+// CustomField è un campo aggiuntivo di una entry
+type CustomField struct {
+	Key       string
+	Value     string
+	Protected bool // Valore nascosto nell'interfaccia e cifrato in memoria
 }
 
 // Group rappresenta un gruppo/categoria
 type Group struct {
-	Name     string
-	Path     string
-	Entries  []Entry
-	SubGroups []Group
+	UUID       gokeepasslib.UUID
+	Name       string
+	Path       string
+	IsExpanded bool // Stato espanso/collassato salvato nel .kdbx
+	Entries    []Entry
+	SubGroups  []Group
 }
 
+// PathSeparator separa i nomi dei gruppi in un GroupPath
+const PathSeparator = " / "
+
 // OpenDatabase apre un database .kdbx esistente
 func OpenDatabase(filePath string, password string) (*Database, error) {
 	file, err := os.Open(filePath)
@@ -74,38 +103,11 @@ func (db *Database) GetAllEntries() []Entry {
 func (db *Database) extractEntriesFromGroup(group *gokeepasslib.Group, parentPath string) []Entry {
 	var entries []Entry
 
-	currentPath := parentPath
-	if group.Name != "" {
-		if currentPath != "" {
-			currentPath += " / " + group.Name
-		} else {
-			currentPath = group.Name
-		}
-	}
+	currentPath := joinGroupPath(parentPath, group.Name)
 
 	// Estrai entries del gruppo corrente
-	for _, entry := range group.Entries {
-		e := Entry{
-			GroupPath: currentPath,
-		}
-
-		// Estrai i valori dai campi
-		for _, value := range entry.Values {
-			switch value.Key {
-			case "Title":
-				e.Title = value.Value.Content
-			case "UserName":
-				e.Username = value.Value.Content
-			case "Password":
-				e.Password = value.Value.Content
-			case "URL":
-				e.URL = value.Value.Content
-			case "Notes":
-				e.Notes = value.Value.Content
-			}
-		}
-
-		entries = append(entries, e)
+	for i := range group.Entries {
+		entries = append(entries, newEntry(&group.Entries[i], currentPath))
 	}
 
 	// Elabora ricorsivamente i sottogruppi
@@ -117,6 +119,125 @@ func (db *Database) extractEntriesFromGroup(group *gokeepasslib.Group, parentPat
 	return entries
 }
 
+// newEntry converte una entry gokeepasslib nella Entry esposta dal package
+func newEntry(entry *gokeepasslib.Entry, groupPath string) Entry {
+	e := Entry{
+		UUID:            entry.UUID,
+		GroupPath:       groupPath,
+		Expires:         entry.Times.Expires.Bool,
+		UsageCount:      entry.Times.UsageCount,
+		PasswordChanged: passwordChangedAt(entry),
+		Modified:        modificationTime(entry),
+		Tags:            ParseTags(entry.Tags),
+		OTP:             entryOTP(entry),
+		Attachments:     entryAttachments(entry),
+	}
+
+	if entry.Times.ExpiryTime != nil {
+		e.ExpiryTime = entry.Times.ExpiryTime.Time
+	}
+	if entry.Times.LastAccessTime != nil {
+		e.LastAccessTime = entry.Times.LastAccessTime.Time
+	}
+
+	// Estrai i valori dai campi
+	for _, value := range entry.Values {
+		switch value.Key {
+		case "Title":
+			e.Title = value.Value.Content
+		case "UserName":
+			e.Username = value.Value.Content
+		case "Password":
+			e.Password = value.Value.Content
+		case "URL":
+			e.URL = value.Value.Content
+		case "Notes":
+			e.Notes = value.Value.Content
+		default:
+			if !isOTPField(value.Key) {
+				e.Fields = append(e.Fields, CustomField{
+					Key:       value.Key,
+					Value:     value.Value.Content,
+					Protected: value.Value.Protected.Bool,
+				})
+			}
+		}
+	}
+
+	return e
+}
+
+// passwordChangedAt ricava quando è stata impostata la password corrente:
+// risale la history finché le versioni hanno la stessa password e prende
+// la data di modifica della più vecchia. Se tutta la history ha la stessa
+// password usa la data di creazione; senza history, l'ultima modifica
+func passwordChangedAt(entry *gokeepasslib.Entry) time.Time {
+	password := entry.GetPassword()
+	changed := modificationTime(entry)
+
+	if len(entry.Histories) > 0 {
+		versions := entry.Histories[0].Entries
+		i := len(versions) - 1
+		for ; i >= 0 && versions[i].GetPassword() == password; i-- {
+			changed = modificationTime(&versions[i])
+		}
+		if i < 0 && entry.Times.CreationTime != nil {
+			changed = entry.Times.CreationTime.Time
+		}
+	}
+
+	return changed
+}
+
+// modificationTime ritorna l'ultima modifica della entry, o la creazione
+func modificationTime(entry *gokeepasslib.Entry) time.Time {
+	switch {
+	case entry.Times.LastModificationTime != nil:
+		return entry.Times.LastModificationTime.Time
+	case entry.Times.CreationTime != nil:
+		return entry.Times.CreationTime.Time
+	}
+	return time.Time{}
+}
+
+// IsExpired indica se la entry è scaduta all'istante indicato
+func (e Entry) IsExpired(now time.Time) bool {
+	return e.Expires && !e.ExpiryTime.IsZero() && !now.Before(e.ExpiryTime)
+}
+
+// ExpiresWithin indica se la entry non è ancora scaduta ma scadrà entro
+// window dall'istante indicato
+func (e Entry) ExpiresWithin(now time.Time, window time.Duration) bool {
+	return e.Expires && !e.ExpiryTime.IsZero() && !e.IsExpired(now) && e.ExpiryTime.Before(now.Add(window))
+}
+
+// ExpiringEntries ritorna le entries già scadute o in scadenza entro
+// window, dalla scadenza più vicina
+func ExpiringEntries(entries []Entry, now time.Time, window time.Duration) []Entry {
+	var expiring []Entry
+	for _, e := range entries {
+		if e.IsExpired(now) || e.ExpiresWithin(now, window) {
+			expiring = append(expiring, e)
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiryTime.Before(expiring[j].ExpiryTime)
+	})
+	return expiring
+}
+
+// joinGroupPath aggiunge il nome di un gruppo al path del padre
+func joinGroupPath(parentPath, name string) string {
+	if name == "" {
+		return parentPath
+	}
+	if parentPath == "" {
+		return name
+	}
+	return parentPath + PathSeparator + name
+}
+
 // GetAllGroups ottiene tutti i gruppi/categorie
 func (db *Database) GetAllGroups() []Group {
 	var groups []Group
@@ -132,42 +253,18 @@ func (db *Database) GetAllGroups() []Group {
 func (db *Database) extractGroups(group *gokeepasslib.Group, parentPath string) []Group {
 	var groups []Group
 
-	currentPath := parentPath
-	if group.Name != "" {
-		if currentPath != "" {
-			currentPath += " / " + group.Name
-		} else {
-			currentPath = group.Name
-		}
-	}
+	currentPath := joinGroupPath(parentPath, group.Name)
 
 	g := Group{
-		Name: group.Name,
-		Path: currentPath,
+		UUID:       group.UUID,
+		Name:       group.Name,
+		Path:       currentPath,
+		IsExpanded: group.IsExpanded.Bool,
 	}
 
 	// Estrai entries di questo gruppo
-	for _, entry := range group.Entries {
-		e := Entry{
-			GroupPath: currentPath,
-		}
-
-		for _, value := range entry.Values {
-			switch value.Key {
-			case "Title":
-				e.Title = value.Value.Content
-			case "UserName":
-				e.Username = value.Value.Content
-			case "Password":
-				e.Password = value.Value.Content
-			case "URL":
-				e.URL = value.Value.Content
-			case "Notes":
-				e.Notes = value.Value.Content
-			}
-		}
-
-		g.Entries = append(g.Entries, e)
+	for i := range group.Entries {
+		g.Entries = append(g.Entries, newEntry(&group.Entries[i], currentPath))
 	}
 
 	// Elabora sottogruppi
@@ -180,6 +277,39 @@ func (db *Database) extractGroups(group *gokeepasslib.Group, parentPath string)
 	return groups
 }
 
+// GetEntriesInGroup ottiene le entries di un gruppo, includendo
+// opzionalmente quelle dei sottogruppi
+func (db *Database) GetEntriesInGroup(groupPath string, recursive bool) []Entry {
+	var entries []Entry
+
+	group, parentPath := db.findGroup(groupPath)
+	if group == nil {
+		return entries
+	}
+
+	if recursive {
+		return db.extractEntriesFromGroup(group, parentPath)
+	}
+
+	currentPath := joinGroupPath(parentPath, group.Name)
+	for i := range group.Entries {
+		entries = append(entries, newEntry(&group.Entries[i], currentPath))
+	}
+
+	return entries
+}
+
+// CountEntries conta le entries del gruppo, opzionalmente con i sottogruppi
+func (g Group) CountEntries(recursive bool) int {
+	count := len(g.Entries)
+	if recursive {
+		for _, sub := range g.SubGroups {
+			count += sub.CountEntries(true)
+		}
+	}
+	return count
+}
+
 // Close chiude il database (lockando le entries protette)
 func (db *Database) Close() error {
 	if db.Database != nil {
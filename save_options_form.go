@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// Formati e cifrari nell'ordine in cui vengono proposti
+var (
+	saveFormats = []kdbx.KDBXFormat{kdbx.FormatKDBX4, kdbx.FormatKDBX31}
+	saveCiphers = []kdbx.CipherType{kdbx.CipherChaCha20, kdbx.CipherAES256}
+)
+
+// saveOptionsForm sono i campi con cui si scelgono formato, cifrario e
+// parametri della KDF di un nuovo database. I valori vengono controllati
+// da kdbx.CreateNewDatabase
+type saveOptionsForm struct {
+	formatSelect     *widget.Select
+	cipherSelect     *widget.Select
+	iterationsEntry  *widget.Entry
+	memoryEntry      *widget.Entry
+	parallelismEntry *widget.Entry
+	roundsEntry      *widget.Entry
+}
+
+// newSaveOptionsForm crea i campi con i valori di kdbx.DefaultSaveOptions
+func newSaveOptionsForm() *saveOptionsForm {
+	defaults := kdbx.DefaultSaveOptions("", "")
+
+	var formatLabels, cipherLabels []string
+	for _, format := range saveFormats {
+		formatLabels = append(formatLabels, kdbx.KDBXFormats[format])
+	}
+	for _, cipher := range saveCiphers {
+		cipherLabels = append(cipherLabels, kdbx.ModernCiphers[cipher])
+	}
+
+	f := &saveOptionsForm{
+		formatSelect:     widget.NewSelect(formatLabels, nil),
+		cipherSelect:     widget.NewSelect(cipherLabels, nil),
+		iterationsEntry:  numberEntry(int(defaults.KDFIterations), ""),
+		memoryEntry:      numberEntry(int(defaults.KDFMemory/1024), ""),
+		parallelismEntry: numberEntry(int(defaults.KDFParallelism), ""),
+		roundsEntry:      numberEntry(int(defaults.KDFRounds), ""),
+	}
+	f.cipherSelect.SetSelected(kdbx.ModernCiphers[defaults.CipherType])
+
+	// I KDBX 3.1 hanno solo AES-256 e AES-KDF
+	f.formatSelect.OnChanged = func(string) {
+		if saveFormats[f.formatSelect.SelectedIndex()] == kdbx.FormatKDBX31 {
+			f.cipherSelect.SetSelected(kdbx.ModernCiphers[kdbx.CipherAES256])
+			f.cipherSelect.Disable()
+			f.iterationsEntry.Disable()
+			f.memoryEntry.Disable()
+			f.parallelismEntry.Disable()
+			f.roundsEntry.Enable()
+			return
+		}
+		f.cipherSelect.Enable()
+		f.iterationsEntry.Enable()
+		f.memoryEntry.Enable()
+		f.parallelismEntry.Enable()
+		f.roundsEntry.Disable()
+	}
+	f.formatSelect.SetSelected(kdbx.KDBXFormats[defaults.Format])
+
+	return f
+}
+
+// items ritorna i campi da aggiungere a un form
+func (f *saveOptionsForm) items() []*widget.FormItem {
+	return []*widget.FormItem{
+		widget.NewFormItem("Formato", f.formatSelect),
+		widget.NewFormItem("Cifrario", f.cipherSelect),
+		widget.NewFormItem("Iterazioni Argon2", f.iterationsEntry),
+		widget.NewFormItem("Memoria Argon2 (MB)", f.memoryEntry),
+		widget.NewFormItem("Thread Argon2", f.parallelismEntry),
+		widget.NewFormItem("Round AES-KDF", f.roundsEntry),
+	}
+}
+
+// options ritorna le opzioni scelte per il file e la password dati
+func (f *saveOptionsForm) options(filePath, password string) kdbx.SaveOptions {
+	opts := kdbx.DefaultSaveOptions(filePath, password)
+	opts.Format = saveFormats[f.formatSelect.SelectedIndex()]
+	opts.CipherType = saveCiphers[f.cipherSelect.SelectedIndex()]
+	opts.KDFIterations = uint64(numberValue(f.iterationsEntry))
+	opts.KDFMemory = uint64(numberValue(f.memoryEntry)) * 1024
+	opts.KDFParallelism = uint32(numberValue(f.parallelismEntry))
+	opts.KDFRounds = uint64(numberValue(f.roundsEntry))
+	return opts
+}
+
+// summary descrive le opzioni scelte, per i messaggi di conferma
+func (f *saveOptionsForm) summary() string {
+	if saveFormats[f.formatSelect.SelectedIndex()] == kdbx.FormatKDBX31 {
+		return f.formatSelect.Selected + ", " + f.cipherSelect.Selected + " + AES-KDF"
+	}
+	return f.formatSelect.Selected + ", " + f.cipherSelect.Selected + " + Argon2d"
+}
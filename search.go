@@ -0,0 +1,319 @@
+package kdbx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Campi interrogabili nelle query di ricerca
+const (
+	searchFieldAll      = ""
+	searchFieldTitle    = "title"
+	searchFieldUsername = "username"
+	searchFieldPassword = "password"
+	searchFieldURL      = "url"
+	searchFieldNotes    = "notes"
+	searchFieldGroup    = "group"
+	searchFieldTag      = "tag"
+	searchFieldIs       = "is"
+)
+
+// searchFieldAliases mappa i prefissi accettati (stile KeePassXC) sui campi
+var searchFieldAliases = map[string]string{
+	"title":    searchFieldTitle,
+	"t":        searchFieldTitle,
+	"user":     searchFieldUsername,
+	"username": searchFieldUsername,
+	"u":        searchFieldUsername,
+	"pass":     searchFieldPassword,
+	"password": searchFieldPassword,
+	"pw":       searchFieldPassword,
+	"p":        searchFieldPassword,
+	"url":      searchFieldURL,
+	"notes":    searchFieldNotes,
+	"note":     searchFieldNotes,
+	"n":        searchFieldNotes,
+	"group":    searchFieldGroup,
+	"g":        searchFieldGroup,
+	"tag":      searchFieldTag,
+	"tags":     searchFieldTag,
+	"is":       searchFieldIs,
+}
+
+// searchTerm è un singolo termine di una query
+type searchTerm struct {
+	field  string
+	negate bool
+	text   string         // Testo normalizzato (ricerca per sottostringa)
+	regex  *regexp.Regexp // Espressione regolare (r: o wildcard *)
+
+	// wildcard indica che regex deriva da "*" e va applicata al testo
+	// normalizzato; le regex esplicite lavorano sul testo originale
+	wildcard bool
+}
+
+// SearchQuery è una query di ricerca già analizzata.
+// Tutti i termini devono essere soddisfatti (AND)
+type SearchQuery struct {
+	terms []searchTerm
+}
+
+// ParseSearchQuery analizza una query in sintassi stile KeePassXC:
+//
+//	user:bob url:github -expired "frase esatta" r:^git.*hub$ titl*
+//
+// Il prefisso "-" o "!" nega il termine, "campo:" limita la ricerca a un
+// campo, "r:" introduce un'espressione regolare e "*" è un carattere jolly.
+// Tra virgolette questi caratteri valgono come testo: "-a:b" cerca "-a:b".
+// "tag:lavoro" seleziona le entries con quel tag (tag intero, non parte).
+// "expired" (o "is:expired") seleziona le entries scadute; un "is:" ancora
+// incompleto viene ignorato. Il confronto ignora maiuscole e accenti
+func ParseSearchQuery(query string) (*SearchQuery, error) {
+	q := &SearchQuery{}
+
+	for _, token := range tokenizeQuery(query) {
+		term, err := parseSearchTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		if term != nil {
+			q.terms = append(q.terms, *term)
+		}
+	}
+
+	return q, nil
+}
+
+// IsEmpty indica se la query non contiene termini
+func (q *SearchQuery) IsEmpty() bool {
+	return len(q.terms) == 0
+}
+
+// Match verifica se una entry soddisfa tutti i termini della query
+func (q *SearchQuery) Match(e Entry) bool {
+	for _, term := range q.terms {
+		if term.match(e) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchEntries filtra le entries con una query di ricerca
+func SearchEntries(entries []Entry, query string) ([]Entry, error) {
+	q, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.IsEmpty() {
+		return entries, nil
+	}
+
+	var results []Entry
+	for _, e := range entries {
+		if q.Match(e) {
+			results = append(results, e)
+		}
+	}
+
+	return results, nil
+}
+
+// Search cerca tra tutte le entries del database
+func (db *Database) Search(query string) ([]Entry, error) {
+	return SearchEntries(db.GetAllEntries(), query)
+}
+
+// queryToken è un token della query prima dell'analisi
+type queryToken struct {
+	raw    string
+	quoted bool // Contiene una frase tra virgolette
+	plain  int  // Lunghezza della parte di raw prima delle virgolette
+}
+
+// tokenizeQuery divide la query sugli spazi, rispettando le virgolette
+func tokenizeQuery(query string) []queryToken {
+	var tokens []queryToken
+	var current strings.Builder
+	inQuotes, quoted := false, false
+	plain := 0
+
+	flush := func() {
+		if !quoted {
+			plain = current.Len()
+		}
+		if current.Len() > 0 || quoted {
+			tokens = append(tokens, queryToken{raw: current.String(), quoted: quoted, plain: plain})
+		}
+		current.Reset()
+		quoted = false
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			if !quoted {
+				plain = current.Len()
+			}
+			inQuotes = !inQuotes
+			quoted = true
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// parseSearchTerm converte un token in un termine di ricerca. Negazione,
+// campo e "r:" si riconoscono solo prima delle virgolette
+func parseSearchTerm(token queryToken) (*searchTerm, error) {
+	value := token.raw
+	plain := token.plain
+	term := &searchTerm{field: searchFieldAll}
+
+	if plain > 0 && len(value) > 1 && (value[0] == '-' || value[0] == '!') {
+		term.negate = true
+		value = value[1:]
+		plain--
+	}
+
+	if idx := strings.Index(value[:plain], ":"); idx > 0 {
+		if field, ok := searchFieldAliases[strings.ToLower(value[:idx])]; ok {
+			term.field = field
+			value = value[idx+1:]
+			plain -= idx + 1
+		}
+	}
+
+	// "expired" senza campo né virgolette è una scorciatoia per is:expired
+	if term.field == searchFieldAll && !token.quoted && strings.EqualFold(value, "expired") {
+		term.field = searchFieldIs
+	}
+
+	if term.field == searchFieldIs {
+		value = strings.ToLower(value)
+		switch {
+		case value == "expired":
+			term.text = value
+			return term, nil
+		case strings.HasPrefix("expired", value):
+			// Predicato ancora da completare mentre si scrive
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("predicato di ricerca sconosciuto: is:%s", value)
+		}
+	}
+
+	switch {
+	case plain >= 2 && strings.HasPrefix(value, "r:"):
+		re, err := regexp.Compile("(?i)" + value[2:])
+		if err != nil {
+			return nil, fmt.Errorf("espressione regolare non valida: %w", err)
+		}
+		term.regex = re
+	case strings.Contains(value, "*") && !token.quoted:
+		parts := strings.Split(normalizeSearchText(value), "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		pattern := strings.Join(parts, ".*")
+		// Un tag va indicato per intero anche con i caratteri jolly
+		if term.field == searchFieldTag {
+			pattern = "^" + pattern + "$"
+		}
+		term.regex = regexp.MustCompile(pattern)
+		term.wildcard = true
+	default:
+		if value == "" {
+			return nil, nil
+		}
+		term.text = normalizeSearchText(value)
+	}
+
+	return term, nil
+}
+
+// match verifica il termine (senza considerare la negazione)
+func (t searchTerm) match(e Entry) bool {
+	if t.field == searchFieldIs {
+		return e.IsExpired(time.Now())
+	}
+
+	for _, value := range t.fieldValues(e) {
+		if t.regex != nil {
+			if t.wildcard {
+				value = normalizeSearchText(value)
+			}
+			if t.regex.MatchString(value) {
+				return true
+			}
+			continue
+		}
+
+		// Un tag va indicato per intero: tag:lavoro non trova "telelavoro"
+		if t.field == searchFieldTag {
+			if normalizeSearchText(value) == t.text {
+				return true
+			}
+			continue
+		}
+
+		if strings.Contains(normalizeSearchText(value), t.text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldValues ritorna i valori della entry su cui applicare il termine.
+// La ricerca libera esclude la password come fa KeePassXC
+func (t searchTerm) fieldValues(e Entry) []string {
+	switch t.field {
+	case searchFieldTitle:
+		return []string{e.Title}
+	case searchFieldUsername:
+		return []string{e.Username}
+	case searchFieldPassword:
+		return []string{e.Password}
+	case searchFieldURL:
+		return []string{e.URL}
+	case searchFieldNotes:
+		return []string{e.Notes}
+	case searchFieldGroup:
+		return []string{e.GroupPath}
+	case searchFieldTag:
+		return e.Tags
+	default:
+		return append([]string{e.Title, e.Username, e.URL, e.Notes, e.GroupPath}, e.Tags...)
+	}
+}
+
+// normalizeSearchText porta il testo in minuscolo e senza accenti
+func normalizeSearchText(s string) string {
+	return strings.ToLower(removeAccents(s))
+}
+
+// removeAccents scompone i caratteri accentati e rimuove i segni diacritici
+func removeAccents(s string) string {
+	// La catena ha stato interno, quindi va creata ad ogni chiamata
+	accentRemover := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	result, _, err := transform.String(accentRemover, s)
+	if err != nil {
+		return s
+	}
+	return result
+}
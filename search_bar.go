@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// createSearchBar crea la barra di ricerca che filtra la lista mentre si scrive
+func (mw *MainWindow) createSearchBar() fyne.CanvasObject {
+	mw.searchEntry = mw.newShortcutEntry()
+	mw.searchEntry.PlaceHolder = "Cerca (es. user:bob url:github tag:lavoro -expired)"
+	mw.searchEntry.OnChanged = func(text string) {
+		mw.searchQuery = text
+		mw.refreshEntries()
+	}
+
+	mw.searchStatus = widget.NewLabel("")
+	mw.searchStatus.Wrapping = fyne.TextWrapWord
+	mw.searchStatus.Hide()
+
+	clearBtn := widget.NewButton("✕", func() {
+		mw.searchEntry.SetText("")
+	})
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, clearBtn, mw.searchEntry),
+		mw.searchStatus,
+	)
+}
+
+// applySearch filtra le entries con la query corrente, mostrando
+// l'eventuale errore di sintassi sotto la barra di ricerca
+func (mw *MainWindow) applySearch(entries []kdbx.Entry) []kdbx.Entry {
+	if mw.searchQuery == "" {
+		mw.searchStatus.Hide()
+		return entries
+	}
+
+	results, err := kdbx.SearchEntries(entries, mw.searchQuery)
+	if err != nil {
+		mw.searchStatus.SetText(fmt.Sprintf("Query non valida: %v", err))
+		mw.searchStatus.Show()
+		return []kdbx.Entry{}
+	}
+
+	mw.searchStatus.SetText(fmt.Sprintf("%d risultati", len(results)))
+	mw.searchStatus.Show()
+	return results
+}
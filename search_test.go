@@ -0,0 +1,128 @@
+package kdbx
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	// term descrive un termine atteso; regex è il pattern compilato
+	type term struct {
+		field  string
+		negate bool
+		text   string
+		regex  string
+	}
+
+	tests := []struct {
+		query string
+		terms []term
+	}{
+		{"", nil},
+		{"github", []term{{field: searchFieldAll, text: "github"}}},
+		{"Città", []term{{field: searchFieldAll, text: "citta"}}},
+		{"user:Bob url:git", []term{
+			{field: searchFieldUsername, text: "bob"},
+			{field: searchFieldURL, text: "git"},
+		}},
+		{"-t:banca !n:vecchio", []term{
+			{field: searchFieldTitle, negate: true, text: "banca"},
+			{field: searchFieldNotes, negate: true, text: "vecchio"},
+		}},
+		{"sconosciuto:valore", []term{{field: searchFieldAll, text: "sconosciuto:valore"}}},
+		{"-", []term{{field: searchFieldAll, text: "-"}}},
+		{"user:", nil},
+
+		// Tra virgolette negazione, campo, "r:" e "*" sono testo
+		{`"frase esatta"`, []term{{field: searchFieldAll, text: "frase esatta"}}},
+		{`"-bozza"`, []term{{field: searchFieldAll, text: "-bozza"}}},
+		{`"!importante"`, []term{{field: searchFieldAll, text: "!importante"}}},
+		{`"user:bob"`, []term{{field: searchFieldAll, text: "user:bob"}}},
+		{`"r:^a"`, []term{{field: searchFieldAll, text: "r:^a"}}},
+		{`"a*b"`, []term{{field: searchFieldAll, text: "a*b"}}},
+		{`"expired"`, []term{{field: searchFieldAll, text: "expired"}}},
+		// Prefissi prima delle virgolette
+		{`user:"Bob Rossi"`, []term{{field: searchFieldUsername, text: "bob rossi"}}},
+		{`-"in prova"`, []term{{field: searchFieldAll, negate: true, text: "in prova"}}},
+		{`!notes:"da fare"`, []term{{field: searchFieldNotes, negate: true, text: "da fare"}}},
+
+		// Espressioni regolari e caratteri jolly
+		{"r:^git.*hub$", []term{{field: searchFieldAll, regex: "(?i)^git.*hub$"}}},
+		{"url:r:\\.it$", []term{{field: searchFieldURL, regex: "(?i)\\.it$"}}},
+		{"titl*", []term{{field: searchFieldAll, regex: "titl.*"}}},
+		{"url:*.example.*", []term{{field: searchFieldURL, regex: ".*\\.example\\..*"}}},
+		{"tag:lav*", []term{{field: searchFieldTag, regex: "^lav.*$"}}},
+
+		// Scadute
+		{"expired", []term{{field: searchFieldIs, text: "expired"}}},
+		{"-is:Expired", []term{{field: searchFieldIs, negate: true, text: "expired"}}},
+		{"is:", nil},
+		{"is:exp github", []term{{field: searchFieldAll, text: "github"}}},
+	}
+
+	for _, tt := range tests {
+		q, err := ParseSearchQuery(tt.query)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		var got []term
+		for _, parsed := range q.terms {
+			found := term{field: parsed.field, negate: parsed.negate, text: parsed.text}
+			if parsed.regex != nil {
+				found.regex = parsed.regex.String()
+			}
+			got = append(got, found)
+		}
+		if !reflect.DeepEqual(got, tt.terms) {
+			t.Errorf("%q:\nletto  %+v\natteso %+v", tt.query, got, tt.terms)
+		}
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	for _, query := range []string{"is:scaduta", "r:(", "url:r:[a-"} {
+		if _, err := ParseSearchQuery(query); err == nil {
+			t.Errorf("%q: nessun errore", query)
+		}
+	}
+}
+
+func TestSearchEntries(t *testing.T) {
+	entries := []Entry{
+		{Title: "Banca", Username: "mario", URL: "https://banca.example.it", Tags: []string{"lavoro", "finanza"}},
+		{Title: "Posta", Username: "mario", Notes: "-bozza", Tags: []string{"telelavoro"}},
+		{Title: "Vecchio", Username: "luigi", Expires: true, ExpiryTime: time.Now().Add(-time.Hour)},
+	}
+
+	tests := []struct {
+		query  string
+		titles []string
+	}{
+		{"mario", []string{"Banca", "Posta"}},
+		{"-bozza", []string{"Banca", "Vecchio"}},
+		{`"-bozza"`, []string{"Posta"}},
+		{"tag:lavoro", []string{"Banca"}},
+		// Il jolly non trasforma il tag in una ricerca per sottostringa
+		{"tag:lav*", []string{"Banca"}},
+		{"tag:*lavoro", []string{"Banca", "Posta"}},
+		{"expired", []string{"Vecchio"}},
+		{"is:", []string{"Banca", "Posta", "Vecchio"}},
+	}
+
+	for _, tt := range tests {
+		results, err := SearchEntries(entries, tt.query)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		var titles []string
+		for _, e := range results {
+			titles = append(titles, e.Title)
+		}
+		if !reflect.DeepEqual(titles, tt.titles) {
+			t.Errorf("%q: trovate %v, attese %v", tt.query, titles, tt.titles)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// showSelectionExport esporta i gruppi o i tag scelti in un nuovo database
+// cifrato con una sua password master, ad esempio per condividere alcune
+// credenziali. Il database aperto non viene modificato
+func (mw *MainWindow) showSelectionExport() {
+	const title = "Esporta selezione"
+	if mw.Database == nil {
+		dialog.ShowError(fmt.Errorf("Nessun database aperto"), mw.Window)
+		return
+	}
+
+	var sel kdbx.ExportOptions
+
+	groups := container.NewVBox()
+	for _, path := range mw.groupPaths() {
+		path := path
+		groups.Add(widget.NewCheck(path, func(checked bool) {
+			sel.Groups = toggleString(sel.Groups, path, checked)
+		}))
+	}
+
+	tags := container.NewVBox()
+	for _, tag := range mw.Database.AllTags() {
+		tag := tag.Tag
+		tags.Add(widget.NewCheck(tag, func(checked bool) {
+			sel.Tags = toggleString(sel.Tags, tag, checked)
+		}))
+	}
+	if len(tags.Objects) == 0 {
+		tags.Add(widget.NewLabel("Nessun tag nel database"))
+	}
+
+	historyCheck := widget.NewCheck("Escludi la history delle entries", func(checked bool) {
+		sel.StripHistory = checked
+	})
+	attachmentsCheck := widget.NewCheck("Escludi gli allegati", func(checked bool) {
+		sel.StripAttachments = checked
+	})
+
+	content := container.NewBorder(
+		widget.NewLabel("Vengono esportate le entries dei gruppi scelti (con i sottogruppi)\ne quelle con almeno uno dei tag scelti."),
+		container.NewVBox(widget.NewSeparator(), historyCheck, attachmentsCheck),
+		nil,
+		nil,
+		container.NewGridWithColumns(2,
+			container.NewBorder(widget.NewLabelWithStyle("Gruppi", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), nil, nil, nil,
+				container.NewVScroll(groups)),
+			container.NewBorder(widget.NewLabelWithStyle("Tag", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), nil, nil, nil,
+				container.NewVScroll(tags)),
+		),
+	)
+
+	d := dialog.NewCustomConfirm(title, "Avanti", "Annulla", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if len(sel.Groups) == 0 && len(sel.Tags) == 0 {
+			dialog.ShowInformation(title, "Seleziona almeno un gruppo o un tag", mw.Window)
+			return
+		}
+
+		// Password e opzioni prima del file: annullando non resta un file vuoto
+		saveForm := newSaveOptionsForm()
+		mw.promptNewPassword("Nuovo database", saveForm.items(), func(password string) {
+			// Il dialog svuota il file scelto prima di restituirlo: se è il
+			// database aperto va riscritto com'era
+			source := mw.Database.FilePath
+			original, readErr := os.ReadFile(source)
+
+			save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+				if err != nil || writer == nil {
+					return
+				}
+				filePath := writer.URI().Path()
+				writer.Close()
+
+				if sameFilePath(filePath, source) {
+					if readErr == nil {
+						readErr = os.WriteFile(source, original, 0o600)
+					}
+					if readErr != nil {
+						dialog.ShowError(fmt.Errorf("il file %s è stato svuotato, salva il database per riscriverlo: %w", source, readErr), mw.Window)
+						return
+					}
+					dialog.ShowError(fmt.Errorf("non si può esportare nel database aperto: scegli un altro file"), mw.Window)
+					return
+				}
+
+				result, err := mw.Database.ExportSelection(sel, saveForm.options(filePath, password))
+				if err != nil {
+					// Il file creato dal dialog è vuoto o incompleto
+					os.Remove(filePath)
+					dialog.ShowError(err, mw.Window)
+					return
+				}
+				dialog.ShowInformation(title,
+					fmt.Sprintf("%d entries in %d gruppi e %d allegati esportati in %s.\nCifratura: %s",
+						result.Entries, result.Groups, result.Attachments, filePath, saveForm.summary()),
+					mw.Window)
+			}, mw.Window)
+			save.SetFileName("condivisione.kdbx")
+			save.Show()
+		})
+	}, mw.Window)
+	d.Resize(fyne.NewSize(640, 520))
+	d.Show()
+}
+
+// sameFilePath indica se due percorsi indicano lo stesso file, confrontando
+// i percorsi assoluti
+func sameFilePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// promptNewPassword chiede una nuova password due volte, per evitare
+// errori di battitura, insieme agli eventuali campi extra
+func (mw *MainWindow) promptNewPassword(title string, extra []*widget.FormItem, callback func(string)) {
+	passwordEntry := widget.NewPasswordEntry()
+	confirmEntry := widget.NewPasswordEntry()
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Password master", passwordEntry),
+		widget.NewFormItem("Conferma", confirmEntry),
+	}
+	dialog.ShowForm(title, "OK", "Annulla",
+		append(items, extra...),
+		func(ok bool) {
+			if !ok || passwordEntry.Text == "" {
+				return
+			}
+			if passwordEntry.Text != confirmEntry.Text {
+				dialog.ShowError(fmt.Errorf("Le password non coincidono"), mw.Window)
+				return
+			}
+			callback(passwordEntry.Text)
+		},
+		mw.Window,
+	)
+}
+
+// toggleString aggiunge o toglie value da values
+func toggleString(values []string, value string, add bool) []string {
+	for i, v := range values {
+		if v == value {
+			if add {
+				return values
+			}
+			return append(values[:i], values[i+1:]...)
+		}
+	}
+	if add {
+		values = append(values, value)
+	}
+	return values
+}
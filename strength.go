@@ -0,0 +1,926 @@
+package kdbx
+
+import (
+	_ "embed"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+//go:embed wordlists/common_passwords.txt
+var commonPasswordsList string
+
+// StrengthScore è la fascia di robustezza di una password (0-4)
+type StrengthScore int
+
+const (
+	ScoreVeryWeak   StrengthScore = iota // Indovinabile in pochi tentativi
+	ScoreWeak                            // Protegge solo da attacchi online limitati
+	ScoreFair                            // Protegge da attacchi online
+	ScoreStrong                          // Protegge da attacchi offline lenti
+	ScoreVeryStrong                      // Protegge da attacchi offline veloci
+)
+
+// String ritorna la descrizione della fascia
+func (s StrengthScore) String() string {
+	switch s {
+	case ScoreVeryWeak:
+		return "Molto debole"
+	case ScoreWeak:
+		return "Debole"
+	case ScoreFair:
+		return "Discreta"
+	case ScoreStrong:
+		return "Buona"
+	default:
+		return "Ottima"
+	}
+}
+
+// Tipi di pattern riconosciuti dallo stimatore
+const (
+	PatternDictionary = "dizionario"
+	PatternKeyboard   = "tastiera"
+	PatternDate       = "data"
+	PatternRepeat     = "ripetizione"
+	PatternSequence   = "sequenza"
+	PatternBruteforce = "casuale"
+)
+
+// PasswordPattern è una porzione della password riconosciuta da un pattern
+type PasswordPattern struct {
+	Kind    string
+	Token   string
+	Start   int // Indice (in rune) del primo carattere
+	End     int // Indice (in rune) dopo l'ultimo carattere
+	Guesses float64
+	Detail  string // Es. la parola trovata o "l33t"
+}
+
+// PasswordStrength è il risultato della stima di robustezza
+type PasswordStrength struct {
+	Guesses  float64 // Tentativi stimati (al massimo math.MaxFloat64)
+	Entropy  float64 // log2 dei tentativi, in bit (calcolato senza overflow)
+	Score    StrengthScore
+	Patterns []PasswordPattern // Scomposizione usata per la stima
+	Warnings []string
+}
+
+// Parametri dello stimatore
+const (
+	strengthMaxLength       = 100 // Oltre questa lunghezza si usa solo la forza bruta
+	minDictionaryLength     = 3
+	minSubmatchGuesses      = 50.0
+	minSingleCharGuesses    = 10.0
+	keyboardStartPositions  = 94.0
+	keyboardAverageDegree   = 4.6
+	referenceYearDistance   = 20
+	dateSeparatorMultiplier = 4.0
+)
+
+// Soglie di tentativi per le fasce di robustezza, in bit (log2 di 1e3,
+// 1e6, 1e8 e 1e10): il confronto avviene sull'entropia, che non va in
+// overflow anche con password lunghissime
+var scoreThresholds = []float64{
+	3 * math.Log2(10),
+	6 * math.Log2(10),
+	8 * math.Log2(10),
+	10 * math.Log2(10),
+}
+
+// EstimateStrength stima quanto è difficile indovinare una password
+// qualsiasi, riconoscendo parole comuni (anche in l33t o al contrario),
+// sequenze di tastiera, date, ripetizioni e sequenze di caratteri
+func EstimateStrength(password string) PasswordStrength {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return PasswordStrength{Guesses: 1, Score: ScoreVeryWeak, Warnings: []string{"Nessuna password"}}
+	}
+
+	var matches []PasswordPattern
+	if len(runes) <= strengthMaxLength {
+		matches = findPatterns(runes)
+	}
+
+	patterns, entropy := mostGuessableSequence(runes, matches)
+
+	strength := PasswordStrength{
+		Guesses:  guessesFromBits(entropy),
+		Entropy:  entropy,
+		Patterns: patterns,
+	}
+
+	for i, threshold := range scoreThresholds {
+		if entropy < threshold {
+			strength.Score = StrengthScore(i)
+			break
+		}
+		strength.Score = ScoreVeryStrong
+	}
+
+	strength.Warnings = strengthWarnings(runes, patterns)
+	return strength
+}
+
+// findPatterns raccoglie tutti i pattern trovati nella password
+func findPatterns(runes []rune) []PasswordPattern {
+	var matches []PasswordPattern
+	matches = append(matches, dictionaryMatches(runes)...)
+	matches = append(matches, keyboardMatches(runes)...)
+	matches = append(matches, dateMatches(runes)...)
+	matches = append(matches, repeatMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	return matches
+}
+
+// mostGuessableSequence sceglie la scomposizione con meno tentativi totali
+// e ritorna il log2 dei tentativi. I caratteri non coperti da pattern sono
+// stimati a forza bruta
+func mostGuessableSequence(runes []rune, matches []PasswordPattern) ([]PasswordPattern, float64) {
+	n := len(runes)
+	cardinality := bruteforceCardinality(runes)
+	charCost := math.Log2(cardinality)
+
+	// cost[i] = log2 dei tentativi migliori per i primi i caratteri
+	cost := make([]float64, n+1)
+	prev := make([]int, n+1) // Indice del pattern usato (-1 = forza bruta)
+	segments := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		cost[i] = math.Inf(1)
+	}
+
+	byEnd := map[int][]int{}
+	for i, m := range matches {
+		byEnd[m.End] = append(byEnd[m.End], i)
+	}
+
+	for i := 1; i <= n; i++ {
+		// Estendi un tratto a forza bruta
+		bruteCost := cost[i-1] + charCost
+		bruteSegments := segments[i-1]
+		if i == 1 || prev[i-1] != -1 {
+			bruteSegments++
+		}
+		cost[i], prev[i], segments[i] = bruteCost, -1, bruteSegments
+
+		for _, idx := range byEnd[i] {
+			m := matches[idx]
+			minGuesses := minSubmatchGuesses
+			if m.End-m.Start == 1 {
+				minGuesses = minSingleCharGuesses
+			}
+			c := cost[m.Start] + math.Log2(math.Max(m.Guesses, minGuesses))
+			if c < cost[i] {
+				cost[i], prev[i], segments[i] = c, idx, segments[m.Start]+1
+			}
+		}
+	}
+
+	// Ricostruisci la scomposizione scelta
+	var patterns []PasswordPattern
+	for i := n; i > 0; {
+		if prev[i] >= 0 {
+			m := matches[prev[i]]
+			patterns = append(patterns, m)
+			i = m.Start
+			continue
+		}
+
+		end := i
+		for i > 0 && prev[i] == -1 {
+			i--
+		}
+		token := runes[i:end]
+		patterns = append(patterns, PasswordPattern{
+			Kind:    PatternBruteforce,
+			Token:   string(token),
+			Start:   i,
+			End:     end,
+			Guesses: guessesFromBits(charCost * float64(len(token))),
+		})
+	}
+
+	for l, r := 0, len(patterns)-1; l < r; l, r = l+1, r-1 {
+		patterns[l], patterns[r] = patterns[r], patterns[l]
+	}
+
+	// Più pattern ci sono, più combinazioni deve provare l'attaccante
+	total := cost[n] + log2Factorial(segments[n])
+	return patterns, math.Max(0, total)
+}
+
+// guessesFromBits converte un numero di bit in tentativi, limitandoli al
+// massimo rappresentabile invece di andare a +Inf
+func guessesFromBits(bits float64) float64 {
+	return math.Min(math.Pow(2, bits), math.MaxFloat64)
+}
+
+// bruteforceCardinality stima la dimensione dell'alfabeto della password
+func bruteforceCardinality(runes []rune) float64 {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	cardinality := 0.0
+	if lower {
+		cardinality += 26
+	}
+	if upper {
+		cardinality += 26
+	}
+	if digit {
+		cardinality += 10
+	}
+	if symbol {
+		cardinality += 33
+	}
+	if other {
+		cardinality += 100
+	}
+	return math.Max(cardinality, minSingleCharGuesses)
+}
+
+// log2Factorial calcola log2(n!)
+func log2Factorial(n int) float64 {
+	lg, _ := math.Lgamma(float64(n + 1))
+	return lg / math.Ln2
+}
+
+// binomial calcola il coefficiente binomiale C(n, k)
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+// ---- Dizionario ----
+
+var (
+	dictionaryOnce  sync.Once
+	dictionaryRanks map[string]int
+)
+
+// loadDictionary carica le liste di parole incorporate (rango = posizione)
+func loadDictionary() map[string]int {
+	dictionaryOnce.Do(func() {
+		dictionaryRanks = map[string]int{}
+		addWordList(dictionaryRanks, commonPasswordsList)
+
+		// Le parole diceware sono equiprobabili: ognuna costa quanto la lista
+		eff := EFFLargeWordlist().Words
+		for _, word := range eff {
+			if _, exists := dictionaryRanks[word]; !exists {
+				dictionaryRanks[word] = len(eff)
+			}
+		}
+	})
+	return dictionaryRanks
+}
+
+// addWordList aggiunge una lista (una parola per riga, # per i commenti)
+// al dizionario mantenendo il rango migliore
+func addWordList(ranks map[string]int, list string) {
+	rank := 0
+	for _, line := range strings.Split(list, "\n") {
+		word := strings.ToLower(strings.TrimSpace(line))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		rank++
+		if _, exists := ranks[word]; !exists {
+			ranks[word] = rank
+		}
+	}
+}
+
+// l33tTable mappa le sostituzioni l33t sulle lettere originali
+var l33tTable = map[rune][]rune{
+	'4': {'a'}, '@': {'a'},
+	'8': {'b'},
+	'(': {'c'}, '{': {'c'}, '[': {'c'}, '<': {'c'},
+	'3': {'e'},
+	'6': {'g'}, '9': {'g'},
+	'1': {'i', 'l'}, '!': {'i'}, '|': {'i', 'l'},
+	'0': {'o'},
+	'$': {'s'}, '5': {'s'},
+	'+': {'t'}, '7': {'t'},
+	'%': {'x'},
+	'2': {'z'},
+}
+
+// dictionaryMatches cerca parole note, anche al contrario o in l33t
+func dictionaryMatches(runes []rune) []PasswordPattern {
+	dict := loadDictionary()
+	var matches []PasswordPattern
+	n := len(runes)
+
+	for i := 0; i < n; i++ {
+		for j := i + minDictionaryLength; j <= n; j++ {
+			token := runes[i:j]
+			lower := []rune(strings.ToLower(string(token)))
+
+			if rank, ok := dict[string(lower)]; ok {
+				matches = append(matches, PasswordPattern{
+					Kind:    PatternDictionary,
+					Token:   string(token),
+					Start:   i,
+					End:     j,
+					Guesses: float64(rank) * uppercaseVariations(token),
+					Detail:  string(lower),
+				})
+			}
+
+			if rank, ok := dict[reverseString(string(lower))]; ok && len(lower) > minDictionaryLength {
+				matches = append(matches, PasswordPattern{
+					Kind:    PatternDictionary,
+					Token:   string(token),
+					Start:   i,
+					End:     j,
+					Guesses: float64(rank) * uppercaseVariations(token) * 2,
+					Detail:  reverseString(string(lower)) + " (al contrario)",
+				})
+			}
+
+			for _, candidate := range unl33t(lower) {
+				if rank, ok := dict[candidate.word]; ok {
+					matches = append(matches, PasswordPattern{
+						Kind:    PatternDictionary,
+						Token:   string(token),
+						Start:   i,
+						End:     j,
+						Guesses: float64(rank) * uppercaseVariations(token) * l33tVariations(candidate.substitutions),
+						Detail:  candidate.word + " (l33t)",
+					})
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+// l33tCandidate è una possibile traduzione di un token l33t
+type l33tCandidate struct {
+	word          string
+	substitutions int
+}
+
+// unl33t genera le traduzioni di un token con sostituzioni l33t
+func unl33t(token []rune) []l33tCandidate {
+	candidates := []l33tCandidate{{}}
+	found := false
+
+	for _, r := range token {
+		subs, ok := l33tTable[r]
+		if !ok {
+			for i := range candidates {
+				candidates[i].word += string(r)
+			}
+			continue
+		}
+
+		found = true
+		var next []l33tCandidate
+		for _, c := range candidates {
+			for _, sub := range subs {
+				next = append(next, l33tCandidate{c.word + string(sub), c.substitutions + 1})
+			}
+		}
+		candidates = next
+
+		// Limita l'esplosione combinatoria su token lunghi
+		if len(candidates) > 16 {
+			candidates = candidates[:16]
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return candidates
+}
+
+// uppercaseVariations conta i modi di mettere maiuscole nel token
+func uppercaseVariations(token []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	if upper == 0 {
+		return 1
+	}
+
+	// Prima lettera, ultima lettera o tutto maiuscolo sono i casi più comuni
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1]))) {
+		return 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+// l33tVariations stima il costo aggiuntivo delle sostituzioni l33t
+func l33tVariations(substitutions int) float64 {
+	return math.Max(2, math.Pow(2, float64(substitutions)))
+}
+
+// reverseString inverte una stringa
+func reverseString(s string) string {
+	runes := []rune(s)
+	for l, r := 0, len(runes)-1; l < r; l, r = l+1, r-1 {
+		runes[l], runes[r] = runes[r], runes[l]
+	}
+	return string(runes)
+}
+
+// ---- Tastiera ----
+
+// qwertyRows descrive la tastiera QWERTY (carattere normale e con Shift)
+var qwertyRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+var qwertyShiftedRows = []string{
+	"~!@#$%^&*()_+",
+	"QWERTYUIOP{}|",
+	"ASDFGHJKL:\"",
+	"ZXCVBNM<>?",
+}
+
+// keyPosition è la posizione di un tasto sulla tastiera
+type keyPosition struct {
+	row, col int
+	shifted  bool
+}
+
+var (
+	keyboardOnce      sync.Once
+	keyboardPositions map[rune]keyPosition
+)
+
+// loadKeyboard costruisce la mappa carattere -> posizione del tasto
+func loadKeyboard() map[rune]keyPosition {
+	keyboardOnce.Do(func() {
+		keyboardPositions = map[rune]keyPosition{}
+		for row, keys := range qwertyRows {
+			for col, r := range []rune(keys) {
+				keyboardPositions[r] = keyPosition{row: row, col: col}
+			}
+		}
+		for row, keys := range qwertyShiftedRows {
+			for col, r := range []rune(keys) {
+				keyboardPositions[r] = keyPosition{row: row, col: col, shifted: true}
+			}
+		}
+	})
+	return keyboardPositions
+}
+
+// keyboardDirection ritorna la direzione tra due tasti adiacenti, o -1.
+// Le righe della tastiera sono sfalsate di mezzo tasto verso destra
+func keyboardDirection(a, b keyPosition) int {
+	dr, dc := b.row-a.row, b.col-a.col
+	switch {
+	case dr == 0 && dc == -1:
+		return 0
+	case dr == 0 && dc == 1:
+		return 1
+	case dr == -1 && (dc == 0 || dc == 1):
+		return 2 + dc
+	case dr == 1 && (dc == 0 || dc == -1):
+		return 4 - dc
+	default:
+		return -1
+	}
+}
+
+// keyboardMatches cerca sequenze di tasti adiacenti (es. "qwerty", "zaq1")
+func keyboardMatches(runes []rune) []PasswordPattern {
+	positions := loadKeyboard()
+	var matches []PasswordPattern
+
+	i := 0
+	for i < len(runes)-2 {
+		j := i
+		turns, shifted := 0, 0
+		lastDirection := -1
+
+		if pos, ok := positions[runes[i]]; ok && pos.shifted {
+			shifted++
+		}
+
+		for j+1 < len(runes) {
+			a, okA := positions[runes[j]]
+			b, okB := positions[runes[j+1]]
+			if !okA || !okB {
+				break
+			}
+			direction := keyboardDirection(a, b)
+			if direction < 0 {
+				break
+			}
+			if direction != lastDirection {
+				turns++
+				lastDirection = direction
+			}
+			if b.shifted {
+				shifted++
+			}
+			j++
+		}
+
+		length := j - i + 1
+		if length >= 3 {
+			matches = append(matches, PasswordPattern{
+				Kind:    PatternKeyboard,
+				Token:   string(runes[i : j+1]),
+				Start:   i,
+				End:     j + 1,
+				Guesses: keyboardGuesses(length, turns, shifted),
+			})
+			i = j
+			continue
+		}
+		i++
+	}
+
+	return matches
+}
+
+// keyboardGuesses stima i tentativi per una sequenza di tastiera
+func keyboardGuesses(length, turns, shifted int) float64 {
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= min(turns, i-1); j++ {
+			guesses += binomial(i-1, j-1) * keyboardStartPositions * math.Pow(keyboardAverageDegree, float64(j))
+		}
+	}
+
+	if shifted > 0 {
+		unshifted := length - shifted
+		if unshifted == 0 {
+			guesses *= 2
+		} else {
+			variations := 0.0
+			for i := 1; i <= min(shifted, unshifted); i++ {
+				variations += binomial(shifted+unshifted, i)
+			}
+			guesses *= variations
+		}
+	}
+
+	return guesses
+}
+
+// ---- Date ----
+
+var dateWithSeparator = regexp.MustCompile(`^(\d{1,4})([\s/\\_.-])(\d{1,2})([\s/\\_.-])(\d{1,4})$`)
+
+// dateMatches cerca date (con o senza separatori) e anni
+func dateMatches(runes []rune) []PasswordPattern {
+	var matches []PasswordPattern
+	n := len(runes)
+	currentYear := time.Now().Year()
+
+	for i := 0; i < n; i++ {
+		for j := i + 4; j <= min(n, i+10); j++ {
+			token := string(runes[i:j])
+
+			var year int
+			var ok, separated bool
+			if m := dateWithSeparator.FindStringSubmatch(token); m != nil && m[2] == m[4] {
+				year, ok = parseDateParts(m[1], m[3], m[5])
+				separated = true
+			} else if isAllDigits(token) {
+				year, ok = parseDigitDate(token)
+			}
+
+			if !ok {
+				continue
+			}
+
+			distance := max(abs(year-currentYear), referenceYearDistance)
+			guesses := float64(distance)
+			if len(token) > 4 {
+				guesses *= 365
+			}
+			if separated {
+				guesses *= dateSeparatorMultiplier
+			}
+
+			matches = append(matches, PasswordPattern{
+				Kind:    PatternDate,
+				Token:   token,
+				Start:   i,
+				End:     j,
+				Guesses: guesses,
+				Detail:  strconv.Itoa(year),
+			})
+		}
+	}
+
+	return matches
+}
+
+// parseDigitDate riconosce anni (AAAA) e date senza separatori
+// (GGMM, GGMMAA, AAMMGG, GGMMAAAA, AAAAMMGG, MMGGAAAA)
+func parseDigitDate(token string) (int, bool) {
+	switch len(token) {
+	case 4:
+		year, _ := strconv.Atoi(token)
+		return year, isPlausibleYear(year)
+	case 6:
+		return parseDateParts(token[:2], token[2:4], token[4:])
+	case 8:
+		if year, ok := parseDateParts(token[:2], token[2:4], token[4:]); ok {
+			return year, true
+		}
+		return parseDateParts(token[:4], token[4:6], token[6:])
+	default:
+		return 0, false
+	}
+}
+
+// parseDateParts interpreta tre parti come giorno/mese/anno in qualsiasi
+// ordine plausibile e ritorna l'anno
+func parseDateParts(a, b, c string) (int, bool) {
+	parts := []string{a, b, c}
+	values := make([]int, 3)
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, false
+		}
+		values[i] = v
+	}
+
+	// L'anno è la prima o l'ultima parte
+	for _, yearIndex := range []int{2, 0} {
+		year := values[yearIndex]
+		if len(parts[yearIndex]) == 2 {
+			year = expandTwoDigitYear(year)
+		} else if len(parts[yearIndex]) != 4 {
+			continue
+		}
+		if !isPlausibleYear(year) {
+			continue
+		}
+
+		x, y := values[1], values[2]
+		if yearIndex == 2 {
+			x, y = values[0], values[1]
+		}
+		if isDayMonth(x, y) || isDayMonth(y, x) {
+			return year, true
+		}
+	}
+
+	return 0, false
+}
+
+// isDayMonth verifica che day e month siano un giorno e un mese validi
+func isDayMonth(day, month int) bool {
+	return day >= 1 && day <= 31 && month >= 1 && month <= 12
+}
+
+// isPlausibleYear limita gli anni a quelli usati di solito nelle password
+func isPlausibleYear(year int) bool {
+	return year >= 1900 && year <= 2099
+}
+
+// expandTwoDigitYear converte un anno a due cifre (50-99 -> 19xx)
+func expandTwoDigitYear(year int) int {
+	if year >= 50 {
+		return 1900 + year
+	}
+	return 2000 + year
+}
+
+// isAllDigits verifica che la stringa contenga solo cifre ASCII
+func isAllDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// ---- Ripetizioni ----
+
+// repeatMatches cerca caratteri o blocchi ripetuti (es. "aaa", "abcabc").
+// Come in zxcvbn per ogni posizione si tiene solo la ripetizione più
+// lunga, con il blocco più corto, e la ricerca riprende dalla sua fine.
+// Il blocco viene stimato una sola volta anche se compare più volte
+func repeatMatches(runes []rune) []PasswordPattern {
+	var matches []PasswordPattern
+	n := len(runes)
+	blockGuesses := map[string]float64{}
+
+	i := 0
+	for i < n-1 {
+		bestLen, bestCount := 0, 0
+		for blockLen := 1; i+blockLen*2 <= n; blockLen++ {
+			count := 1
+			for i+(count+1)*blockLen <= n && sameRunes(runes[i:i+blockLen], runes[i+count*blockLen:i+(count+1)*blockLen]) {
+				count++
+			}
+			if count < 2 || (blockLen == 1 && count < 3) {
+				continue
+			}
+			if count*blockLen > bestLen*bestCount {
+				bestLen, bestCount = blockLen, count
+			}
+		}
+
+		if bestCount == 0 {
+			i++
+			continue
+		}
+
+		block := string(runes[i : i+bestLen])
+		baseGuesses, ok := blockGuesses[block]
+		if !ok {
+			if bestLen == 1 {
+				baseGuesses = bruteforceCardinality(runes[i : i+1])
+			} else {
+				baseGuesses = EstimateStrength(block).Guesses
+			}
+			blockGuesses[block] = baseGuesses
+		}
+
+		end := i + bestCount*bestLen
+		matches = append(matches, PasswordPattern{
+			Kind:    PatternRepeat,
+			Token:   string(runes[i:end]),
+			Start:   i,
+			End:     end,
+			Guesses: baseGuesses * float64(bestCount),
+			Detail:  block,
+		})
+		i = end
+	}
+
+	return matches
+}
+
+// sameRunes verifica che due blocchi contengano gli stessi caratteri
+func sameRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ---- Sequenze ----
+
+// sequenceMatches cerca sequenze come "abc", "123", "zyx"
+func sequenceMatches(runes []rune) []PasswordPattern {
+	var matches []PasswordPattern
+	n := len(runes)
+
+	i := 0
+	for i < n-2 {
+		delta := int(runes[i+1]) - int(runes[i])
+		if delta != 1 && delta != -1 || !sameCharClass(runes[i], runes[i+1]) {
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j+1 < n && int(runes[j+1])-int(runes[j]) == delta && sameCharClass(runes[j], runes[j+1]) {
+			j++
+		}
+
+		if length := j - i + 1; length >= 3 {
+			matches = append(matches, PasswordPattern{
+				Kind:    PatternSequence,
+				Token:   string(runes[i : j+1]),
+				Start:   i,
+				End:     j + 1,
+				Guesses: sequenceGuesses(runes[i], length, delta < 0),
+			})
+		}
+		i = j
+	}
+
+	return matches
+}
+
+// sequenceGuesses stima i tentativi per una sequenza
+func sequenceGuesses(first rune, length int, descending bool) float64 {
+	var base float64
+	switch {
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if unicode.IsUpper(first) {
+		base *= 2
+	}
+	if descending {
+		base *= 2
+	}
+	return base * float64(length)
+}
+
+// sameCharClass verifica che due caratteri siano della stessa classe
+func sameCharClass(a, b rune) bool {
+	switch {
+	case unicode.IsLower(a):
+		return unicode.IsLower(b)
+	case unicode.IsUpper(a):
+		return unicode.IsUpper(b)
+	case unicode.IsDigit(a):
+		return unicode.IsDigit(b)
+	default:
+		return false
+	}
+}
+
+// ---- Avvisi ----
+
+// strengthWarnings produce i suggerimenti per l'utente
+func strengthWarnings(runes []rune, patterns []PasswordPattern) []string {
+	seen := map[string]bool{}
+	var warnings []string
+	add := func(w string) {
+		if !seen[w] {
+			seen[w] = true
+			warnings = append(warnings, w)
+		}
+	}
+
+	if len(runes) < 12 {
+		add("Password corta: usa almeno 12 caratteri")
+	}
+
+	for _, p := range patterns {
+		switch p.Kind {
+		case PatternDictionary:
+			if strings.HasSuffix(p.Detail, "(l33t)") {
+				add("Le sostituzioni prevedibili come '@' al posto di 'a' non aiutano molto")
+			} else {
+				add("Contiene una parola comune o una password nota")
+			}
+		case PatternKeyboard:
+			add("Le sequenze di tasti vicini sono facili da indovinare")
+		case PatternDate:
+			add("Date e anni sono facili da indovinare")
+		case PatternRepeat:
+			add("Ripetizioni come 'aaa' o 'abcabc' sono facili da indovinare")
+		case PatternSequence:
+			add("Sequenze come 'abc' o '123' sono facili da indovinare")
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,128 @@
+package kdbx
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// findPattern ritorna il primo pattern del tipo dato nella scomposizione
+func findPattern(strength PasswordStrength, kind string) (PasswordPattern, bool) {
+	for _, p := range strength.Patterns {
+		if p.Kind == kind {
+			return p, true
+		}
+	}
+	return PasswordPattern{}, false
+}
+
+func TestEstimateStrengthPatterns(t *testing.T) {
+	tests := []struct {
+		password string
+		kind     string
+		token    string
+		detail   string // Vuoto = non controllato
+	}{
+		{"password", PatternDictionary, "password", "password"},
+		{"Dragon", PatternDictionary, "Dragon", "dragon"},
+		{"drowssap", PatternDictionary, "drowssap", "password (al contrario)"},
+		{"p@ssw0rd", PatternDictionary, "p@ssw0rd", "password (l33t)"},
+		{"wsxcde", PatternKeyboard, "wsxcde", ""},
+		{"zaq12wsx", PatternKeyboard, "zaq12wsx", ""},
+		{"15/08/1987", PatternDate, "15/08/1987", "1987"},
+		{"19870815", PatternDate, "19870815", "1987"},
+		{"zzzzzzzz", PatternRepeat, "zzzzzzzz", "z"},
+		{"xkqxkqxkq", PatternRepeat, "xkqxkqxkq", "xkq"},
+		{"lmnopqr", PatternSequence, "lmnopqr", ""},
+		{"98765", PatternSequence, "98765", ""},
+	}
+
+	for _, tt := range tests {
+		strength := EstimateStrength(tt.password)
+		p, ok := findPattern(strength, tt.kind)
+		if !ok {
+			t.Errorf("%q: nessun pattern %s in %+v", tt.password, tt.kind, strength.Patterns)
+			continue
+		}
+		if p.Token != tt.token {
+			t.Errorf("%q: token %s = %q, atteso %q", tt.password, tt.kind, p.Token, tt.token)
+		}
+		if tt.detail != "" && p.Detail != tt.detail {
+			t.Errorf("%q: dettaglio = %q, atteso %q", tt.password, p.Detail, tt.detail)
+		}
+		if strength.Score > ScoreFair {
+			t.Errorf("%q: punteggio %s, atteso al massimo %s", tt.password, strength.Score, ScoreFair)
+		}
+	}
+}
+
+func TestEstimateStrengthScores(t *testing.T) {
+	tests := []struct {
+		password string
+		min, max StrengthScore
+	}{
+		{"", ScoreVeryWeak, ScoreVeryWeak},
+		{"123456", ScoreVeryWeak, ScoreVeryWeak},
+		{"Password1", ScoreVeryWeak, ScoreWeak},
+		{"correct horse battery staple", ScoreStrong, ScoreVeryStrong},
+		{"rT7#qL2v!mZ9@wX4", ScoreVeryStrong, ScoreVeryStrong},
+	}
+
+	for _, tt := range tests {
+		strength := EstimateStrength(tt.password)
+		if strength.Score < tt.min || strength.Score > tt.max {
+			t.Errorf("%q: punteggio %s (%.1f bit), atteso tra %s e %s",
+				tt.password, strength.Score, strength.Entropy, tt.min, tt.max)
+		}
+	}
+}
+
+func TestEstimateStrengthRepeatsAreFast(t *testing.T) {
+	// Ogni blocco veniva stimato di nuovo per ogni posizione e lunghezza:
+	// 100 "a" richiedevano circa 20 secondi
+	passwords := []string{
+		strings.Repeat("a", 100),
+		strings.Repeat("ab", 50),
+		strings.Repeat("xkq", 34)[:100],
+		strings.Repeat("aaab", 25),
+		strings.Repeat("password", 13)[:100],
+	}
+
+	for _, password := range passwords {
+		start := time.Now()
+		strength := EstimateStrength(password)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%q: stima in %v", password[:12], elapsed)
+		}
+		if p, ok := findPattern(strength, PatternRepeat); !ok || p.Start != 0 {
+			t.Errorf("%q: ripetizione %+v", password[:12], p)
+		}
+		if strength.Score > ScoreFair {
+			t.Errorf("%q: punteggio %s", password[:12], strength.Score)
+		}
+	}
+}
+
+func TestEstimateStrengthLongPasswordIsFinite(t *testing.T) {
+	for _, length := range []int{150, 300, 1000} {
+		password := strings.Repeat("aZ3#kQ9!", length/8+1)[:length]
+		strength := EstimateStrength(password)
+		if math.IsInf(strength.Entropy, 0) || math.IsNaN(strength.Entropy) {
+			t.Errorf("lunghezza %d: entropia %v", length, strength.Entropy)
+		}
+		if math.IsInf(strength.Guesses, 0) || math.IsNaN(strength.Guesses) {
+			t.Errorf("lunghezza %d: tentativi %v", length, strength.Guesses)
+		}
+		if strength.Score != ScoreVeryStrong {
+			t.Errorf("lunghezza %d: punteggio %s", length, strength.Score)
+		}
+	}
+
+	// Oltre strengthMaxLength si usa la forza bruta: l'entropia cresce
+	short := EstimateStrength(strings.Repeat("x7", 100)).Entropy
+	long := EstimateStrength(strings.Repeat("x7", 400)).Entropy
+	if long <= short {
+		t.Errorf("entropia di 800 caratteri (%.0f) non maggiore di 200 (%.0f)", long, short)
+	}
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// maxTagSuggestions è il numero di tag suggeriti sotto il campo
+const maxTagSuggestions = 5
+
+// newTagInput crea il campo dei tag ("lavoro; banca") con i suggerimenti
+// presi dai tag già usati nel database: mentre si scrive un tag compaiono
+// quelli che iniziano con lo stesso testo e un clic lo completa
+func newTagInput(known []kdbx.TagCount, tags []string) (*widget.Entry, fyne.CanvasObject) {
+	input := widget.NewEntry()
+	input.PlaceHolder = "tag separati da ;"
+	input.SetText(strings.Join(tags, "; "))
+
+	suggestions := container.NewHBox()
+
+	input.OnChanged = func(text string) {
+		suggestions.RemoveAll()
+
+		// Solo l'ultimo tag, quello che si sta scrivendo, viene completato
+		start := strings.LastIndexAny(text, ";,") + 1
+		current := strings.ToLower(strings.TrimSpace(text[start:]))
+		if current == "" {
+			suggestions.Refresh()
+			return
+		}
+
+		used := map[string]bool{}
+		for _, tag := range kdbx.ParseTags(text[:start]) {
+			used[strings.ToLower(tag)] = true
+		}
+
+		for _, candidate := range known {
+			lower := strings.ToLower(candidate.Tag)
+			if used[lower] || lower == current || !strings.HasPrefix(lower, current) {
+				continue
+			}
+
+			prefix, tag := text[:start], candidate.Tag
+			if prefix != "" {
+				prefix += " "
+			}
+			suggestions.Add(widget.NewButton(tag, func() {
+				input.SetText(prefix + tag + "; ")
+				input.CursorColumn = len([]rune(input.Text))
+				input.Refresh()
+			}))
+			if len(suggestions.Objects) == maxTagSuggestions {
+				break
+			}
+		}
+		suggestions.Refresh()
+	}
+
+	return input, container.NewVBox(input, suggestions)
+}
+
+// inputTags ritorna i tag scritti nel campo
+func inputTags(input *widget.Entry) []string {
+	return kdbx.ParseTags(input.Text)
+}
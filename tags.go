@@ -0,0 +1,82 @@
+package kdbx
+
+import (
+	"sort"
+	"strings"
+)
+
+// tagSeparators sono i separatori accettati nel campo Tags: KDBX 4.1 usa
+// ";" ma KeePassXC e KeePass 2 accettano anche ","
+const tagSeparators = ";,"
+
+// ParseTags divide il campo Tags in tag puliti, senza vuoti né duplicati
+// (il confronto ignora maiuscole e accenti, vince la prima grafia)
+func ParseTags(field string) []string {
+	var tags []string
+	seen := map[string]bool{}
+
+	for _, tag := range strings.FieldsFunc(field, func(r rune) bool {
+		return strings.ContainsRune(tagSeparators, r)
+	}) {
+		tag = strings.TrimSpace(tag)
+		key := normalizeSearchText(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// JoinTags ricompone i tag nel formato KDBX 4.1 ("a;b;c")
+func JoinTags(tags []string) string {
+	return strings.Join(ParseTags(strings.Join(tags, ";")), ";")
+}
+
+// HasTag indica se la entry ha il tag, ignorando maiuscole e accenti
+func (e Entry) HasTag(tag string) bool {
+	key := normalizeSearchText(strings.TrimSpace(tag))
+	for _, t := range e.Tags {
+		if normalizeSearchText(t) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// TagCount è un tag con il numero di entries che lo usano
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// CountTags conta i tag delle entries, ordinati per nome
+func CountTags(entries []Entry) []TagCount {
+	index := map[string]int{}
+	var counts []TagCount
+
+	for _, e := range entries {
+		for _, tag := range e.Tags {
+			key := normalizeSearchText(tag)
+			i, ok := index[key]
+			if !ok {
+				i = len(counts)
+				index[key] = i
+				counts = append(counts, TagCount{Tag: tag})
+			}
+			counts[i].Count++
+		}
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		return strings.ToLower(counts[i].Tag) < strings.ToLower(counts[j].Tag)
+	})
+	return counts
+}
+
+// AllTags ritorna i tag usati nel database con il loro conteggio
+func (db *Database) AllTags() []TagCount {
+	return CountTags(db.GetAllEntries())
+}
@@ -0,0 +1,317 @@
+package kdbx
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+// Campi in cui KeePassXC e i plugin KeePass salvano il seed TOTP: "otp"
+// contiene un URI otpauth://, "TOTP Seed" e "TOTP Settings" sono il
+// formato legacy (seed base32 e "periodo;cifre", con "S" per Steam)
+const (
+	otpFieldKey          = "otp"
+	legacyTOTPSeedKey    = "TOTP Seed"
+	legacyTOTPSettingKey = "TOTP Settings"
+)
+
+// isOTPField indica se il campo contiene il seed TOTP, gestito da Entry.OTP
+func isOTPField(key string) bool {
+	return key == otpFieldKey || key == legacyTOTPSeedKey || key == legacyTOTPSettingKey
+}
+
+// OTPAlgorithm è la funzione di hash dell'HMAC (RFC 6238)
+type OTPAlgorithm string
+
+const (
+	OTPSHA1   OTPAlgorithm = "SHA1"
+	OTPSHA256 OTPAlgorithm = "SHA256"
+	OTPSHA512 OTPAlgorithm = "SHA512"
+)
+
+// newHash ritorna il costruttore dell'hash
+func (a OTPAlgorithm) newHash() func() hash.Hash {
+	switch a {
+	case OTPSHA256:
+		return sha256.New
+	case OTPSHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// Valori di default e limiti dei codici
+const (
+	defaultOTPDigits = 6
+	defaultOTPPeriod = 30
+	steamOTPDigits   = 5
+	minOTPDigits     = 6
+	maxOTPDigits     = 10
+)
+
+// steamAlphabet è l'alfabeto dei codici Steam Guard
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// TOTP è la configurazione di un generatore di codici a tempo (RFC 6238)
+type TOTP struct {
+	Secret    []byte
+	Digits    int
+	Period    int // Secondi di validità di un codice
+	Algorithm OTPAlgorithm
+	Steam     bool // Codici Steam Guard (5 caratteri alfanumerici)
+	Issuer    string
+	Account   string
+}
+
+// ParseOTPURI legge un URI otpauth://totp/... (formato Google
+// Authenticator, usato da KeePassXC), compreso encoder=steam
+func ParseOTPURI(uri string) (*TOTP, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("URI OTP non valido: atteso otpauth://totp/...")
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return nil, fmt.Errorf("tipo OTP non supportato: %s (solo TOTP)", u.Host)
+	}
+
+	query := u.Query()
+	t := &TOTP{
+		Digits:    defaultOTPDigits,
+		Period:    defaultOTPPeriod,
+		Algorithm: OTPSHA1,
+		Issuer:    query.Get("issuer"),
+	}
+
+	// L'etichetta è "issuer:account" oppure solo "account". L'issuer del
+	// parametro ha la precedenza e può contenere a sua volta ':'
+	label := strings.TrimPrefix(u.Path, "/")
+	if account, found := strings.CutPrefix(label, t.Issuer+":"); found && t.Issuer != "" {
+		t.Account = strings.TrimSpace(account)
+	} else if issuer, account, found := strings.Cut(label, ":"); found {
+		if t.Issuer == "" {
+			t.Issuer = issuer
+		}
+		t.Account = strings.TrimSpace(account)
+	} else {
+		t.Account = label
+	}
+
+	if t.Secret, err = decodeOTPSecret(query.Get("secret")); err != nil {
+		return nil, err
+	}
+	if value := query.Get("digits"); value != "" {
+		if t.Digits, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("numero di cifre non valido: %s", value)
+		}
+	}
+	if value := query.Get("period"); value != "" {
+		if t.Period, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("periodo non valido: %s", value)
+		}
+	}
+	if value := query.Get("algorithm"); value != "" {
+		t.Algorithm = OTPAlgorithm(strings.ToUpper(value))
+	}
+	// Gli URI esportati da Steam Guard spesso indicano solo issuer=Steam
+	steamIssuer := strings.EqualFold(t.Issuer, "Steam") && query.Get("digits") == ""
+	if strings.EqualFold(query.Get("encoder"), "steam") || steamIssuer {
+		t.Steam = true
+		t.Digits = steamOTPDigits
+	}
+
+	return t, t.validate()
+}
+
+// ParseLegacyTOTP legge il formato dei campi "TOTP Seed" e "TOTP Settings"
+// ("30;6", "30;S" per Steam, opzionalmente ";SHA256")
+func ParseLegacyTOTP(seed, settings string) (*TOTP, error) {
+	t := &TOTP{
+		Digits:    defaultOTPDigits,
+		Period:    defaultOTPPeriod,
+		Algorithm: OTPSHA1,
+	}
+
+	var err error
+	if t.Secret, err = decodeOTPSecret(seed); err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(settings, ";")
+	if settings = strings.TrimSpace(settings); settings != "" {
+		if t.Period, err = strconv.Atoi(strings.TrimSpace(parts[0])); err != nil {
+			return nil, fmt.Errorf("impostazioni TOTP non valide: %s", settings)
+		}
+	}
+	if len(parts) > 1 {
+		digits := strings.TrimSpace(parts[1])
+		if strings.EqualFold(digits, "S") {
+			t.Steam = true
+			t.Digits = steamOTPDigits
+		} else if t.Digits, err = strconv.Atoi(digits); err != nil {
+			return nil, fmt.Errorf("impostazioni TOTP non valide: %s", settings)
+		}
+	}
+	if len(parts) > 2 {
+		t.Algorithm = OTPAlgorithm(strings.ToUpper(strings.TrimSpace(parts[2])))
+	}
+
+	return t, t.validate()
+}
+
+// decodeOTPSecret decodifica un seed base32, tollerando spazi, trattini,
+// minuscole e padding mancante
+func decodeOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret))
+	if secret == "" {
+		return nil, fmt.Errorf("seed TOTP mancante")
+	}
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("seed TOTP non valido: deve essere in base32")
+	}
+	return key, nil
+}
+
+// validate controlla che i parametri siano utilizzabili
+func (t *TOTP) validate() error {
+	switch t.Algorithm {
+	case OTPSHA1, OTPSHA256, OTPSHA512:
+	default:
+		return fmt.Errorf("algoritmo TOTP non supportato: %s", t.Algorithm)
+	}
+	if t.Period <= 0 {
+		return fmt.Errorf("il periodo TOTP deve essere positivo")
+	}
+	if !t.Steam && (t.Digits < minOTPDigits || t.Digits > maxOTPDigits) {
+		return fmt.Errorf("il codice TOTP deve avere da %d a %d cifre", minOTPDigits, maxOTPDigits)
+	}
+	return nil
+}
+
+// Code calcola il codice valido all'istante indicato
+func (t *TOTP) Code(now time.Time) string {
+	return t.codeAt(uint64(now.Unix()) / uint64(t.Period))
+}
+
+// codeAt calcola il codice per il contatore indicato
+func (t *TOTP) codeAt(counter uint64) string {
+	value := hotpValue(t.Secret, counter, t.Algorithm)
+
+	if t.Steam {
+		code := make([]byte, steamOTPDigits)
+		for i := range code {
+			code[i] = steamAlphabet[value%uint32(len(steamAlphabet))]
+			value /= uint32(len(steamAlphabet))
+		}
+		return string(code)
+	}
+
+	modulo := uint64(1)
+	for i := 0; i < t.Digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", t.Digits, uint64(value)%modulo)
+}
+
+// Remaining ritorna per quanto il codice corrente resta valido
+func (t *TOTP) Remaining(now time.Time) time.Duration {
+	period := time.Duration(t.Period) * time.Second
+	return period - time.Duration(now.UnixNano())%period
+}
+
+// HOTP calcola un codice a contatore (RFC 4226) con le cifre indicate
+func HOTP(secret []byte, counter uint64, digits int) string {
+	t := &TOTP{Secret: secret, Digits: digits, Algorithm: OTPSHA1}
+	return t.codeAt(counter)
+}
+
+// hotpValue applica HMAC e troncamento dinamico (RFC 4226, sezione 5.3)
+func hotpValue(secret []byte, counter uint64, algorithm OTPAlgorithm) uint32 {
+	mac := hmac.New(algorithm.newHash(), secret)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	return binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+}
+
+// URI ritorna la configurazione come URI otpauth://, il formato salvato
+// nel campo "otp"
+func (t *TOTP) URI() string {
+	// Senza issuer un account con ':' verrebbe letto come "issuer:account"
+	label := t.Account
+	if t.Issuer != "" || strings.Contains(t.Account, ":") {
+		label = t.Issuer + ":" + t.Account
+	}
+
+	query := url.Values{}
+	query.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(t.Secret))
+	if t.Issuer != "" {
+		query.Set("issuer", t.Issuer)
+	}
+	query.Set("period", strconv.Itoa(t.Period))
+	query.Set("digits", strconv.Itoa(t.Digits))
+	query.Set("algorithm", string(t.Algorithm))
+	if t.Steam {
+		query.Set("encoder", "steam")
+	}
+
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// TOTP ritorna il generatore della entry, nil se la entry non ha un seed
+func (e Entry) TOTP() (*TOTP, error) {
+	if e.OTP == "" {
+		return nil, nil
+	}
+	return ParseOTPURI(e.OTP)
+}
+
+// entryOTP legge il seed della entry come URI, convertendo il formato
+// legacy; un seed legacy illeggibile viene ignorato
+func entryOTP(entry *gokeepasslib.Entry) string {
+	if uri := entry.GetContent(otpFieldKey); uri != "" {
+		return uri
+	}
+
+	seed := entry.GetContent(legacyTOTPSeedKey)
+	if seed == "" {
+		return ""
+	}
+	t, err := ParseLegacyTOTP(seed, entry.GetContent(legacyTOTPSettingKey))
+	if err != nil {
+		return ""
+	}
+	t.Account = entry.GetTitle()
+	return t.URI()
+}
+
+// applyEntryOTP salva il seed nel campo "otp"; i campi esistenti restano
+// intatti se il seed non è cambiato, così il formato legacy sopravvive
+func applyEntryOTP(entry *gokeepasslib.Entry, uri string) {
+	if entryOTP(entry) == uri {
+		return
+	}
+
+	removeEntryValue(entry, legacyTOTPSeedKey)
+	removeEntryValue(entry, legacyTOTPSettingKey)
+	if uri == "" {
+		removeEntryValue(entry, otpFieldKey)
+		return
+	}
+	setEntryValue(entry, otpFieldKey, uri, true)
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// totpQRSize è il lato in pixel dell'immagine del QR code
+const totpQRSize = 320
+
+// secretParam trova il seed nell'URI otpauth:// per mascherarlo
+var secretParam = regexp.MustCompile(`(?i)(secret=)[^&]*`)
+
+// showTOTPQR mostra il QR code dell'URI otpauth:// della entry, da
+// inquadrare con l'app di autenticazione del nuovo telefono. Il QR è
+// generato in locale; QR e seed restano nascosti finché non si sceglie
+// di mostrarli
+func (mw *MainWindow) showTOTPQR(entry kdbx.Entry, totp *kdbx.TOTP) {
+	// Le app mostrano issuer e account: senza, usa titolo e username
+	export := *totp
+	if export.Issuer == "" {
+		export.Issuer = entry.Title
+	}
+	if export.Account == "" || export.Account == entry.Title {
+		export.Account = entry.Username
+	}
+	uri := export.URI()
+	maskedURI := secretParam.ReplaceAllString(uri, "${1}••••••••")
+
+	qr, err := qrcode.New(uri, qrcode.Medium)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("errore generazione QR code: %w", err), mw.Window)
+		return
+	}
+
+	image := canvas.NewImageFromImage(qr.Image(totpQRSize))
+	image.FillMode = canvas.ImageFillContain
+	image.ScaleMode = canvas.ImageScalePixels
+	image.SetMinSize(fyne.NewSize(totpQRSize, totpQRSize))
+	image.Hide()
+
+	hiddenLabel := widget.NewLabelWithStyle("QR code nascosto: contiene il seed TOTP",
+		fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+
+	uriLabel := widget.NewLabel(maskedURI)
+	uriLabel.Wrapping = fyne.TextWrapBreak
+
+	var revealBtn *widget.Button
+	revealBtn = widget.NewButton("Mostra QR e seed", func() {
+		if image.Hidden {
+			image.Show()
+			hiddenLabel.Hide()
+			uriLabel.SetText(uri)
+			revealBtn.SetText("Nascondi")
+			return
+		}
+		image.Hide()
+		hiddenLabel.Show()
+		uriLabel.SetText(maskedURI)
+		revealBtn.SetText("Mostra QR e seed")
+	})
+
+	info := widget.NewLabel("Inquadra il codice con l'app di autenticazione. " +
+		"Chiunque veda il QR può generare i tuoi codici: mostralo solo in un luogo riservato.")
+	info.Wrapping = fyne.TextWrapWord
+
+	// Lo spazio del QR resta riservato anche quando è nascosto
+	placeholder := canvas.NewRectangle(color.Transparent)
+	placeholder.SetMinSize(fyne.NewSize(totpQRSize, totpQRSize))
+
+	content := container.NewVBox(
+		info,
+		container.NewStack(placeholder, container.NewCenter(hiddenLabel), container.NewCenter(image)),
+		uriLabel,
+		revealBtn,
+	)
+
+	d := dialog.NewCustom(fmt.Sprintf("QR code TOTP — %s", entry.Title), "Chiudi", content, mw.Window)
+	d.Resize(fyne.NewSize(480, 560))
+	d.Show()
+}
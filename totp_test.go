@@ -0,0 +1,170 @@
+package kdbx
+
+import (
+	"testing"
+	"time"
+)
+
+// Seed dei vettori di test dell'RFC 6238, uno per algoritmo
+var rfc6238Secrets = map[OTPAlgorithm]string{
+	OTPSHA1:   "12345678901234567890",
+	OTPSHA256: "12345678901234567890123456789012",
+	OTPSHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+}
+
+func TestTOTPRFC6238(t *testing.T) {
+	tests := []struct {
+		unix  int64
+		codes map[OTPAlgorithm]string
+	}{
+		{59, map[OTPAlgorithm]string{OTPSHA1: "94287082", OTPSHA256: "46119246", OTPSHA512: "90693936"}},
+		{1111111109, map[OTPAlgorithm]string{OTPSHA1: "07081804", OTPSHA256: "68084774", OTPSHA512: "25091201"}},
+		{1111111111, map[OTPAlgorithm]string{OTPSHA1: "14050471", OTPSHA256: "67062674", OTPSHA512: "99943326"}},
+		{1234567890, map[OTPAlgorithm]string{OTPSHA1: "89005924", OTPSHA256: "91819424", OTPSHA512: "93441116"}},
+		{2000000000, map[OTPAlgorithm]string{OTPSHA1: "69279037", OTPSHA256: "90698825", OTPSHA512: "38618901"}},
+		{20000000000, map[OTPAlgorithm]string{OTPSHA1: "65353130", OTPSHA256: "77737706", OTPSHA512: "47863826"}},
+	}
+
+	for _, tt := range tests {
+		for algorithm, want := range tt.codes {
+			totp := &TOTP{Secret: []byte(rfc6238Secrets[algorithm]), Digits: 8, Period: 30, Algorithm: algorithm}
+			if code := totp.Code(time.Unix(tt.unix, 0)); code != want {
+				t.Errorf("%s a %d: codice %s, atteso %s", algorithm, tt.unix, code, want)
+			}
+		}
+	}
+}
+
+func TestHOTPRFC4226(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, code := range want {
+		if got := HOTP([]byte("12345678901234567890"), uint64(counter), 6); got != code {
+			t.Errorf("contatore %d: codice %s, atteso %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPSteam(t *testing.T) {
+	// Stesso seed e istante del primo vettore RFC 6238: il valore troncato
+	// 1094287082 scritto in base 26 con l'alfabeto di Steam
+	totp, err := ParseLegacyTOTP("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "30;S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := totp.Code(time.Unix(59, 0)); code != "PV9M4" {
+		t.Errorf("codice Steam %s, atteso PV9M4", code)
+	}
+	if remaining := totp.Remaining(time.Unix(59, 0)); remaining != time.Second {
+		t.Errorf("validità residua %v, attesa 1s", remaining)
+	}
+}
+
+func TestParseLegacyTOTP(t *testing.T) {
+	const seed = "JBSW Y3DP-EHPK3PXP"
+
+	tests := []struct {
+		settings  string
+		period    int
+		digits    int
+		algorithm OTPAlgorithm
+		steam     bool
+	}{
+		{"", 30, 6, OTPSHA1, false},
+		{"60", 60, 6, OTPSHA1, false},
+		{"30;8", 30, 8, OTPSHA1, false},
+		{"30;S", 30, 5, OTPSHA1, true},
+		{"30;6;SHA256", 30, 6, OTPSHA256, false},
+		{"30; 6; sha512", 30, 6, OTPSHA512, false},
+	}
+
+	for _, tt := range tests {
+		totp, err := ParseLegacyTOTP(seed, tt.settings)
+		if err != nil {
+			t.Errorf("%q: %v", tt.settings, err)
+			continue
+		}
+		if totp.Period != tt.period || totp.Digits != tt.digits || totp.Algorithm != tt.algorithm || totp.Steam != tt.steam {
+			t.Errorf("%q: %+v", tt.settings, totp)
+		}
+		if string(totp.Secret) != "Hello!\xde\xad\xbe\xef" {
+			t.Errorf("%q: seed %x", tt.settings, totp.Secret)
+		}
+	}
+
+	invalid := []struct{ seed, settings string }{
+		{"", "30;6"},
+		{"non-base32!", "30;6"},
+		{seed, "trenta"},
+		{seed, "30;sei"},
+		{seed, "30;4"},
+		{seed, "0;6"},
+		{seed, "30;6;MD5"},
+	}
+	for _, tt := range invalid {
+		if _, err := ParseLegacyTOTP(tt.seed, tt.settings); err == nil {
+			t.Errorf("seed %q, impostazioni %q: nessun errore", tt.seed, tt.settings)
+		}
+	}
+}
+
+func TestOTPURIRoundTrip(t *testing.T) {
+	tests := []TOTP{
+		{Digits: 6, Period: 30, Algorithm: OTPSHA1, Issuer: "Example", Account: "mario@example.com"},
+		{Digits: 8, Period: 60, Algorithm: OTPSHA512, Account: "solo account"},
+		{Digits: 5, Period: 30, Algorithm: OTPSHA1, Steam: true, Issuer: "Steam", Account: "mario"},
+		// Etichette con ':' nell'account, nell'issuer o in entrambi
+		{Digits: 6, Period: 30, Algorithm: OTPSHA256, Issuer: "Example", Account: "mario:lavoro"},
+		{Digits: 6, Period: 30, Algorithm: OTPSHA1, Account: "mario:lavoro"},
+		{Digits: 6, Period: 30, Algorithm: OTPSHA1, Issuer: "ACME: VPN", Account: "a:b"},
+	}
+
+	for _, want := range tests {
+		want.Secret = []byte("Hello!\xde\xad\xbe\xef")
+		uri := want.URI()
+		got, err := ParseOTPURI(uri)
+		if err != nil {
+			t.Errorf("%s: %v", uri, err)
+			continue
+		}
+		if string(got.Secret) != string(want.Secret) || got.Digits != want.Digits || got.Period != want.Period ||
+			got.Algorithm != want.Algorithm || got.Steam != want.Steam ||
+			got.Issuer != want.Issuer || got.Account != want.Account {
+			t.Errorf("%s:\nletto  %+v\natteso %+v", uri, got, want)
+		}
+	}
+}
+
+func TestParseOTPURI(t *testing.T) {
+	// Formato di Google Authenticator: issuer solo nell'etichetta,
+	// parametri di default e seed in minuscolo senza padding
+	totp, err := ParseOTPURI("otpauth://totp/Example:%20mario@example.com?secret=jbswy3dpehpk3pxp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totp.Issuer != "Example" || totp.Account != "mario@example.com" ||
+		totp.Digits != 6 || totp.Period != 30 || totp.Algorithm != OTPSHA1 || totp.Steam {
+		t.Errorf("%+v", totp)
+	}
+
+	// Steam Guard esporta solo issuer=Steam
+	if totp, err := ParseOTPURI("otpauth://totp/Steam:mario?secret=JBSWY3DPEHPK3PXP&issuer=Steam"); err != nil || !totp.Steam || totp.Digits != 5 {
+		t.Errorf("Steam: %+v, %v", totp, err)
+	}
+
+	for _, uri := range []string{
+		"https://example.com/?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://hotp/Example?secret=JBSWY3DPEHPK3PXP&counter=1",
+		"otpauth://totp/Example",
+		"otpauth://totp/Example?secret=JBSWY3DPEHPK3PXP&digits=sei",
+		"otpauth://totp/Example?secret=JBSWY3DPEHPK3PXP&digits=12",
+		"otpauth://totp/Example?secret=JBSWY3DPEHPK3PXP&period=-30",
+		"otpauth://totp/Example?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+	} {
+		if _, err := ParseOTPURI(uri); err == nil {
+			t.Errorf("%s: nessun errore", uri)
+		}
+	}
+}
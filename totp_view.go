@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gabriel1/keepassgo/pkg/kdbx"
+)
+
+// newTOTPView crea il riquadro del codice TOTP nel pannello dettagli: il
+// codice corrente, una barra con il tempo rimasto, la copia e il QR code.
+// Il timer precedente viene fermato, così ne resta attivo uno solo
+func (mw *MainWindow) newTOTPView(entry kdbx.Entry, totp *kdbx.TOTP) fyne.CanvasObject {
+	mw.stopTOTPTimer()
+
+	codeLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true, Monospace: true})
+	countdown := widget.NewProgressBar()
+	countdown.Max = float64(totp.Period)
+	countdown.TextFormatter = func() string {
+		return fmt.Sprintf("%.0f s", countdown.Value)
+	}
+
+	update := func() {
+		now := time.Now()
+		codeLabel.SetText(formatTOTPCode(totp.Code(now)))
+		countdown.SetValue(totp.Remaining(now).Seconds())
+	}
+	update()
+
+	copyBtn := widget.NewButton("Copia codice", func() {
+		mw.Window.Clipboard().SetContent(totp.Code(time.Now()))
+		mw.recordUsage(entry)
+	})
+	qrBtn := widget.NewButton("QR code", func() {
+		mw.showTOTPQR(entry, totp)
+	})
+
+	ticker := time.NewTicker(time.Second)
+	done := make(chan struct{})
+	mw.stopTOTP = func() {
+		ticker.Stop()
+		close(done)
+	}
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(update)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return container.NewBorder(nil, nil, codeLabel, container.NewHBox(copyBtn, qrBtn), countdown)
+}
+
+// stopTOTPTimer ferma l'aggiornamento del codice TOTP mostrato
+func (mw *MainWindow) stopTOTPTimer() {
+	if mw.stopTOTP != nil {
+		mw.stopTOTP()
+		mw.stopTOTP = nil
+	}
+}
+
+// formatTOTPCode divide il codice in due metà per leggerlo meglio
+func formatTOTPCode(code string) string {
+	if len(code) < 6 {
+		return code
+	}
+	half := len(code) / 2
+	return code[:half] + " " + code[half:]
+}
+
+// totpDigitsOptions sono le lunghezze del codice proposte nella
+// configurazione manuale; "Steam" sceglie i codici Steam Guard
+var totpDigitsOptions = []string{"6", "7", "8", "Steam"}
+
+// showTOTPSetup configura il seed TOTP di una entry incollando un URI
+// otpauth:// oppure il seed base32 con i suoi parametri. onDone riceve il
+// nuovo URI ("" per rimuovere il TOTP)
+func (mw *MainWindow) showTOTPSetup(entry kdbx.Entry, onDone func(uri string)) {
+	// L'URI contiene il seed (secret=...): resta nascosto come il seed,
+	// con il pulsante per mostrarlo
+	uriEntry := widget.NewPasswordEntry()
+	uriEntry.PlaceHolder = "otpauth://totp/..."
+	uriEntry.SetText(entry.OTP)
+
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.PlaceHolder = "seed base32 (es. JBSW Y3DP EHPK 3PXP)"
+
+	digitsSelect := widget.NewSelect(totpDigitsOptions, nil)
+	digitsSelect.SetSelected("6")
+
+	periodEntry := numberEntry(30, "30")
+
+	algorithmSelect := widget.NewSelect([]string{string(kdbx.OTPSHA1), string(kdbx.OTPSHA256), string(kdbx.OTPSHA512)}, nil)
+	algorithmSelect.SetSelected(string(kdbx.OTPSHA1))
+
+	previewLabel := widget.NewLabel("")
+	previewLabel.Wrapping = fyne.TextWrapWord
+
+	// build ricava la configurazione dall'URI o, se vuoto, dal seed
+	build := func() (*kdbx.TOTP, error) {
+		if strings.TrimSpace(uriEntry.Text) != "" {
+			return kdbx.ParseOTPURI(uriEntry.Text)
+		}
+
+		settings := strconv.Itoa(numberValue(periodEntry))
+		if periodEntry.Text == "" {
+			settings = "30"
+		}
+		if digitsSelect.Selected == "Steam" {
+			settings += ";S"
+		} else {
+			settings += ";" + digitsSelect.Selected
+		}
+		settings += ";" + algorithmSelect.Selected
+
+		totp, err := kdbx.ParseLegacyTOTP(secretEntry.Text, settings)
+		if err != nil {
+			return nil, err
+		}
+		totp.Account = entry.Username
+		totp.Issuer = entry.Title
+		return totp, nil
+	}
+
+	preview := func(string) {
+		if strings.TrimSpace(uriEntry.Text) == "" && secretEntry.Text == "" {
+			previewLabel.SetText("Incolla un URI otpauth:// oppure il seed")
+			return
+		}
+		totp, err := build()
+		if err != nil {
+			previewLabel.SetText(err.Error())
+			return
+		}
+		previewLabel.SetText(fmt.Sprintf("Codice attuale: %s", formatTOTPCode(totp.Code(time.Now()))))
+	}
+	uriEntry.OnChanged = preview
+	secretEntry.OnChanged = preview
+	digitsSelect.OnChanged = preview
+	algorithmSelect.OnChanged = preview
+	periodEntry.OnChanged = preview
+	preview("")
+
+	var d dialog.Dialog
+	saveBtn := widget.NewButton("Salva", func() {
+		totp, err := build()
+		if err != nil {
+			dialog.ShowError(err, mw.Window)
+			return
+		}
+		d.Hide()
+		onDone(totp.URI())
+	})
+	removeBtn := widget.NewButton("Rimuovi TOTP", func() {
+		d.Hide()
+		onDone("")
+	})
+	if entry.OTP == "" {
+		removeBtn.Disable()
+	}
+
+	content := container.NewVBox(
+		widget.NewForm(widget.NewFormItem("URI", uriEntry)),
+		widget.NewLabel("oppure, se non hai un URI:"),
+		widget.NewForm(
+			widget.NewFormItem("Seed", secretEntry),
+			widget.NewFormItem("Cifre", digitsSelect),
+			widget.NewFormItem("Periodo (secondi)", periodEntry),
+			widget.NewFormItem("Algoritmo", algorithmSelect),
+		),
+		previewLabel,
+		container.NewHBox(saveBtn, removeBtn),
+	)
+
+	d = dialog.NewCustom("Configura TOTP", "Annulla", content, mw.Window)
+	d.Resize(fyne.NewSize(520, 420))
+	d.Show()
+}
@@ -1,10 +1,14 @@
 package kdbx
 
 import (
+	"crypto/rand"
 	"fmt"
+	"math"
 	"os"
+	"strings"
 
 	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
 )
 
 // CipherType rappresenta il tipo di cifratura
@@ -17,19 +21,35 @@ const (
 
 // ModernCiphers contiene solo cifrari moderni sicuri
 var ModernCiphers = map[CipherType]string{
-	CipherAES256:    "AES-256",
-	CipherChaCha20:  "ChaCha20",
+	CipherAES256:   "AES-256",
+	CipherChaCha20: "ChaCha20",
+}
+
+// KDBXFormat è la versione del formato del file
+type KDBXFormat int
+
+const (
+	FormatKDBX4  KDBXFormat = iota // Argon2, AES-256 o ChaCha20
+	FormatKDBX31                   // AES-KDF e AES-256, per i client più vecchi
+)
+
+// KDBXFormats contiene i formati con cui si può creare un database
+var KDBXFormats = map[KDBXFormat]string{
+	FormatKDBX4:  "KDBX 4",
+	FormatKDBX31: "KDBX 3.1",
 }
 
 // SaveOptions opzioni per il salvataggio del database
 type SaveOptions struct {
-	FilePath     string
-	Password     string
-	CipherType   CipherType
-	// KDF parameters (Argon2id recommended)
-	KDFIterations uint64 // Raccomandato: 10+
-	KDFMemory     uint64 // Raccomandato: 1GB (1048576 KB)
+	FilePath   string
+	Password   string
+	CipherType CipherType
+	Format     KDBXFormat
+	// Parametri di Argon2 (solo KDBX 4)
+	KDFIterations  uint64 // Raccomandato: 10+
+	KDFMemory      uint64 // Raccomandato: 1GB (1048576 KB)
 	KDFParallelism uint32 // Raccomandato: 4
+	KDFRounds      uint64 // Solo KDBX 3.1: round di AES-KDF
 }
 
 // DefaultSaveOptions ritorna opzioni sicure di default
@@ -38,15 +58,26 @@ func DefaultSaveOptions(filePath, password string) SaveOptions {
 		FilePath:       filePath,
 		Password:       password,
 		CipherType:     CipherChaCha20, // ChaCha20 come default (moderno e veloce)
-		KDFIterations:  10,              // 10 iterazioni Argon2
-		KDFMemory:      1048576,         // 1GB di RAM
-		KDFParallelism: 4,               // 4 thread paralleli
+		Format:         FormatKDBX4,
+		KDFIterations:  10,      // 10 iterazioni Argon2
+		KDFMemory:      1048576, // 1GB di RAM
+		KDFParallelism: 4,       // 4 thread paralleli
+		KDFRounds:      1000000, // Solo KDBX 3.1
 	}
 }
 
-// CreateNewDatabase crea un nuovo database con cifrari moderni
+// CreateNewDatabase crea un nuovo database con il formato, il cifrario e
+// i parametri di derivazione della chiave di opts
 func CreateNewDatabase(opts SaveOptions) (*Database, error) {
-	db := gokeepasslib.NewDatabase()
+	var db *gokeepasslib.Database
+	switch opts.Format {
+	case FormatKDBX4:
+		db = gokeepasslib.NewDatabase(gokeepasslib.WithDatabaseKDBXVersion4())
+	case FormatKDBX31:
+		db = gokeepasslib.NewDatabase(gokeepasslib.WithDatabaseKDBXVersion3())
+	default:
+		return nil, fmt.Errorf("formato del database non supportato")
+	}
 
 	// Imposta le credenziali
 	db.Credentials = gokeepasslib.NewPasswordCredentials(opts.Password)
@@ -57,16 +88,17 @@ func CreateNewDatabase(opts SaveOptions) (*Database, error) {
 		return nil, fmt.Errorf("errore impostazione cifratura: %w", err)
 	}
 
-	// Crea gruppo root di default
+	// Crea gruppo root di default, con un UUID: le impostazioni dei gruppi
+	// nel CustomData sono indicizzate per UUID
+	root := gokeepasslib.NewGroup()
+	root.Name = "Root"
+	root.Groups = []gokeepasslib.Group{}
+	root.Entries = []gokeepasslib.Entry{}
 	db.Content = &gokeepasslib.DBContent{
+		InnerHeader: db.Content.InnerHeader, // Solo KDBX 4
+		Meta:        gokeepasslib.NewMetaData(),
 		Root: &gokeepasslib.RootData{
-			Groups: []gokeepasslib.Group{
-				{
-					Name: "Root",
-					Groups: []gokeepasslib.Group{},
-					Entries: []gokeepasslib.Entry{},
-				},
-			},
+			Groups: []gokeepasslib.Group{root},
 		},
 	}
 
@@ -83,17 +115,20 @@ func (db *Database) Save(opts SaveOptions) error {
 		return fmt.Errorf("cipher non supportato: usa solo AES-256 o ChaCha20")
 	}
 
-	// Aggiorna encryption settings
-	err := setModernEncryption(db.Database, opts)
-	if err != nil {
-		return fmt.Errorf("errore impostazione cifratura: %w", err)
-	}
+	// Formato, cifrario e KDF di un database esistente restano quelli del
+	// file: si scelgono quando il database viene creato. I tempi vanno
+	// scritti nel formato del file: le entries nuove li hanno in ISO 8601,
+	// che KeePass non accetta nei KDBX 4
+	setContentTimeFormat(db.Content, !db.Header.IsKdbx4())
 
-	// Locka le entries protette prima di salvare
-	err = db.Database.LockProtectedEntries()
+	// Locka le entries protette prima di salvare. L'encoder le lascia
+	// cifrate in memoria: al termine vanno sbloccate di nuovo, altrimenti
+	// il database aperto mostrerebbe le password cifrate
+	err := db.Database.LockProtectedEntries()
 	if err != nil {
 		return fmt.Errorf("errore lock entries: %w", err)
 	}
+	defer db.Database.UnlockProtectedEntries()
 
 	// Crea/apri file per scrittura
 	file, err := os.Create(opts.FilePath)
@@ -113,16 +148,51 @@ func (db *Database) Save(opts SaveOptions) error {
 	return nil
 }
 
-// setModernEncryption configura cifratura moderna e sicura
+// setModernEncryption imposta nell'header di un nuovo database il
+// cifrario e i parametri di derivazione della chiave di opts
 func setModernEncryption(db *gokeepasslib.Database, opts SaveOptions) error {
-	// gokeepasslib usa automaticamente:
-	// - AES-256 o ChaCha20 (dipende dalla versione)
-	// - Argon2 per KDF (default in .kdbx v4)
-	// - .kdbx v4 format
+	if !isModernCipher(opts.CipherType) {
+		return fmt.Errorf("cipher non supportato: usa solo AES-256 o ChaCha20")
+	}
+	headers := db.Header.FileHeaders
+
+	if !db.Header.IsKdbx4() {
+		// I KDBX 3.1 hanno solo AES-KDF, e KeePass vi usa solo AES-256
+		if opts.CipherType != CipherAES256 {
+			return fmt.Errorf("ChaCha20 richiede il formato KDBX 4")
+		}
+		if opts.KDFRounds == 0 {
+			return fmt.Errorf("i round di AES-KDF devono essere almeno 1")
+		}
+		headers.TransformRounds = opts.KDFRounds
+		return nil
+	}
+
+	switch {
+	case opts.KDFIterations == 0 || opts.KDFIterations > math.MaxUint32:
+		return fmt.Errorf("iterazioni Argon2 non valide: %d", opts.KDFIterations)
+	case opts.KDFParallelism == 0 || opts.KDFParallelism > math.MaxUint8:
+		return fmt.Errorf("parallelismo Argon2 non valido: %d (da 1 a %d)", opts.KDFParallelism, math.MaxUint8)
+	case opts.KDFMemory < 8*uint64(opts.KDFParallelism) || opts.KDFMemory > math.MaxUint32:
+		// Argon2 richiede almeno 8 KB per thread
+		return fmt.Errorf("memoria Argon2 non valida: %d KB", opts.KDFMemory)
+	}
 
-	// La libreria gestisce automaticamente i cifrari moderni
-	// Non è necessario configurare manualmente (API non espone questi campi)
+	if opts.CipherType == CipherAES256 {
+		// Il vettore di inizializzazione di AES è di 16 byte, quello
+		// di ChaCha20 (il default dei KDBX 4) di 12
+		headers.CipherID = gokeepasslib.CipherAES
+		headers.EncryptionIV = make([]byte, 16)
+		if _, err := rand.Read(headers.EncryptionIV); err != nil {
+			return err
+		}
+	}
 
+	// La libreria supporta solo Argon2d, con la memoria in byte
+	kdf := headers.KdfParameters
+	kdf.Iterations = opts.KDFIterations
+	kdf.Memory = opts.KDFMemory * 1024
+	kdf.Parallelism = opts.KDFParallelism
 	return nil
 }
 
@@ -132,38 +202,388 @@ func isModernCipher(cipher CipherType) bool {
 	return exists
 }
 
+// maxHistoryItems è il numero massimo di versioni precedenti conservate
+// per ogni entry (stesso default di KeePass/KeePassXC)
+const maxHistoryItems = 10
+
 // AddEntry aggiunge una password al database
 func (db *Database) AddEntry(groupPath, title, username, password, url, notes string) error {
+	_, err := db.CreateEntry(Entry{
+		Title:     title,
+		Username:  username,
+		Password:  password,
+		URL:       url,
+		Notes:     notes,
+		GroupPath: groupPath,
+	})
+	return err
+}
+
+// CreateEntry aggiunge una entry completa (inclusa la scadenza) nel gruppo
+// indicato da e.GroupPath e la ritorna con il nuovo UUID. Ritorna un
+// *PolicyError se la password viola una policy bloccante del gruppo
+func (db *Database) CreateEntry(e Entry) (Entry, error) {
 	if db.Content == nil || db.Content.Root == nil {
-		return fmt.Errorf("database non inizializzato correttamente")
+		return Entry{}, fmt.Errorf("database non inizializzato correttamente")
 	}
 
+	// Una entry nuova non ha ancora un UUID (e una password da far scadere)
+	e.UUID = gokeepasslib.UUID{}
+	if err := db.enforcePasswordPolicy(e); err != nil {
+		return Entry{}, err
+	}
+
+	return db.addEntry(e), nil
+}
+
+// addEntry crea la entry nel suo gruppo, creando i gruppi mancanti, senza
+// controllare la policy
+func (db *Database) addEntry(e Entry) Entry {
 	// Trova o crea il gruppo
-	group := db.findOrCreateGroup(groupPath)
+	group := db.findOrCreateGroup(e.GroupPath)
 
 	// Crea la nuova entry
 	entry := gokeepasslib.NewEntry()
-	entry.Values = append(entry.Values,
-		gokeepasslib.ValueData{Key: "Title", Value: gokeepasslib.V{Content: title}},
-		gokeepasslib.ValueData{Key: "UserName", Value: gokeepasslib.V{Content: username}},
-		gokeepasslib.ValueData{Key: "Password", Value: gokeepasslib.V{Content: password}},
-		gokeepasslib.ValueData{Key: "URL", Value: gokeepasslib.V{Content: url}},
-		gokeepasslib.ValueData{Key: "Notes", Value: gokeepasslib.V{Content: notes}},
-	)
+	applyEntryFields(&entry, e)
+	for _, attachment := range e.Attachments {
+		if attachment.Data != nil {
+			db.addAttachment(&entry, attachment)
+		}
+	}
 
 	// La libreria proteggerà automaticamente la password durante il salvataggio
 
 	group.Entries = append(group.Entries, entry)
+
+	e.UUID = entry.UUID
+	return e
+}
+
+// UpdateEntry modifica la entry con lo stesso UUID, salvando la versione
+// precedente nella history. Se GroupPath è cambiato la entry viene spostata.
+// Ritorna un *PolicyError se la password viola una policy bloccante
+func (db *Database) UpdateEntry(e Entry) error {
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return fmt.Errorf("database non inizializzato correttamente")
+	}
+
+	parent, index := findEntry(&db.Content.Root.Groups[0], e.UUID)
+	if parent == nil {
+		return fmt.Errorf("entry non trovata")
+	}
+
+	if err := db.enforcePasswordPolicy(e); err != nil {
+		return err
+	}
+
+	return db.updateEntry(parent, index, e, false)
+}
+
+// updateEntry applica la modifica alla entry in parent.Entries[index],
+// senza controllare la policy. Gli allegati con Data vengono aggiunti; con
+// replaceAttachments sostituiscono quelli esistenti, che restano solo
+// nella history
+func (db *Database) updateEntry(parent *gokeepasslib.Group, index int, e Entry, replaceAttachments bool) error {
+	entry := &parent.Entries[index]
+	addToHistory(entry)
+	applyEntryFields(entry, e)
+	if replaceAttachments {
+		entry.Binaries = nil
+	}
+	for _, attachment := range e.Attachments {
+		if attachment.Data != nil {
+			db.addAttachment(entry, attachment)
+		}
+	}
+
+	now := w.Now()
+	entry.Times.LastModificationTime = &now
+
+	if target, _ := db.findGroup(e.GroupPath); target != parent {
+		return db.MoveEntry(e.UUID, e.GroupPath)
+	}
+
 	return nil
 }
 
+// applyEntryFields copia i campi di una Entry nella entry gokeepasslib
+func applyEntryFields(entry *gokeepasslib.Entry, e Entry) {
+	// Prima del titolo, da cui dipende l'URI ricavato dai campi legacy
+	applyEntryOTP(entry, e.OTP)
+
+	setEntryValue(entry, "Title", e.Title, false)
+	setEntryValue(entry, "UserName", e.Username, false)
+	setEntryValue(entry, "Password", e.Password, true)
+	setEntryValue(entry, "URL", e.URL, false)
+	setEntryValue(entry, "Notes", e.Notes, false)
+	applyCustomFields(entry, e.Fields)
+	entry.Tags = JoinTags(e.Tags)
+
+	entry.Times.Expires = w.NewBoolWrapper(e.Expires)
+	if e.Expires {
+		expiry := w.Now()
+		expiry.Time = e.ExpiryTime.UTC()
+		entry.Times.ExpiryTime = &expiry
+	}
+}
+
+// setEntryValue imposta un campo della entry, creandolo se non esiste
+func setEntryValue(entry *gokeepasslib.Entry, key, value string, protected bool) {
+	v := gokeepasslib.V{Content: value}
+	if protected {
+		v.Protected = w.NewBoolWrapper(true)
+	}
+
+	if existing := entry.Get(key); existing != nil {
+		existing.Value = v
+		return
+	}
+
+	entry.Values = append(entry.Values, gokeepasslib.ValueData{Key: key, Value: v})
+}
+
+// standardFields sono i campi KDBX mappati sui campi di Entry
+var standardFields = map[string]bool{
+	"Title":    true,
+	"UserName": true,
+	"Password": true,
+	"URL":      true,
+	"Notes":    true,
+}
+
+// applyCustomFields allinea i campi aggiuntivi della entry: quelli non più
+// presenti vengono eliminati, gli altri impostati
+func applyCustomFields(entry *gokeepasslib.Entry, fields []CustomField) {
+	keep := map[string]bool{}
+	for _, field := range fields {
+		keep[field.Key] = true
+	}
+
+	values := entry.Values[:0]
+	for _, value := range entry.Values {
+		if standardFields[value.Key] || isOTPField(value.Key) || keep[value.Key] {
+			values = append(values, value)
+		}
+	}
+	entry.Values = values
+
+	for _, field := range fields {
+		if !standardFields[field.Key] && !isOTPField(field.Key) {
+			setEntryValue(entry, field.Key, field.Value, field.Protected)
+		}
+	}
+}
+
+// removeEntryValue elimina un campo della entry, se esiste
+func removeEntryValue(entry *gokeepasslib.Entry, key string) {
+	for i, value := range entry.Values {
+		if value.Key == key {
+			entry.Values = append(entry.Values[:i], entry.Values[i+1:]...)
+			return
+		}
+	}
+}
+
+// addToHistory salva una copia della entry nella sua history,
+// scartando le versioni più vecchie oltre maxHistoryItems. Una history già
+// oltre il limite (ad esempio dopo MergeDuplicates) torna alla lunghezza
+// che aveva, scartando prima le versioni con una password ancora presente
+// in un'altra: le password delle entries unite restano finché possibile
+func addToHistory(entry *gokeepasslib.Entry) {
+	snapshot := *entry
+	snapshot.Histories = nil
+	snapshot.Values = make([]gokeepasslib.ValueData, len(entry.Values))
+	copy(snapshot.Values, entry.Values)
+
+	if len(entry.Histories) == 0 {
+		entry.Histories = []gokeepasslib.History{{}}
+	}
+
+	history := &entry.Histories[0]
+	limit := maxHistoryItems
+	if len(history.Entries) > limit {
+		limit = len(history.Entries)
+	}
+	history.Entries = append(history.Entries, snapshot)
+	if limit == maxHistoryItems {
+		if len(history.Entries) > limit {
+			history.Entries = history.Entries[len(history.Entries)-limit:]
+		}
+		return
+	}
+
+	for len(history.Entries) > limit {
+		i := redundantHistoryVersion(history.Entries)
+		history.Entries = append(history.Entries[:i], history.Entries[i+1:]...)
+	}
+}
+
+// redundantHistoryVersion sceglie la versione da scartare: la più vecchia
+// la cui password compare anche in un'altra versione, altrimenti la più
+// vecchia in assoluto
+func redundantHistoryVersion(versions []gokeepasslib.Entry) int {
+	count := map[string]int{}
+	for i := range versions {
+		count[versions[i].GetPassword()]++
+	}
+	for i := range versions {
+		if count[versions[i].GetPassword()] > 1 {
+			return i
+		}
+	}
+	return 0
+}
+
 // findOrCreateGroup trova o crea un gruppo dal path
 func (db *Database) findOrCreateGroup(path string) *gokeepasslib.Group {
-	if path == "" || path == "Root" {
-		return &db.Content.Root.Groups[0]
+	group := &db.Content.Root.Groups[0]
+
+	for _, name := range db.relativeGroupNames(path) {
+		child := findSubGroup(group, name)
+		if child == nil {
+			newGroup := gokeepasslib.NewGroup()
+			newGroup.Name = name
+			group.Groups = append(group.Groups, newGroup)
+			child = &group.Groups[len(group.Groups)-1]
+		}
+		group = child
+	}
+
+	return group
+}
+
+// findGroup trova un gruppo dal path, ritornando anche il path del padre.
+// Ritorna nil se il gruppo non esiste
+func (db *Database) findGroup(path string) (*gokeepasslib.Group, string) {
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return nil, ""
+	}
+
+	group := &db.Content.Root.Groups[0]
+	parentPath := ""
+
+	for _, name := range db.relativeGroupNames(path) {
+		child := findSubGroup(group, name)
+		if child == nil {
+			return nil, ""
+		}
+		parentPath = joinGroupPath(parentPath, group.Name)
+		group = child
+	}
+
+	return group, parentPath
+}
+
+// relativeGroupNames divide il path nei nomi dei gruppi sotto il root.
+// Il nome del gruppo root all'inizio del path è opzionale
+func (db *Database) relativeGroupNames(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, PathSeparator) {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) > 0 && names[0] == db.Content.Root.Groups[0].Name {
+		names = names[1:]
+	}
+
+	return names
+}
+
+// findSubGroup cerca un sottogruppo diretto per nome
+func findSubGroup(group *gokeepasslib.Group, name string) *gokeepasslib.Group {
+	for i := range group.Groups {
+		if group.Groups[i].Name == name {
+			return &group.Groups[i]
+		}
+	}
+	return nil
+}
+
+// findEntry cerca ricorsivamente una entry per UUID, ritornando il gruppo
+// che la contiene e l'indice. Ritorna nil se la entry non esiste
+func findEntry(group *gokeepasslib.Group, uuid gokeepasslib.UUID) (*gokeepasslib.Group, int) {
+	for i := range group.Entries {
+		if group.Entries[i].UUID.Compare(uuid) {
+			return group, i
+		}
+	}
+
+	for i := range group.Groups {
+		if parent, index := findEntry(&group.Groups[i], uuid); parent != nil {
+			return parent, index
+		}
+	}
+
+	return nil, -1
+}
+
+// findGroupByUUID cerca ricorsivamente un gruppo per UUID. Ritorna nil se
+// il gruppo non esiste
+func findGroupByUUID(group *gokeepasslib.Group, uuid gokeepasslib.UUID) *gokeepasslib.Group {
+	if group.UUID.Compare(uuid) {
+		return group
+	}
+	for i := range group.Groups {
+		if found := findGroupByUUID(&group.Groups[i], uuid); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// MoveEntry sposta una entry nel gruppo indicato, creandolo se necessario
+func (db *Database) MoveEntry(uuid gokeepasslib.UUID, groupPath string) error {
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return fmt.Errorf("database non inizializzato correttamente")
+	}
+
+	parent, index := findEntry(&db.Content.Root.Groups[0], uuid)
+	if parent == nil {
+		return fmt.Errorf("entry non trovata")
 	}
 
-	// Per semplicità, aggiungi al root
-	// TODO: implementare navigazione path completa
-	return &db.Content.Root.Groups[0]
+	entry := parent.Entries[index]
+	parent.Entries = append(parent.Entries[:index], parent.Entries[index+1:]...)
+
+	// Aggiorna la data di spostamento come fa KeePassXC
+	now := w.Now()
+	entry.Times.LocationChanged = &now
+
+	target := db.findOrCreateGroup(groupPath)
+	target.Entries = append(target.Entries, entry)
+	return nil
+}
+
+// RecordEntryUsage registra un utilizzo della entry (es. copia della
+// password) aggiornando UsageCount e LastAccessTime, così le statistiche
+// viaggiano con il file .kdbx
+func (db *Database) RecordEntryUsage(uuid gokeepasslib.UUID) error {
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return fmt.Errorf("database non inizializzato correttamente")
+	}
+
+	parent, index := findEntry(&db.Content.Root.Groups[0], uuid)
+	if parent == nil {
+		return fmt.Errorf("entry non trovata")
+	}
+
+	entry := &parent.Entries[index]
+	now := w.Now()
+	entry.Times.LastAccessTime = &now
+	entry.Times.UsageCount++
+	return nil
+}
+
+// SetGroupExpanded salva lo stato espanso/collassato di un gruppo
+func (db *Database) SetGroupExpanded(groupPath string, expanded bool) error {
+	group, _ := db.findGroup(groupPath)
+	if group == nil {
+		return fmt.Errorf("gruppo non trovato: %s", groupPath)
+	}
+
+	group.IsExpanded = w.NewBoolWrapper(expanded)
+	return nil
 }
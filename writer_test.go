@@ -0,0 +1,218 @@
+package kdbx
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+)
+
+// testSaveOptions ritorna opzioni di salvataggio con una KDF leggera,
+// per non rallentare i test
+func testSaveOptions(t *testing.T, name, password string) SaveOptions {
+	t.Helper()
+	opts := DefaultSaveOptions(filepath.Join(t.TempDir(), name), password)
+	opts.KDFIterations = 1
+	opts.KDFMemory = 1024
+	opts.KDFParallelism = 1
+	opts.KDFRounds = 1000
+	return opts
+}
+
+func TestSaveKeepsProtectedValuesReadable(t *testing.T) {
+	opts := testSaveOptions(t, "test.kdbx", "master")
+	db, err := CreateNewDatabase(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.CreateEntry(Entry{
+		Title:     "Banca",
+		Username:  "mario",
+		Password:  "segreta",
+		GroupPath: "Finanza",
+		Fields:    []CustomField{{Key: "PIN", Value: "1234", Protected: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(t *testing.T, db *Database) {
+		t.Helper()
+		entries := db.GetAllEntries()
+		if len(entries) != 1 {
+			t.Fatalf("entries = %d, attese 1", len(entries))
+		}
+		e := entries[0]
+		if e.Password != "segreta" {
+			t.Errorf("password = %q, attesa %q", e.Password, "segreta")
+		}
+		if len(e.Fields) != 1 || e.Fields[0].Value != "1234" {
+			t.Errorf("campi = %+v, atteso PIN 1234", e.Fields)
+		}
+	}
+
+	// Due salvataggi di fila: il secondo cifrerebbe valori già cifrati
+	for i := 0; i < 2; i++ {
+		if err := db.Save(opts); err != nil {
+			t.Fatalf("salvataggio %d: %v", i+1, err)
+		}
+		check(t, db)
+	}
+
+	reopened, err := OpenDatabase(opts.FilePath, "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, reopened)
+}
+
+func TestSaveNewDatabaseWithAttachment(t *testing.T) {
+	opts := testSaveOptions(t, "test.kdbx", "master")
+	db, err := CreateNewDatabase(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.CreateEntry(Entry{
+		Title:       "Contratto",
+		Attachments: []Attachment{{Name: "contratto.pdf", Data: []byte("%PDF")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Save(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenDatabase(opts.FilePath, "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := reopened.GetAllEntries()
+	if len(entries) != 1 || len(entries[0].Attachments) != 1 || entries[0].Attachments[0].Name != "contratto.pdf" {
+		t.Fatalf("entries = %+v, atteso un allegato contratto.pdf", entries)
+	}
+}
+
+func TestCreateNewDatabaseFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format KDBXFormat
+		cipher CipherType
+		id     []byte
+	}{
+		{"KDBX 4 ChaCha20", FormatKDBX4, CipherChaCha20, gokeepasslib.CipherChaCha20},
+		{"KDBX 4 AES", FormatKDBX4, CipherAES256, gokeepasslib.CipherAES},
+		{"KDBX 3.1 AES", FormatKDBX31, CipherAES256, gokeepasslib.CipherAES},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testSaveOptions(t, "test.kdbx", "master")
+			opts.Format = tt.format
+			opts.CipherType = tt.cipher
+			opts.KDFIterations = 3
+			opts.KDFMemory = 2048
+			opts.KDFParallelism = 2
+			opts.KDFRounds = 1234
+
+			db, err := CreateNewDatabase(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.CreateEntry(Entry{Title: "Banca", Password: "segreta"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Save(opts); err != nil {
+				t.Fatal(err)
+			}
+
+			// Tempi in base64 nei KDBX 4, in ISO 8601 nei KDBX 3.1
+			times := db.Content.Root.Groups[0].Entries[0].Times
+			if times.CreationTime.Formatted != (tt.format == FormatKDBX31) {
+				t.Errorf("tempi in ISO 8601 = %v", times.CreationTime.Formatted)
+			}
+
+			reopened, err := OpenDatabase(opts.FilePath, "master")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries := reopened.GetAllEntries(); len(entries) != 1 || entries[0].Password != "segreta" {
+				t.Fatalf("entries = %+v", entries)
+			}
+
+			header := reopened.Header
+			if header.IsKdbx4() != (tt.format == FormatKDBX4) {
+				t.Errorf("KDBX 4 = %v", header.IsKdbx4())
+			}
+			if !bytes.Equal(header.FileHeaders.CipherID, tt.id) {
+				t.Errorf("cifrario %x, atteso %x", header.FileHeaders.CipherID, tt.id)
+			}
+			if tt.format == FormatKDBX31 {
+				if header.FileHeaders.TransformRounds != 1234 {
+					t.Errorf("round AES-KDF %d, attesi 1234", header.FileHeaders.TransformRounds)
+				}
+				return
+			}
+			kdf := header.FileHeaders.KdfParameters
+			if kdf.Iterations != 3 || kdf.Memory != 2048*1024 || kdf.Parallelism != 2 {
+				t.Errorf("Argon2: iterazioni %d, memoria %d byte, parallelismo %d",
+					kdf.Iterations, kdf.Memory, kdf.Parallelism)
+			}
+		})
+	}
+}
+
+func TestCreateNewDatabaseInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*SaveOptions)
+	}{
+		{"formato sconosciuto", func(o *SaveOptions) { o.Format = 7 }},
+		{"cifrario sconosciuto", func(o *SaveOptions) { o.CipherType = 7 }},
+		{"KDBX 3.1 con ChaCha20", func(o *SaveOptions) { o.Format = FormatKDBX31 }},
+		{"KDBX 3.1 senza round", func(o *SaveOptions) {
+			o.Format, o.CipherType, o.KDFRounds = FormatKDBX31, CipherAES256, 0
+		}},
+		{"0 iterazioni", func(o *SaveOptions) { o.KDFIterations = 0 }},
+		{"parallelismo 0", func(o *SaveOptions) { o.KDFParallelism = 0 }},
+		{"parallelismo 256", func(o *SaveOptions) { o.KDFParallelism = 256 }},
+		{"memoria insufficiente", func(o *SaveOptions) { o.KDFMemory, o.KDFParallelism = 8, 4 }},
+	}
+
+	for _, tt := range tests {
+		opts := testSaveOptions(t, "test.kdbx", "master")
+		tt.change(&opts)
+		if _, err := CreateNewDatabase(opts); err == nil {
+			t.Errorf("%s: nessun errore", tt.name)
+		}
+	}
+}
+
+// entryByTitle ritorna l'entry con il titolo dato
+func entryByTitle(t *testing.T, entries []Entry, title string) Entry {
+	t.Helper()
+	for _, e := range entries {
+		if e.Title == title {
+			return e
+		}
+	}
+	t.Fatalf("nessuna entry %q tra %d", title, len(entries))
+	return Entry{}
+}
+
+// historyPasswords ritorna le password delle versioni nella history
+func historyPasswords(t *testing.T, db *Database, uuid gokeepasslib.UUID) map[string]bool {
+	t.Helper()
+	parent, index := findEntry(&db.Content.Root.Groups[0], uuid)
+	if parent == nil {
+		t.Fatal("entry non trovata")
+	}
+	passwords := map[string]bool{}
+	for _, h := range parent.Entries[index].Histories {
+		for _, version := range h.Entries {
+			passwords[version.GetPassword()] = true
+		}
+	}
+	return passwords
+}